@@ -0,0 +1,40 @@
+#!/bin/bash
+
+setUp() {
+  rm test*.json test*.yml || true
+  cat >test.json <<EOL
+{"items": [{"name": "cat", "id": 1}, {"name": "dog", "id": 2}]}
+EOL
+}
+
+testStreamJson() {
+  X=$(./yq --stream -o=json -I=0 '.' test.json | head -2)
+  expected='[["items",0,"name"],"cat"]
+[["items",0,"id"],1]'
+  assertEquals "$expected" "$X"
+}
+
+testStreamPluck() {
+  X=$(./yq --stream -r 'select(.[0][2] == "name") | .[1]' test.json)
+  expected='cat
+dog'
+  assertEquals "$expected" "$X"
+}
+
+testStreamReassemble() {
+  X=$(./yq ea --stream -o=json -I=0 '[.] | fromstream(.[])' test.json)
+  assertEquals '{"items":[{"name":"cat","id":1},{"name":"dog","id":2}]}' "$X"
+}
+
+testStreamYaml() {
+  cat >test.yml <<EOL
+a: [1]
+EOL
+  X=$(./yq --stream -o=json -I=0 '.' test.yml)
+  expected='[["a",0],1]
+[["a",0]]
+[["a"]]'
+  assertEquals "$expected" "$X"
+}
+
+source ./scripts/shunit2
@@ -0,0 +1,60 @@
+#!/bin/bash
+
+setUp() {
+  rm test*.yml test*.txt || true
+  cat >test.yml <<EOL
+a: 1
+---
+b: 2
+EOL
+  echo "hello" >test.txt
+}
+
+testArg() {
+  X=$(./yq -n --arg name "mike" '.name = $name')
+  assertEquals "name: mike" "$X"
+}
+
+testArgIsAlwaysAString() {
+  X=$(./yq -n --arg v 3 '$v | tag')
+  assertEquals "!!str" "$X"
+}
+
+testArgJson() {
+  X=$(./yq -n -o=json -I=0 --argjson v '{"a": [1, 2]}' '$v.a')
+  assertEquals "[1,2]" "$X"
+}
+
+testRawFile() {
+  X=$(./yq -n --rawfile v test.txt '$v')
+  assertEquals "hello" "$X"
+}
+
+testSlurpFile() {
+  X=$(./yq -n -o=json -I=0 --slurpfile v test.yml '$v')
+  assertEquals '[{"a":1},{"b":2}]' "$X"
+}
+
+testArgsEval() {
+  X=$(./yq -n -o=json -I=0 --args '$__prog_args' a b)
+  assertEquals '["a","b"]' "$X"
+}
+
+testArgEvalAll() {
+  X=$(./yq ea --arg v cat '. as $item ireduce ({}; . * $item) | .c = $v' test.yml)
+  expected=$(cat <<EOM
+a: 1
+b: 2
+c: cat
+EOM
+)
+  assertEquals "$expected" "$X"
+}
+
+testArgMissingValue() {
+  X=$(./yq -n '$v' --arg v 2>&1)
+  assertEquals 1 $?
+  assertEquals "Error: --arg requires a name and a value, e.g. --arg name value" "$X"
+}
+
+source ./scripts/shunit2
@@ -37,3 +37,17 @@ var completedSuccessfully = false
 var forceExpression = ""
 
 var expressionFile = ""
+
+// variables set with --arg, --argjson, --rawfile and --slurpfile, each as name=value
+var stringVariables = []string{}
+var jsonVariables = []string{}
+var rawFileVariables = []string{}
+var slurpFileVariables = []string{}
+
+// when set, positional arguments after the expression are given to the
+// expression as $__prog_args rather than being treated as files
+var positionalArgsAsVariables = false
+var programArgs = []string{}
+
+// when set, each document is read as a series of [path, leaf] events (--stream)
+var streamEvents = false
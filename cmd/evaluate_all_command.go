@@ -119,12 +119,17 @@ func evaluateAll(cmd *cobra.Command, args []string) (cmdError error) {
 		defer frontMatterHandler.CleanUp()
 	}
 
-	allAtOnceEvaluator := yqlib.NewAllAtOnceEvaluator()
+	variables, err := configureVariables()
+	if err != nil {
+		return err
+	}
+	allAtOnceEvaluator := yqlib.NewAllAtOnceEvaluatorWithVariables(variables)
 
 	switch len(args) {
 	case 0:
 		if nullInput {
-			err = yqlib.NewStreamEvaluator().EvaluateNew(processExpression(expression), printer)
+			streamEvaluator := yqlib.NewStreamEvaluatorWithVariables(variables)
+			err = streamEvaluator.EvaluateNew(processExpression(expression), printer)
 		} else {
 			cmd.Println(cmd.UsageString())
 			return nil
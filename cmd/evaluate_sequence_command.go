@@ -118,7 +118,11 @@ func evaluateSequence(cmd *cobra.Command, args []string) (cmdError error) {
 	if err != nil {
 		return err
 	}
-	streamEvaluator := yqlib.NewStreamEvaluator()
+	variables, err := configureVariables()
+	if err != nil {
+		return err
+	}
+	streamEvaluator := yqlib.NewStreamEvaluatorWithVariables(variables)
 
 	if frontMatter != "" {
 		yqlib.GetLogger().Debug("using front matter handler")
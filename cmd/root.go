@@ -165,6 +165,11 @@ yq -P -oy sample.json
 	if err = rootCmd.RegisterFlagCompletionFunc("shell-key-separator", cobra.NoFileCompletions); err != nil {
 		panic(err)
 	}
+	rootCmd.PersistentFlags().BoolVar(&yqlib.ConfiguredShellVariablesPreferences.UnflattenKeys, "shell-unflatten", yqlib.ConfiguredShellVariablesPreferences.UnflattenKeys, "un-flatten shell variable keys into nested maps and arrays on the key separator when decoding")
+
+	rootCmd.PersistentFlags().BoolVar(&yqlib.ConfiguredPlistPreferences.Binary, "plist-binary", yqlib.ConfiguredPlistPreferences.Binary, "output binary plists instead of xml plists")
+
+	rootCmd.PersistentFlags().BoolVar(&yqlib.ConfiguredJSONLPreferences.SkipInvalidLines, "jsonl-skip-invalid", yqlib.ConfiguredJSONLPreferences.SkipInvalidLines, "skip (and warn about) lines that are not valid JSON when decoding JSON Lines")
 
 	rootCmd.PersistentFlags().BoolVar(&yqlib.StringInterpolationEnabled, "string-interpolation", yqlib.StringInterpolationEnabled, "Toggles strings interpolation of \\(exp)")
 
@@ -212,6 +217,18 @@ yq -P -oy sample.json
 		panic(err)
 	}
 
+	rootCmd.PersistentFlags().StringArrayVarP(&yqlib.ConfiguredLibraryPaths, "library-path", "L", []string{}, "directory to search for modules used by import and include. Can be given multiple times, defaults to $HOME/.yq")
+	if err = rootCmd.MarkPersistentFlagDirname("library-path"); err != nil {
+		panic(err)
+	}
+
+	rootCmd.PersistentFlags().StringArrayVarP(&stringVariables, "arg", "", []string{}, "set $name to the given string, e.g. --arg name value. Can be given multiple times.")
+	rootCmd.PersistentFlags().StringArrayVarP(&jsonVariables, "argjson", "", []string{}, "set $name to the given yaml/json value, e.g. --argjson name '{\"a\": 1}'. Can be given multiple times.")
+	rootCmd.PersistentFlags().StringArrayVarP(&rawFileVariables, "rawfile", "", []string{}, "set $name to the contents of the given file as a string, e.g. --rawfile name file.txt. Can be given multiple times.")
+	rootCmd.PersistentFlags().StringArrayVarP(&slurpFileVariables, "slurpfile", "", []string{}, "set $name to an array of the documents in the given file, e.g. --slurpfile name file.yaml. Can be given multiple times.")
+	rootCmd.PersistentFlags().BoolVarP(&positionalArgsAsVariables, "args", "", false, "treat the arguments after the expression as strings in $__prog_args, instead of files.")
+	rootCmd.PersistentFlags().BoolVarP(&streamEvents, "stream", "", false, "read each document as a series of [path, leaf] events, like jq's --stream. JSON input is read incrementally.")
+
 	rootCmd.PersistentFlags().BoolVarP(&yqlib.ConfiguredSecurityPreferences.DisableEnvOps, "security-disable-env-ops", "", false, "Disable env related operations.")
 	rootCmd.PersistentFlags().BoolVarP(&yqlib.ConfiguredSecurityPreferences.DisableFileOps, "security-disable-file-ops", "", false, "Disable file related operations (e.g. load)")
 	rootCmd.PersistentFlags().BoolVarP(&yqlib.ConfiguredSecurityPreferences.EnableSystemOps, "security-enable-system-operator", "", false, "Enable system operator to allow execution of external commands.")
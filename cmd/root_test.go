@@ -254,6 +254,13 @@ func TestNew_FlagCompletions(t *testing.T) {
 		"front-matter",
 		"expression",
 		"split-exp",
+		"library-path",
+		"arg",
+		"argjson",
+		"rawfile",
+		"slurpfile",
+		"args",
+		"stream",
 	}
 
 	for _, flagName := range flags {
@@ -173,10 +173,16 @@ func configureDecoder(evaluateTogether bool) (yqlib.Decoder, error) {
 	if format.DecoderFactory == nil {
 		return nil, fmt.Errorf("no support for %s input format", inputFormat)
 	}
+	if streamEvents && format == yqlib.JSONFormat {
+		return yqlib.NewJSONStreamDecoder(), nil
+	}
 	yqlibDecoder := format.DecoderFactory()
 	if yqlibDecoder == nil {
 		return nil, fmt.Errorf("no support for %s input format", inputFormat)
 	}
+	if streamEvents {
+		return yqlib.NewStreamDecoder(yqlibDecoder), nil
+	}
 	return yqlibDecoder, nil
 }
 
@@ -279,7 +285,19 @@ func processStdInArgs(args []string) []string {
 
 func processArgs(originalArgs []string) (string, []string, error) {
 	expression := forceExpression
-	args := processStdInArgs(originalArgs)
+	args := originalArgs
+
+	if positionalArgsAsVariables {
+		// everything after the expression is a value for $__prog_args, not a file
+		if expression == "" && expressionFile == "" && len(args) > 0 {
+			expression = args[0]
+			args = args[1:]
+		}
+		programArgs = args
+		args = []string{}
+	}
+
+	args = processStdInArgs(args)
 	maybeFirstArgIsAFile := len(args) > 0 && maybeFile(args[0])
 
 	if expressionFile == "" && maybeFirstArgIsAFile && strings.HasSuffix(args[0], ".yq") {
@@ -306,3 +324,74 @@ func processArgs(originalArgs []string) (string, []string, error) {
 	}
 	return expression, args, nil
 }
+
+// variable flags take two arguments (e.g. --arg name value), which cobra does not support.
+var variableFlags = map[string]bool{
+	"--arg":       true,
+	"--argjson":   true,
+	"--rawfile":   true,
+	"--slurpfile": true,
+}
+
+// ExpandVariableFlags rewrites jq style variable flags (e.g. --arg name value) into
+// a single argument (--arg=name=value) so they can be parsed as regular flags.
+func ExpandVariableFlags(args []string) []string {
+	expanded := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			return append(expanded, args[i:]...)
+		}
+		if variableFlags[args[i]] && i+2 < len(args) {
+			expanded = append(expanded, fmt.Sprintf("%v=%v=%v", args[i], args[i+1], args[i+2]))
+			i = i + 2
+			continue
+		}
+		expanded = append(expanded, args[i])
+	}
+	return expanded
+}
+
+func splitVariableFlag(flag string, value string) (string, string, error) {
+	name, variableValue, found := strings.Cut(value, "=")
+	if !found || name == "" {
+		return "", "", fmt.Errorf("--%v requires a name and a value, e.g. --%v name value", flag, flag)
+	}
+	return name, variableValue, nil
+}
+
+func addVariables(variables yqlib.Variables, flag string, values []string, create func(string) (*yqlib.CandidateNode, error)) error {
+	for _, value := range values {
+		name, variableValue, err := splitVariableFlag(flag, value)
+		if err != nil {
+			return err
+		}
+		node, err := create(variableValue)
+		if err != nil {
+			return fmt.Errorf("--%v %v: %w", flag, name, err)
+		}
+		variables[name] = node
+	}
+	return nil
+}
+
+func configureVariables() (yqlib.Variables, error) {
+	variables := yqlib.Variables{}
+
+	stringVariable := func(value string) (*yqlib.CandidateNode, error) {
+		return yqlib.NewStringVariable(value), nil
+	}
+	if err := addVariables(variables, "arg", stringVariables, stringVariable); err != nil {
+		return nil, err
+	}
+	if err := addVariables(variables, "argjson", jsonVariables, yqlib.ParseVariable); err != nil {
+		return nil, err
+	}
+	if err := addVariables(variables, "rawfile", rawFileVariables, yqlib.ReadFileVariable); err != nil {
+		return nil, err
+	}
+	if err := addVariables(variables, "slurpfile", slurpFileVariables, yqlib.SlurpFileVariable); err != nil {
+		return nil, err
+	}
+	variables["__prog_args"] = yqlib.NewStringArrayVariable(programArgs)
+	return variables, nil
+}
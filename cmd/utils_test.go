@@ -203,6 +203,7 @@ func TestConfigureDecoder(t *testing.T) {
 		name             string
 		inputFormat      string
 		evaluateTogether bool
+		streamEvents     bool
 		expectError      bool
 		expectType       string
 	}{
@@ -227,6 +228,22 @@ func TestConfigureDecoder(t *testing.T) {
 			expectError:      false,
 			expectType:       "xmlDecoder",
 		},
+		{
+			name:             "yaml format with stream",
+			inputFormat:      "yaml",
+			evaluateTogether: false,
+			streamEvents:     true,
+			expectError:      false,
+			expectType:       "streamDecoder",
+		},
+		{
+			name:             "json format with stream",
+			inputFormat:      "json",
+			evaluateTogether: false,
+			streamEvents:     true,
+			expectError:      false,
+			expectType:       "jsonStreamDecoder",
+		},
 		{
 			name:             "invalid format",
 			inputFormat:      "invalid",
@@ -240,9 +257,14 @@ func TestConfigureDecoder(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Save original value
 			originalInputFormat := inputFormat
-			defer func() { inputFormat = originalInputFormat }()
+			originalStreamEvents := streamEvents
+			defer func() {
+				inputFormat = originalInputFormat
+				streamEvents = originalStreamEvents
+			}()
 
 			inputFormat = tt.inputFormat
+			streamEvents = tt.streamEvents
 
 			decoder, err := configureDecoder(tt.evaluateTogether)
 			if tt.expectError {
@@ -1458,3 +1480,104 @@ func TestConfigureUnwrapScalar(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandVariableFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "no variable flags",
+			args:     []string{"-n", ".a"},
+			expected: []string{"-n", ".a"},
+		},
+		{
+			name:     "arg",
+			args:     []string{"--arg", "name", "value", ".a = $name"},
+			expected: []string{"--arg=name=value", ".a = $name"},
+		},
+		{
+			name:     "multiple flags",
+			args:     []string{"--argjson", "a", "{}", "--rawfile", "b", "file.txt", "."},
+			expected: []string{"--argjson=a={}", "--rawfile=b=file.txt", "."},
+		},
+		{
+			name:     "missing value",
+			args:     []string{".", "--slurpfile", "a"},
+			expected: []string{".", "--slurpfile", "a"},
+		},
+		{
+			name:     "after double dash",
+			args:     []string{"--", "--arg", "name", "value"},
+			expected: []string{"--", "--arg", "name", "value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExpandVariableFlags(tt.args)
+			if fmt.Sprintf("%q", result) != fmt.Sprintf("%q", tt.expected) {
+				t.Errorf("ExpandVariableFlags() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessArgsWithArgs(t *testing.T) {
+	originalPositionalArgsAsVariables := positionalArgsAsVariables
+	defer func() {
+		positionalArgsAsVariables = originalPositionalArgsAsVariables
+		programArgs = []string{}
+	}()
+
+	positionalArgsAsVariables = true
+	expression, args, err := processArgs([]string{"$__prog_args", "a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expression != "$__prog_args" {
+		t.Errorf("expected expression '$__prog_args', got %q", expression)
+	}
+	if len(args) > 1 {
+		t.Errorf("expected no file args, got %v", args)
+	}
+	if fmt.Sprintf("%q", programArgs) != fmt.Sprintf("%q", []string{"a", "b"}) {
+		t.Errorf("expected program args [a b], got %q", programArgs)
+	}
+}
+
+func TestConfigureVariables(t *testing.T) {
+	originalStringVariables := stringVariables
+	originalJSONVariables := jsonVariables
+	defer func() {
+		stringVariables = originalStringVariables
+		jsonVariables = originalJSONVariables
+	}()
+
+	stringVariables = []string{"name=a=b"}
+	jsonVariables = []string{"value=[1, 2]"}
+
+	variables, err := configureVariables()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if variables["name"].Value != "a=b" {
+		t.Errorf("expected $name to be 'a=b', got %q", variables["name"].Value)
+	}
+	if len(variables["value"].Content) != 2 {
+		t.Errorf("expected $value to have 2 items, got %v", len(variables["value"].Content))
+	}
+	if len(variables["__prog_args"].Content) != 0 {
+		t.Errorf("expected $__prog_args to be empty, got %v", len(variables["__prog_args"].Content))
+	}
+
+	jsonVariables = []string{"value"}
+	_, err = configureVariables()
+	if err == nil {
+		t.Fatal("expected error for missing value")
+	}
+	if err.Error() != "--argjson requires a name and a value, e.g. --argjson name value" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
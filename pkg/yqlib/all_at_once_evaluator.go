@@ -17,11 +17,17 @@ type Evaluator interface {
 
 type allAtOnceEvaluator struct {
 	treeNavigator DataTreeNavigator
+	variables     Variables
 }
 
 func NewAllAtOnceEvaluator() Evaluator {
+	return NewAllAtOnceEvaluatorWithVariables(nil)
+}
+
+// NewAllAtOnceEvaluatorWithVariables creates an evaluator where the given variables are available to the expression, e.g. $name
+func NewAllAtOnceEvaluatorWithVariables(variables Variables) Evaluator {
 	InitExpressionParser()
-	return &allAtOnceEvaluator{treeNavigator: NewDataTreeNavigator()}
+	return &allAtOnceEvaluator{treeNavigator: NewDataTreeNavigator(), variables: variables}
 }
 
 func (e *allAtOnceEvaluator) EvaluateNodes(expression string, nodes ...*CandidateNode) (*list.List, error) {
@@ -37,7 +43,7 @@ func (e *allAtOnceEvaluator) EvaluateCandidateNodes(expression string, inputCand
 	if err != nil {
 		return nil, err
 	}
-	context, err := e.treeNavigator.GetMatchingNodes(Context{MatchingNodes: inputCandidates}, node)
+	context, err := e.treeNavigator.GetMatchingNodes(newRootContext(inputCandidates, e.variables), node)
 	if err != nil {
 		return nil, err
 	}
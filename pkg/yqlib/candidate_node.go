@@ -103,6 +103,7 @@ type CandidateNode struct {
 
 	Parent *CandidateNode // parent node
 	Key    *CandidateNode // node key, if this is a value from a map (or index in an array)
+	origin *CandidateNode // the node this was first copied from
 
 	LeadingContent string
 
@@ -399,6 +400,11 @@ func (n *CandidateNode) doCopy(cloneContent bool) *CandidateNode {
 		copyKey = n.Key.Copy()
 	}
 
+	origin := n.origin
+	if origin == nil {
+		origin = n
+	}
+
 	clone := &CandidateNode{
 		Kind:  n.Kind,
 		Style: n.Style,
@@ -418,6 +424,7 @@ func (n *CandidateNode) doCopy(cloneContent bool) *CandidateNode {
 
 		Parent: n.Parent,
 		Key:    copyKey,
+		origin: origin,
 
 		LeadingContent: n.LeadingContent,
 
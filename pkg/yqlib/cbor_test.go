@@ -0,0 +1,202 @@
+//go:build !yq_nocbor
+
+package yqlib
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+// the binary inputs and outputs of the scenarios are in hex
+var cborScenarios = []formatScenario{
+	{
+		description:    "Encode CBOR",
+		subdescription: "Each value uses its smallest encoding, e.g. 1.5 is a half precision float.",
+		input:          "name: cat\nage: 3\nweight: 1.5\nnicknames: [kitty]\n",
+		expected:       "a4646e616d6563636174636167650366776569676874f93e00696e69636b6e616d657381656b69747479",
+		scenarioType:   "encode",
+	},
+	{
+		description:    "Decode CBOR",
+		subdescription: "Byte strings are decoded as base64 encoded binary, and dates (tags 0 and 1) as timestamps.",
+		input:          "a364646174614501020304056763726561746564c11a65937d25657072696365fbc0091eb851eb851f",
+		expected:       "data: !!binary AQIDBAU=\ncreated: 2024-01-02T03:04:05Z\nprice: -3.14\n",
+		scenarioType:   "decode",
+	},
+	{
+		description:    "Decode a CBOR sequence",
+		subdescription: "Each item of a CBOR sequence is a separate document.",
+		input:          "a1616101a1616102",
+		expected:       "a: 1\n---\na: 2\n",
+		scenarioType:   "decode",
+	},
+	{
+		description:  "Integers",
+		skipDoc:      true,
+		input:        "[0, 23, 24, 100, 1000, 1000000, 1000000000000, 18446744073709551615, !!int 18446744073709551616, -1, -10, -100, -1000, !!int -18446744073709551617]",
+		expected:     "8e001718181864 1903e8 1a000f4240 1b000000e8d4a51000 1bffffffffffffffff c249010000000000000000 20 29 3863 3903e7 c349010000000000000000",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Floats",
+		skipDoc:      true,
+		input:        "[0.0, -0.0, 1.0, 1.1, 65504.0, 100000.0, 1.0e+300, 5.960464477539063e-8, -4.0, .inf, -.inf, .nan]",
+		expected:     "8c f90000 f98000 f93c00 fb3ff199999999999a f97bff fa47c35000 fb7e37e43c8800759c f90001 f9c400 f97c00 f9fc00 f97e00",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Scalars",
+		skipDoc:      true,
+		input:        "[false, true, null, \"\", a, \"ü\", !!binary aGVsbG8=, 2013-03-21T20:04:00Z, 2013-03-21T20:04:00.5Z]",
+		expected:     "89 f4 f5 f6 60 6161 62c3bc 4568656c6c6f c11a514b67b0 c1fb41d452d9ec200000",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Dates that lose precision as floats are date/time strings",
+		skipDoc:      true,
+		input:        "2600-01-01T00:00:00.123456789Z",
+		expected:     "c0781e 323630302d30312d30315430303a30303a30302e3132333435363738395a",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Indefinite lengths",
+		skipDoc:      true,
+		input:        "bf 6161 9f 01 820203 ff 6162 7f 657374726561 646d696e67 ff 6163 5f 4101 4102 ff ff",
+		expected:     "a:\n  - 1\n  - - 2\n    - 3\nb: streaming\nc: !!binary AQI=\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Tags",
+		skipDoc:      true,
+		input:        "84 d9d9f7 01 c074323031332d30332d32315432303a30343a30305a c1fa4f000000 d820 6161",
+		expected:     "- 1\n- 2013-03-21T20:04:00Z\n- 2038-01-19T03:14:08Z\n- a\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Half floats",
+		skipDoc:      true,
+		input:        "84 f97bff f90001 f90400 f9fc00",
+		expected:     "- 65504.0\n- 5.9604645e-08\n- 6.1035156e-05\n- -.inf\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Roundtrip",
+		skipDoc:      true,
+		input:        "a: {b: [1, -2, 3.5, true, null, hello, !!binary aGVsbG8=, 1970-01-01T00:00:01.5Z]}\n1: x\n",
+		expected:     "a:\n  b:\n    - 1\n    - -2\n    - 3.5\n    - true\n    - null\n    - hello\n    - !!binary aGVsbG8=\n    - 1970-01-01T00:00:01.5Z\n1: x\n",
+		scenarioType: "roundtrip",
+	},
+	{
+		description:   "Truncated",
+		skipDoc:       true,
+		input:         "8301",
+		expectedError: "bad file 'sample.yml': invalid cbor: unexpected EOF",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Unexpected break",
+		skipDoc:       true,
+		input:         "ff",
+		expectedError: "bad file 'sample.yml': invalid cbor: unexpected break",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Bogus length",
+		skipDoc:       true,
+		input:         "5bffffffffffffffff",
+		expectedError: "bad file 'sample.yml': invalid cbor: length 18446744073709551615 is too long",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Collection keys",
+		skipDoc:       true,
+		input:         "a18001",
+		expectedError: "bad file 'sample.yml': invalid cbor: unsupported map key of kind SequenceNode",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Nested too deeply",
+		skipDoc:       true,
+		input:         strings.Repeat("81", 10002),
+		expectedError: "bad file 'sample.yml': invalid cbor: nested deeper than 10000",
+		scenarioType:  "decode-error",
+	},
+}
+
+func testCBORScenario(t *testing.T, s formatScenario) {
+	switch s.scenarioType {
+	case "encode":
+		encoded := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewCBOREncoder())
+		test.AssertResultWithContext(t, strings.ReplaceAll(s.expected, " ", ""), hex.EncodeToString([]byte(encoded)), s.description)
+	case "decode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(withHexInput(s), NewCBORDecoder(), NewYamlEncoder(ConfiguredYamlPreferences)), s.description)
+	case "roundtrip":
+		encoded := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewCBOREncoder())
+		decoded := mustProcessFormatScenario(formatScenario{input: encoded}, NewCBORDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		test.AssertResultWithContext(t, s.expected, decoded, s.description)
+	case "decode-error":
+		result, err := processFormatScenario(withHexInput(s), NewCBORDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		if err == nil {
+			t.Errorf("Expected error '%v' but it worked: %v", s.expectedError, result)
+		} else {
+			test.AssertResultWithContext(t, true, strings.HasPrefix(err.Error(), s.expectedError),
+				fmt.Sprintf("Expected [%v] to start with [%v]", err.Error(), s.expectedError))
+		}
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func documentCBORScenario(_ *testing.T, w *bufio.Writer, i interface{}) {
+	s := i.(formatScenario)
+	if s.skipDoc {
+		return
+	}
+	writeOrPanic(w, fmt.Sprintf("## %v\n", s.description))
+
+	if s.subdescription != "" {
+		writeOrPanic(w, s.subdescription)
+		writeOrPanic(w, "\n\n")
+	}
+
+	expression := s.expression
+	if expression == "" {
+		expression = "."
+	}
+
+	switch s.scenarioType {
+	case "encode":
+		writeOrPanic(w, "Given a sample.yml file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=cbor '%v' sample.yml | xxd -p\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		encoded := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewCBOREncoder())
+		writeOrPanic(w, fmt.Sprintf("```\n%v\n```\n\n", hex.EncodeToString([]byte(encoded))))
+	case "decode":
+		writeOrPanic(w, "Given a sample.cbor file of (in hex):\n")
+		writeOrPanic(w, fmt.Sprintf("```\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=yaml '%v' sample.cbor\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n\n", mustProcessFormatScenario(withHexInput(s), NewCBORDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))))
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func TestCBORScenarios(t *testing.T) {
+	for _, tt := range cborScenarios {
+		testCBORScenario(t, tt)
+	}
+	genericScenarios := make([]interface{}, len(cborScenarios))
+	for i, s := range cborScenarios {
+		genericScenarios[i] = s
+	}
+	documentScenarios(t, "usage", "cbor", genericScenarios, documentCBORScenario)
+}
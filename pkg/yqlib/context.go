@@ -12,6 +12,11 @@ type Context struct {
 	Variables      map[string]*list.List
 	DontAutoCreate bool
 	datetimeLayout string
+	// set by tz(timezone; exp), so calendar math respects daylight savings
+	datetimeLocation *time.Location
+	// set by with_semver, so versions are compared by semver precedence
+	semverCompare bool
+	functions     map[string]*functionDefinition
 }
 
 func (n *Context) SingleReadonlyChildContext(candidate *CandidateNode) Context {
@@ -39,6 +44,22 @@ func (n *Context) GetDateTimeLayout() string {
 	return time.RFC3339
 }
 
+func (n *Context) SetDateTimeLocation(location *time.Location) {
+	n.datetimeLocation = location
+}
+
+func (n *Context) GetDateTimeLocation() *time.Location {
+	return n.datetimeLocation
+}
+
+func (n *Context) SetSemverCompare(semverCompare bool) {
+	n.semverCompare = semverCompare
+}
+
+func (n *Context) IsSemverCompare() bool {
+	return n.semverCompare
+}
+
 func (n *Context) GetVariable(name string) *list.List {
 	if n.Variables == nil {
 		return nil
@@ -53,8 +74,29 @@ func (n *Context) SetVariable(name string, value *list.List) {
 	n.Variables[name] = value
 }
 
+// GetFunction looks up a function by name and number of parameters, falling back
+// to the builtin functions if it has not been defined in this context.
+func (n *Context) GetFunction(name string, arity int) *functionDefinition {
+	key := functionKey(name, arity)
+	if function, ok := n.functions[key]; ok {
+		return function
+	}
+	return getBuiltinFunctions()[key]
+}
+
+// SetFunction adds the function to this context. Function tables are shared
+// between child contexts, so they are copied on write.
+func (n *Context) SetFunction(function *functionDefinition) {
+	functions := make(map[string]*functionDefinition, len(n.functions)+1)
+	for key, existing := range n.functions {
+		functions[key] = existing
+	}
+	functions[functionKey(function.name, len(function.params))] = function
+	n.functions = functions
+}
+
 func (n *Context) ChildContext(results *list.List) Context {
-	clone := Context{DontAutoCreate: n.DontAutoCreate, datetimeLayout: n.datetimeLayout}
+	clone := Context{DontAutoCreate: n.DontAutoCreate, datetimeLayout: n.datetimeLayout, datetimeLocation: n.datetimeLocation, semverCompare: n.semverCompare, functions: n.functions}
 	clone.Variables = make(map[string]*list.List)
 	for variableKey, originalValueList := range n.Variables {
 
@@ -0,0 +1,49 @@
+//go:build !yq_nobase32
+
+package yqlib
+
+import (
+	"bytes"
+	"encoding/base32"
+	"io"
+	"strings"
+)
+
+type base32Decoder struct {
+	reader   io.Reader
+	finished bool
+	encoding base32.Encoding
+}
+
+func NewBase32Decoder() Decoder {
+	return &base32Decoder{finished: false, encoding: *base32.StdEncoding}
+}
+
+func (dec *base32Decoder) Init(reader io.Reader) error {
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return err
+	}
+
+	// base32 strings should be a multiple of 8 characters, add padding if needed
+	stripped := strings.TrimSpace(buf.String())
+	if padLen := len(stripped) % 8; padLen > 0 {
+		stripped += strings.Repeat("=", 8-padLen)
+	}
+
+	dec.reader = strings.NewReader(stripped)
+	dec.finished = false
+	return nil
+}
+
+func (dec *base32Decoder) Decode() (*CandidateNode, error) {
+	if dec.finished {
+		return nil, io.EOF
+	}
+	dec.finished = true
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(base32.NewDecoder(&dec.encoding, dec.reader)); err != nil {
+		return nil, err
+	}
+	return createStringScalarNode(buf.String()), nil
+}
@@ -20,6 +20,10 @@ func NewBase64Decoder() Decoder {
 	return &base64Decoder{finished: false, encoding: *base64.StdEncoding}
 }
 
+func NewBase64URLDecoder() Decoder {
+	return &base64Decoder{finished: false, encoding: *base64.URLEncoding}
+}
+
 func (dec *base64Decoder) Init(reader io.Reader) error {
 	// Read all data from the reader and strip leading/trailing whitespace
 	// This is necessary because base64 decoding needs to see the complete input
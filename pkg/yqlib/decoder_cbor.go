@@ -0,0 +1,371 @@
+//go:build !yq_nocbor
+
+package yqlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+const (
+	cborUnsignedInt = 0
+	cborNegativeInt = 1
+	cborByteString  = 2
+	cborTextString  = 3
+	cborArray       = 4
+	cborMap         = 5
+	cborTag         = 6
+	cborSimple      = 7
+
+	// additional information for indefinite lengths, and the break that ends them
+	cborIndefinite = 31
+	cborBreak      = 0xFF
+
+	cborTagDateTime     = 0
+	cborTagEpoch        = 1
+	cborTagPositiveBig  = 2
+	cborTagNegativeBig  = 3
+	cborTagSelfDescribe = 55799
+
+	// the most nested arrays and maps that will be decoded
+	cborMaxDepth = 10000
+)
+
+// cborDecoder reads a CBOR sequence, each top level item is a separate document.
+type cborDecoder struct {
+	reader *bufio.Reader
+}
+
+func NewCBORDecoder() Decoder {
+	return &cborDecoder{}
+}
+
+func (dec *cborDecoder) Init(reader io.Reader) error {
+	dec.reader = bufio.NewReader(reader)
+	return nil
+}
+
+func (dec *cborDecoder) Decode() (*CandidateNode, error) {
+	if _, err := dec.reader.Peek(1); err != nil {
+		return nil, err
+	}
+	node, err := dec.readItem(0)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid cbor: %w", err)
+	}
+	return node, nil
+}
+
+// readHead reads the initial byte of an item and its argument.
+func (dec *cborDecoder) readHead() (byte, byte, uint64, error) {
+	initial, err := dec.reader.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := initial>>5, initial&0x1F
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		argument := make([]byte, 1<<(info-24))
+		if _, err := io.ReadFull(dec.reader, argument); err != nil {
+			return 0, 0, 0, err
+		}
+		return major, info, readBigEndianUint(argument), nil
+	case info == cborIndefinite && major >= cborByteString && major != cborTag:
+		return major, info, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("invalid additional information %v for major type %v", info, major)
+}
+
+// isBreak consumes the break that ends an indefinite length item, if it is next.
+func (dec *cborDecoder) isBreak() (bool, error) {
+	next, err := dec.reader.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	if next[0] != cborBreak {
+		return false, nil
+	}
+	_, err = dec.reader.ReadByte()
+	return true, err
+}
+
+func (dec *cborDecoder) readBytes(length uint64) ([]byte, error) {
+	if length > math.MaxInt64 {
+		return nil, fmt.Errorf("length %v is too long", length)
+	}
+	// copied rather than allocated up front, as the length could be bogus
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, dec.reader, int64(length)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readString reads a byte or text string, joining the chunks of indefinite length strings.
+func (dec *cborDecoder) readString(major byte, info byte, length uint64) ([]byte, error) {
+	if info != cborIndefinite {
+		return dec.readBytes(length)
+	}
+	var buf bytes.Buffer
+	for {
+		if done, err := dec.isBreak(); err != nil {
+			return nil, err
+		} else if done {
+			return buf.Bytes(), nil
+		}
+		chunkMajor, chunkInfo, chunkLength, err := dec.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkInfo == cborIndefinite {
+			return nil, fmt.Errorf("invalid chunk of major type %v in an indefinite length string", chunkMajor)
+		}
+		chunk, err := dec.readBytes(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(chunk)
+	}
+}
+
+// readEntries calls readEntry length times, or until the break for indefinite lengths.
+func (dec *cborDecoder) readEntries(info byte, length uint64, readEntry func() error) error {
+	for index := uint64(0); info == cborIndefinite || index < length; index++ {
+		if info == cborIndefinite {
+			if done, err := dec.isBreak(); err != nil {
+				return err
+			} else if done {
+				return nil
+			}
+		}
+		if err := readEntry(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dec *cborDecoder) readItem(depth int) (*CandidateNode, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("nested deeper than %v", cborMaxDepth)
+	}
+	major, info, argument, err := dec.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsignedInt:
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: strconv.FormatUint(argument, 10)}, nil
+	case cborNegativeInt:
+		// the value is -1 - argument
+		value := new(big.Int).SetUint64(argument)
+		value.Not(value)
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: value.String()}, nil
+	case cborByteString:
+		data, err := dec.readString(major, info, argument)
+		if err != nil {
+			return nil, err
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!binary", Value: base64.StdEncoding.EncodeToString(data)}, nil
+	case cborTextString:
+		text, err := dec.readString(major, info, argument)
+		if err != nil {
+			return nil, err
+		}
+		return createStringScalarNode(string(text)), nil
+	case cborArray:
+		array := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+		err := dec.readEntries(info, argument, func() error {
+			value, err := dec.readItem(depth + 1)
+			if err != nil {
+				return err
+			}
+			array.AddChild(value)
+			return nil
+		})
+		return array, err
+	case cborMap:
+		dict := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+		err := dec.readEntries(info, argument, func() error {
+			key, err := dec.readItem(depth + 1)
+			if err != nil {
+				return err
+			}
+			if key.Kind != ScalarNode {
+				return fmt.Errorf("unsupported map key of kind %v", KindString(key.Kind))
+			}
+			value, err := dec.readItem(depth + 1)
+			if err != nil {
+				return err
+			}
+			dict.AddKeyValueChild(key, value)
+			return nil
+		})
+		return dict, err
+	case cborTag:
+		return dec.readTagged(argument, depth)
+	}
+	return dec.readSimple(info, argument)
+}
+
+func (dec *cborDecoder) readTagged(tag uint64, depth int) (*CandidateNode, error) {
+	if tag == cborTagEpoch {
+		return dec.readEpoch()
+	}
+	content, err := dec.readItem(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case cborTagDateTime:
+		if content.Tag != "!!str" {
+			return nil, fmt.Errorf("expected a text string for a date/time but got %v", content.Tag)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, content.Value); err != nil {
+			return nil, err
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!timestamp", Value: content.Value}, nil
+	case cborTagPositiveBig, cborTagNegativeBig:
+		if content.Tag != "!!binary" {
+			return nil, fmt.Errorf("expected a byte string for a bignum but got %v", content.Tag)
+		}
+		data, err := base64.StdEncoding.DecodeString(content.Value)
+		if err != nil {
+			return nil, err
+		}
+		value := new(big.Int).SetBytes(data)
+		if tag == cborTagNegativeBig {
+			// the value is -1 - n
+			value.Not(value)
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: value.String()}, nil
+	case cborTagSelfDescribe:
+		return content, nil
+	}
+	log.Debugf("ignoring unsupported cbor tag %v", tag)
+	return content, nil
+}
+
+// readEpoch reads the number of an epoch date, floats are read directly as their
+// formatted value may not have the precision of a float64.
+func (dec *cborDecoder) readEpoch() (*CandidateNode, error) {
+	major, info, argument, err := dec.readHead()
+	if err != nil {
+		return nil, err
+	}
+	var date time.Time
+	switch {
+	case (major == cborUnsignedInt || major == cborNegativeInt) && argument <= math.MaxInt64:
+		seconds := int64(argument)
+		if major == cborNegativeInt {
+			seconds = -1 - seconds
+		}
+		date = time.Unix(seconds, 0)
+	case major == cborSimple && info >= 25 && info <= 27:
+		seconds := cborFloat(info, argument)
+		if math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+			return nil, fmt.Errorf("invalid epoch date %v", formatFloatValue(seconds))
+		}
+		whole, fraction := math.Modf(seconds)
+		date = time.Unix(int64(whole), int64(math.Round(fraction*1e9)))
+	default:
+		return nil, fmt.Errorf("expected a number for an epoch date but got major type %v", major)
+	}
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!timestamp", Value: date.UTC().Format(time.RFC3339Nano)}, nil
+}
+
+// cborFloat returns the half, single or double precision float of the argument.
+func cborFloat(info byte, argument uint64) float64 {
+	switch info {
+	case 25:
+		return float16ToFloat64(uint16(argument))
+	case 26:
+		return float64(math.Float32frombits(uint32(argument)))
+	}
+	return math.Float64frombits(argument)
+}
+
+func (dec *cborDecoder) readSimple(info byte, argument uint64) (*CandidateNode, error) {
+	switch info {
+	case 20:
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: "false"}, nil
+	case 21:
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: "true"}, nil
+	case 22, 23:
+		// null and undefined
+		return createScalarNode(nil, "null"), nil
+	case 25, 26:
+		return createFloatScalarNode(cborFloat(info, argument), 32), nil
+	case 27:
+		return createFloatScalarNode(cborFloat(info, argument), 64), nil
+	case cborIndefinite:
+		return nil, errors.New("unexpected break")
+	}
+	return nil, fmt.Errorf("unsupported simple value %v", argument)
+}
+
+func float16ToFloat64(bits uint16) float64 {
+	sign := 1.0
+	if bits&0x8000 != 0 {
+		sign = -1.0
+	}
+	exponent := int(bits>>10) & 0x1F
+	fraction := float64(bits & 0x3FF)
+	switch exponent {
+	case 0:
+		// subnormal
+		return sign * math.Ldexp(fraction, -24)
+	case 0x1F:
+		if fraction == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(fraction+1024, exponent-25)
+}
+
+// float64ToFloat16 returns the half precision bits of the value, if it can be represented exactly.
+func float64ToFloat16(value float64) (uint16, bool) {
+	if math.IsNaN(value) {
+		return 0x7E00, true
+	}
+	single := float32(value)
+	if float64(single) != value {
+		return 0, false
+	}
+	bits := math.Float32bits(single)
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int(bits>>23&0xFF) - 127
+	mantissa := bits & 0x7FFFFF
+
+	var half uint16
+	switch {
+	case bits&0x7FFFFFFF == 0:
+		half = sign
+	case exponent == 128:
+		// infinity, as NaN is handled above
+		half = sign | 0x7C00
+	case exponent >= -14 && exponent <= 15:
+		half = sign | uint16(exponent+15)<<10 | uint16(mantissa>>13)
+	case exponent >= -24 && exponent < -14:
+		half = sign | uint16((mantissa|0x800000)>>uint(-(exponent+1)))
+	default:
+		return 0, false
+	}
+	return half, float16ToFloat64(half) == value
+}
@@ -0,0 +1,552 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// json5Decoder decodes JSON5 (and so JSONC), which allows comments, trailing commas,
+// unquoted keys, single quoted strings and more number formats.
+// Comments are kept as yaml style (#) head, line and foot comments.
+type json5Decoder struct {
+	reader   io.Reader
+	readAll  bool
+	input    []rune
+	pos      int
+	line     int
+	comments []string
+}
+
+func NewJSON5Decoder() Decoder {
+	return &json5Decoder{}
+}
+
+func (dec *json5Decoder) Init(reader io.Reader) error {
+	dec.reader = reader
+	dec.readAll = false
+	return nil
+}
+
+func (dec *json5Decoder) Decode() (*CandidateNode, error) {
+	if !dec.readAll {
+		buf := new(bytes.Buffer)
+		if _, err := buf.ReadFrom(dec.reader); err != nil {
+			return nil, err
+		}
+		dec.readAll = true
+		dec.input = []rune(buf.String())
+		dec.pos = 0
+		dec.line = 1
+		dec.comments = nil
+	}
+
+	headComment, err := dec.readComments()
+	if err != nil {
+		return nil, err
+	} else if dec.atEnd() {
+		return nil, io.EOF
+	}
+	node, err := dec.readValue()
+	if err != nil {
+		return nil, err
+	}
+	node.HeadComment = joinCommentLines(headComment, node.HeadComment)
+	if lineComment := dec.readLineComment(); lineComment != "" {
+		node.LineComment = joinCommentLines(node.LineComment, lineComment)
+	}
+	// comments after the last document are its foot comment,
+	// otherwise they are the head comment of the next one.
+	footComment, err := dec.readComments()
+	if err != nil {
+		return nil, err
+	} else if dec.atEnd() {
+		node.FootComment = joinCommentLines(node.FootComment, footComment)
+	} else {
+		dec.comments = []string{footComment}
+	}
+	return node, nil
+}
+
+func joinCommentLines(comments ...string) string {
+	nonEmpty := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		if comment != "" {
+			nonEmpty = append(nonEmpty, comment)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+// joinLineComments joins comments that are on the same line.
+func joinLineComments(comments ...string) string {
+	return strings.ReplaceAll(joinCommentLines(comments...), "\n", " ")
+}
+
+func (dec *json5Decoder) peek(offset int) rune {
+	if dec.pos+offset >= len(dec.input) {
+		return 0
+	}
+	return dec.input[dec.pos+offset]
+}
+
+func (dec *json5Decoder) next() rune {
+	r := dec.peek(0)
+	dec.pos++
+	if r == '\n' {
+		dec.line++
+	}
+	return r
+}
+
+func (dec *json5Decoder) atEnd() bool {
+	return dec.pos >= len(dec.input)
+}
+
+func (dec *json5Decoder) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("line %v: %v", dec.line, fmt.Sprintf(format, a...))
+}
+
+func (dec *json5Decoder) describeNext() string {
+	if dec.atEnd() {
+		return "end of file"
+	}
+	return fmt.Sprintf("'%c'", dec.peek(0))
+}
+
+func isJSON5Space(r rune) bool {
+	return unicode.IsSpace(r) || r == '\uFEFF'
+}
+
+// readComment reads a // or /* */ comment, returning it as yaml style comment lines.
+func (dec *json5Decoder) readComment() (string, error) {
+	line := dec.line
+	dec.next()
+	if dec.next() == '/' {
+		var sb strings.Builder
+		for !dec.atEnd() && dec.peek(0) != '\n' {
+			sb.WriteRune(dec.next())
+		}
+		return "#" + strings.TrimRight(sb.String(), "\r"), nil
+	}
+
+	var sb strings.Builder
+	for !(dec.peek(0) == '*' && dec.peek(1) == '/') {
+		if dec.atEnd() {
+			return "", fmt.Errorf("line %v: unclosed /* comment", line)
+		}
+		sb.WriteRune(dec.next())
+	}
+	dec.next()
+	dec.next()
+
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	for index, commentLine := range lines {
+		commentLine = strings.TrimSpace(commentLine)
+		// drop the leading * of javadoc style comments
+		commentLine = strings.TrimSpace(strings.TrimPrefix(commentLine, "*"))
+		lines[index] = "# " + commentLine
+		if commentLine == "" {
+			lines[index] = "#"
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (dec *json5Decoder) isAtComment() bool {
+	return dec.peek(0) == '/' && (dec.peek(1) == '/' || dec.peek(1) == '*')
+}
+
+// readComments skips whitespace, returning the comments found along the way.
+func (dec *json5Decoder) readComments() (string, error) {
+	comments := dec.comments
+	dec.comments = nil
+	for !dec.atEnd() {
+		if isJSON5Space(dec.peek(0)) {
+			dec.next()
+		} else if dec.isAtComment() {
+			comment, err := dec.readComment()
+			if err != nil {
+				return "", err
+			}
+			comments = append(comments, comment)
+		} else {
+			break
+		}
+	}
+	return joinCommentLines(comments...), nil
+}
+
+// readLineComment returns the comments on the rest of the current line.
+func (dec *json5Decoder) readLineComment() string {
+	comments := make([]string, 0)
+	for !dec.atEnd() && dec.peek(0) != '\n' {
+		if isJSON5Space(dec.peek(0)) {
+			dec.next()
+		} else if dec.isAtComment() {
+			start, line := dec.pos, dec.line
+			comment, err := dec.readComment()
+			if err != nil || dec.line != line {
+				// multi line comments belong to what follows
+				dec.pos, dec.line = start, line
+				break
+			}
+			comments = append(comments, comment)
+		} else {
+			break
+		}
+	}
+	return joinLineComments(comments...)
+}
+
+func (dec *json5Decoder) readValue() (*CandidateNode, error) {
+	switch r := dec.peek(0); {
+	case r == '{':
+		return dec.readObject()
+	case r == '[':
+		return dec.readArray()
+	case r == '"' || r == '\'':
+		value, err := dec.readString()
+		if err != nil {
+			return nil, err
+		}
+		return createStringScalarNode(value), nil
+	case r == '-' || r == '+' || r == '.' || (r >= '0' && r <= '9'):
+		return dec.readNumber()
+	case isJSON5IdentifierStart(r):
+		line := dec.line
+		identifier, err := dec.readIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		switch identifier {
+		case "true", "false":
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: identifier}, nil
+		case "null":
+			return createScalarNode(nil, "null"), nil
+		case "Infinity":
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: ".inf"}, nil
+		case "NaN":
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: ".nan"}, nil
+		}
+		return nil, fmt.Errorf("line %v: unexpected '%v'", line, identifier)
+	}
+	return nil, dec.errorf("unexpected %v", dec.describeNext())
+}
+
+// readEntryEnd reads the comments after an entry, up to and including the ',' separator.
+// When the collection is closed instead, the comments before the closing bracket are returned.
+func (dec *json5Decoder) readEntryEnd(entry *CandidateNode, closing rune) (bool, string, error) {
+	entry.LineComment = joinLineComments(entry.LineComment, dec.readLineComment())
+	comments, err := dec.readComments()
+	if err != nil {
+		return false, "", err
+	} else if dec.peek(0) == closing {
+		return false, comments, nil
+	} else if dec.peek(0) != ',' {
+		return false, "", dec.errorf("expected ',' or '%c' but got %v", closing, dec.describeNext())
+	}
+	dec.next()
+	// comments between the value and the comma
+	entry.FootComment = joinCommentLines(entry.FootComment, comments)
+	entry.LineComment = joinLineComments(entry.LineComment, dec.readLineComment())
+	return true, "", nil
+}
+
+// closeCollection reads the closing bracket, the comments before it become the foot
+// comment of the last entry.
+func (dec *json5Decoder) closeCollection(collection *CandidateNode, last *CandidateNode, comments string) *CandidateNode {
+	dec.next()
+	if last == nil {
+		last = collection
+	}
+	last.FootComment = joinCommentLines(last.FootComment, comments)
+	return collection
+}
+
+func (dec *json5Decoder) readObject() (*CandidateNode, error) {
+	dec.next()
+	object := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+	object.LineComment = dec.readLineComment()
+
+	var last *CandidateNode
+	for {
+		headComment, err := dec.readComments()
+		if err != nil {
+			return nil, err
+		} else if dec.peek(0) == '}' {
+			return dec.closeCollection(object, last, headComment), nil
+		}
+		key, err := dec.readKey()
+		if err != nil {
+			return nil, err
+		}
+		keyComment, err := dec.readComments()
+		if err != nil {
+			return nil, err
+		} else if dec.peek(0) != ':' {
+			return nil, dec.errorf("expected ':' after key '%v' but got %v", key.Value, dec.describeNext())
+		}
+		dec.next()
+		lineComment := dec.readLineComment()
+		valueComment, err := dec.readComments()
+		if err != nil {
+			return nil, err
+		} else if dec.atEnd() {
+			return nil, dec.errorf("expected a value for '%v' but got end of file", key.Value)
+		}
+		value, err := dec.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		key.HeadComment = joinCommentLines(headComment, keyComment, valueComment)
+		if value.Kind == ScalarNode {
+			value.LineComment = lineComment
+		} else {
+			// a comment after the opening bracket
+			key.LineComment = joinLineComments(lineComment, value.LineComment)
+			value.LineComment = ""
+		}
+		key.IsMapKey = true
+		key.SetParent(object)
+		value.SetParent(object)
+		value.Key = key
+		object.Content = append(object.Content, key, value)
+		last = value
+
+		more, footComment, err := dec.readEntryEnd(value, '}')
+		if err != nil {
+			return nil, err
+		}
+		if value.Kind != ScalarNode {
+			// like a comment after the opening bracket, a comment after the closing bracket goes on the key
+			key.LineComment = joinLineComments(key.LineComment, value.LineComment)
+			value.LineComment = ""
+		}
+		if !more {
+			return dec.closeCollection(object, last, footComment), nil
+		}
+	}
+}
+
+func (dec *json5Decoder) readArray() (*CandidateNode, error) {
+	dec.next()
+	array := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	array.LineComment = dec.readLineComment()
+
+	var last *CandidateNode
+	for {
+		headComment, err := dec.readComments()
+		if err != nil {
+			return nil, err
+		} else if dec.peek(0) == ']' {
+			return dec.closeCollection(array, last, headComment), nil
+		} else if dec.atEnd() {
+			return nil, dec.errorf("expected ']' but got end of file")
+		}
+		value, err := dec.readValue()
+		if err != nil {
+			return nil, err
+		}
+		value.HeadComment = joinCommentLines(headComment, value.HeadComment)
+		value.SetParent(array)
+		value.Key = createScalarNode(len(array.Content), fmt.Sprintf("%v", len(array.Content)))
+		array.Content = append(array.Content, value)
+		last = value
+
+		more, footComment, err := dec.readEntryEnd(value, ']')
+		if err != nil {
+			return nil, err
+		}
+		if value.Kind != ScalarNode {
+			// there is no key to put a comment after the closing bracket on, so it comes before the entry
+			value.HeadComment = joinCommentLines(value.HeadComment, value.LineComment)
+			value.LineComment = ""
+		}
+		if !more {
+			return dec.closeCollection(array, last, footComment), nil
+		}
+	}
+}
+
+func (dec *json5Decoder) readKey() (*CandidateNode, error) {
+	if dec.peek(0) == '"' || dec.peek(0) == '\'' {
+		value, err := dec.readString()
+		if err != nil {
+			return nil, err
+		}
+		return createStringScalarNode(value), nil
+	} else if !isJSON5IdentifierStart(dec.peek(0)) {
+		return nil, dec.errorf("expected a key but got %v", dec.describeNext())
+	}
+	identifier, err := dec.readIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	return createStringScalarNode(identifier), nil
+}
+
+func isJSON5IdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || r == '\\' || unicode.IsLetter(r)
+}
+
+func isJSON5IdentifierPart(r rune) bool {
+	return isJSON5IdentifierStart(r) || unicode.IsDigit(r) ||
+		unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200C' || r == '\u200D'
+}
+
+// readIdentifier reads an unquoted key, which may contain \uXXXX escapes.
+func (dec *json5Decoder) readIdentifier() (string, error) {
+	var sb strings.Builder
+	for isJSON5IdentifierPart(dec.peek(0)) {
+		r := dec.next()
+		if r != '\\' {
+			sb.WriteRune(r)
+			continue
+		}
+		if dec.next() != 'u' {
+			return "", dec.errorf("invalid escape in key, expected \\u")
+		}
+		code, err := dec.readHex(4)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteRune(code)
+	}
+	return sb.String(), nil
+}
+
+func (dec *json5Decoder) readHex(digits int) (rune, error) {
+	hex := ""
+	for len(hex) < digits && isDigitInBase(dec.peek(0), 16) {
+		hex += string(dec.next())
+	}
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != digits || err != nil {
+		return 0, dec.errorf("invalid escape, expected %v hex digits but got '%v'", digits, hex)
+	}
+	return rune(code), nil
+}
+
+var json5Escapes = map[rune]string{
+	'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v", '0': "\x00",
+}
+
+// readString reads a single or double quoted string, where a backslash before a new line continues the string.
+func (dec *json5Decoder) readString() (string, error) {
+	line := dec.line
+	quote := dec.next()
+	var sb strings.Builder
+	for !dec.atEnd() {
+		r := dec.next()
+		switch {
+		case r == quote:
+			return sb.String(), nil
+		case r == '\n':
+			return "", fmt.Errorf("line %v: new line in string, use \\n or a \\ at the end of the line", line)
+		case r != '\\':
+			sb.WriteRune(r)
+			continue
+		}
+
+		escape := dec.next()
+		if replacement, ok := json5Escapes[escape]; ok && !(escape == '0' && unicode.IsDigit(dec.peek(0))) {
+			sb.WriteString(replacement)
+			continue
+		}
+		switch escape {
+		case '\n', '\u2028', '\u2029':
+			// line continuation
+		case '\r':
+			if dec.peek(0) == '\n' {
+				dec.next()
+			}
+		case 'x':
+			code, err := dec.readHex(2)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(code)
+		case 'u':
+			code, err := dec.readHex(4)
+			if err != nil {
+				return "", err
+			}
+			if utf16.IsSurrogate(code) && dec.peek(0) == '\\' && dec.peek(1) == 'u' {
+				dec.next()
+				dec.next()
+				low, err := dec.readHex(4)
+				if err != nil {
+					return "", err
+				}
+				code = utf16.DecodeRune(code, low)
+			}
+			sb.WriteRune(code)
+		default:
+			if unicode.IsDigit(escape) {
+				return "", dec.errorf("invalid escape \\%c", escape)
+			}
+			sb.WriteRune(escape)
+		}
+	}
+	return "", fmt.Errorf("line %v: missing closing %c quote", line, quote)
+}
+
+var json5DecimalRegex = regexp.MustCompile(`^((0|[1-9][0-9]*)(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+var json5HexRegex = regexp.MustCompile(`^0[xX][0-9a-fA-F]+$`)
+
+// readNumber reads ints (including hex) and floats, which may have leading or trailing
+// decimal points, a leading + and be Infinity or NaN.
+func (dec *json5Decoder) readNumber() (*CandidateNode, error) {
+	sign := ""
+	if dec.peek(0) == '-' || dec.peek(0) == '+' {
+		if dec.next() == '-' {
+			sign = "-"
+		}
+	}
+	if isJSON5IdentifierStart(dec.peek(0)) {
+		identifier, err := dec.readIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		switch identifier {
+		case "Infinity":
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: sign + ".inf"}, nil
+		case "NaN":
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: ".nan"}, nil
+		}
+		return nil, dec.errorf("unexpected '%v'", identifier)
+	}
+
+	var sb strings.Builder
+	previous := rune(0)
+	for r := dec.peek(0); isJSON5IdentifierPart(r) || r == '.' || ((r == '-' || r == '+') && (previous == 'e' || previous == 'E')); r = dec.peek(0) {
+		previous = dec.next()
+		sb.WriteRune(previous)
+	}
+	number := sb.String()
+
+	if json5HexRegex.MatchString(number) {
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: sign + number}, nil
+	} else if !json5DecimalRegex.MatchString(number) {
+		return nil, dec.errorf("invalid number '%v%v'", sign, number)
+	} else if !strings.ContainsAny(number, ".eE") {
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: sign + number}, nil
+	}
+	// leading and trailing decimal points are not valid json
+	if strings.HasPrefix(number, ".") {
+		number = "0" + number
+	}
+	if index := strings.Index(number, "."); index == len(number)-1 || strings.ContainsAny(number[index+1:index+2], "eE") {
+		number = number[:index+1] + "0" + number[index+1:]
+	}
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: sign + number}, nil
+}
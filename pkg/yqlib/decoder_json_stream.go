@@ -0,0 +1,123 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/goccy/go-json"
+)
+
+type jsonStreamFrame struct {
+	isMap     bool
+	key       interface{}
+	count     int
+	expectKey bool
+}
+
+// jsonStreamDecoder reads json token by token, returning [path, leaf] events
+// as it goes, so that large documents never need to be held in memory.
+type jsonStreamDecoder struct {
+	decoder *json.Decoder
+	frames  []*jsonStreamFrame
+}
+
+func NewJSONStreamDecoder() Decoder {
+	return &jsonStreamDecoder{}
+}
+
+func (dec *jsonStreamDecoder) Init(reader io.Reader) error {
+	dec.decoder = json.NewDecoder(reader)
+	dec.decoder.UseNumber()
+	dec.frames = make([]*jsonStreamFrame, 0)
+	return nil
+}
+
+func (dec *jsonStreamDecoder) path() []interface{} {
+	path := make([]interface{}, len(dec.frames))
+	for i, frame := range dec.frames {
+		path[i] = frame.key
+	}
+	return path
+}
+
+// startValue moves the current map/array on to the next entry, before its value is read.
+func (dec *jsonStreamDecoder) startValue() {
+	if len(dec.frames) == 0 {
+		return
+	}
+	frame := dec.frames[len(dec.frames)-1]
+	if !frame.isMap {
+		frame.key = frame.count
+	}
+	frame.count = frame.count + 1
+	frame.expectKey = frame.isMap
+}
+
+func (dec *jsonStreamDecoder) Decode() (*CandidateNode, error) {
+	for {
+		token, err := dec.decoder.Token()
+		if err != nil {
+			if err == io.EOF && len(dec.frames) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		if len(dec.frames) > 0 {
+			frame := dec.frames[len(dec.frames)-1]
+			if key, isString := token.(string); isString && frame.expectKey {
+				frame.key = key
+				frame.expectKey = false
+				continue
+			}
+		}
+
+		switch token := token.(type) {
+		case json.Delim:
+			switch token {
+			case '{', '[':
+				dec.startValue()
+				dec.frames = append(dec.frames, &jsonStreamFrame{isMap: token == '{', expectKey: token == '{'})
+			case '}', ']':
+				frame := dec.frames[len(dec.frames)-1]
+				path := dec.path()
+				dec.frames = dec.frames[:len(dec.frames)-1]
+				if frame.count > 0 {
+					return createStreamEvent(path, nil), nil
+				}
+				empty := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+				if frame.isMap {
+					empty = &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+				}
+				return createStreamEvent(path[:len(path)-1], empty), nil
+			}
+		default:
+			dec.startValue()
+			leaf, err := createJSONStreamLeaf(token)
+			if err != nil {
+				return nil, err
+			}
+			return createStreamEvent(dec.path(), leaf), nil
+		}
+	}
+}
+
+func createJSONStreamLeaf(token json.Token) (*CandidateNode, error) {
+	switch token := token.(type) {
+	case string:
+		return createStringScalarNode(token), nil
+	case json.Number:
+		if _, err := strconv.ParseInt(string(token), 10, 64); err == nil {
+			return createScalarNode(int64(0), string(token)), nil
+		}
+		return createScalarNode(float64(0), string(token)), nil
+	case bool:
+		return createScalarNode(token, strconv.FormatBool(token)), nil
+	case nil:
+		return createScalarNode(nil, "null"), nil
+	}
+	return nil, fmt.Errorf("unexpected json token %v", token)
+}
@@ -0,0 +1,64 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+var jsonStreamScenarios = []formatScenario{
+	{
+		description: "nested json",
+		input:       `{"a": [1, {"b": "cat"}], "c": 2.5}`,
+		expected:    "[[\"a\",0],1]\n[[\"a\",1,\"b\"],\"cat\"]\n[[\"a\",1,\"b\"]]\n[[\"a\",1]]\n[[\"c\"],2.5]\n[[\"c\"]]\n",
+	},
+	{
+		description: "empty maps and arrays are leaves",
+		input:       `{"a": {}, "b": []}`,
+		expected:    "[[\"a\"],{}]\n[[\"b\"],[]]\n[[\"b\"]]\n",
+	},
+	{
+		description: "top level scalars",
+		input:       "3\n\"cat\"\nnull",
+		expected:    "[[],3]\n[[],\"cat\"]\n[[],null]\n",
+	},
+	{
+		description: "multiple documents",
+		input:       "[true]\n{\"a\": false}",
+		expected:    "[[0],true]\n[[0]]\n[[\"a\"],false]\n[[\"a\"]]\n",
+	},
+	{
+		description: "reassembled with fromstream",
+		input:       `{"a": [1, {"b": "cat"}], "c": []}`,
+		expression:  `[.] | fromstream(.[])`,
+		expected:    "{\"a\":[1,{\"b\":\"cat\"}],\"c\":[]}\n",
+	},
+	{
+		description:   "truncated input",
+		input:         `{"a": [1,`,
+		expectedError: "bad file 'sample.yml': unexpected EOF",
+	},
+}
+
+func testJSONStreamScenario(t *testing.T, s formatScenario) {
+	prefs := ConfiguredJSONPreferences.Copy()
+	prefs.Indent = 0
+	if s.expectedError != "" {
+		_, err := processFormatScenario(s, NewJSONStreamDecoder(), NewJSONEncoder(prefs))
+		if err == nil {
+			t.Errorf("Expected error '%v' but it worked", s.expectedError)
+		} else {
+			test.AssertResultComplexWithContext(t, s.expectedError, err.Error(), s.description)
+		}
+		return
+	}
+	test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewJSONStreamDecoder(), NewJSONEncoder(prefs)), s.description)
+}
+
+func TestJSONStreamScenarios(t *testing.T) {
+	for _, tt := range jsonStreamScenarios {
+		testJSONStreamScenario(t, tt)
+	}
+}
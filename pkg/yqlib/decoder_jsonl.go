@@ -0,0 +1,55 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// jsonlDecoder reads JSON Lines (NDJSON), where each non blank line is a document.
+type jsonlDecoder struct {
+	reader *bufio.Reader
+	prefs  JsonlPreferences
+	line   int
+}
+
+func NewJSONLDecoder(prefs JsonlPreferences) Decoder {
+	return &jsonlDecoder{prefs: prefs}
+}
+
+func (dec *jsonlDecoder) Init(reader io.Reader) error {
+	dec.reader = bufio.NewReader(reader)
+	dec.line = 0
+	return nil
+}
+
+func (dec *jsonlDecoder) Decode() (*CandidateNode, error) {
+	for {
+		line, errReading := dec.reader.ReadString('\n')
+		if errReading != nil && !errors.Is(errReading, io.EOF) {
+			return nil, errReading
+		}
+		dec.line++
+
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			var dataBucket CandidateNode
+			err := json.Unmarshal([]byte(trimmed), &dataBucket)
+			if err == nil {
+				return &dataBucket, nil
+			} else if !dec.prefs.SkipInvalidLines {
+				return nil, fmt.Errorf("line %v: %w", dec.line, err)
+			}
+			log.Warningf("skipping line %v, it is not valid JSON: %v", dec.line, err)
+		}
+
+		if errReading != nil {
+			return nil, io.EOF
+		}
+	}
+}
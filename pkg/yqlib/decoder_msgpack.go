@@ -0,0 +1,241 @@
+//go:build !yq_nomsgpack
+
+package yqlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// the extension type of timestamps
+	msgpackTimestampType = -1
+
+	// the most nested arrays and maps that will be decoded
+	msgpackMaxDepth = 10000
+)
+
+// msgpackDecoder reads a stream of MessagePack objects, each one is a separate document.
+type msgpackDecoder struct {
+	reader *bufio.Reader
+}
+
+func NewMsgpackDecoder() Decoder {
+	return &msgpackDecoder{}
+}
+
+func (dec *msgpackDecoder) Init(reader io.Reader) error {
+	dec.reader = bufio.NewReader(reader)
+	return nil
+}
+
+func (dec *msgpackDecoder) Decode() (*CandidateNode, error) {
+	if _, err := dec.reader.Peek(1); err != nil {
+		return nil, err
+	}
+	node, err := dec.readObject(0)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid msgpack: %w", err)
+	}
+	return node, nil
+}
+
+func (dec *msgpackDecoder) readUint(size int) (uint64, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(dec.reader, data); err != nil {
+		return 0, err
+	}
+	return readBigEndianUint(data), nil
+}
+
+func (dec *msgpackDecoder) readBytes(length uint64) ([]byte, error) {
+	// copied rather than allocated up front, as the length could be bogus
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, dec.reader, int64(length)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func createMsgpackIntNode(value int64) *CandidateNode {
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: strconv.FormatInt(value, 10)}
+}
+
+func (dec *msgpackDecoder) readObject(depth int) (*CandidateNode, error) {
+	if depth > msgpackMaxDepth {
+		return nil, fmt.Errorf("nested deeper than %v", msgpackMaxDepth)
+	}
+	marker, err := dec.reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case marker <= 0x7F:
+		return createMsgpackIntNode(int64(marker)), nil
+	case marker <= 0x8F:
+		return dec.readMap(uint64(marker&0x0F), depth)
+	case marker <= 0x9F:
+		return dec.readArray(uint64(marker&0x0F), depth)
+	case marker <= 0xBF:
+		return dec.readStr(uint64(marker & 0x1F))
+	case marker >= 0xE0:
+		return createMsgpackIntNode(int64(int8(marker))), nil
+	}
+
+	switch marker {
+	case 0xC0:
+		return createScalarNode(nil, "null"), nil
+	case 0xC2:
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: "false"}, nil
+	case 0xC3:
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: "true"}, nil
+	case 0xC4, 0xC5, 0xC6:
+		length, err := dec.readUint(1 << (marker - 0xC4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := dec.readBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!binary", Value: base64.StdEncoding.EncodeToString(data)}, nil
+	case 0xC7, 0xC8, 0xC9:
+		length, err := dec.readUint(1 << (marker - 0xC7))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readExt(length)
+	case 0xCA:
+		bits, err := dec.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return createFloatScalarNode(float64(math.Float32frombits(uint32(bits))), 32), nil
+	case 0xCB:
+		bits, err := dec.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return createFloatScalarNode(math.Float64frombits(bits), 64), nil
+	case 0xCC, 0xCD, 0xCE, 0xCF:
+		value, err := dec.readUint(1 << (marker - 0xCC))
+		if err != nil {
+			return nil, err
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: strconv.FormatUint(value, 10)}, nil
+	case 0xD0, 0xD1, 0xD2, 0xD3:
+		size := 1 << (marker - 0xD0)
+		value, err := dec.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign extend
+		shift := uint(64 - size*8)
+		return createMsgpackIntNode(int64(value<<shift) >> shift), nil
+	case 0xD4, 0xD5, 0xD6, 0xD7, 0xD8:
+		return dec.readExt(1 << (marker - 0xD4))
+	case 0xD9, 0xDA, 0xDB:
+		length, err := dec.readUint(1 << (marker - 0xD9))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readStr(length)
+	case 0xDC, 0xDD:
+		length, err := dec.readUint(2 << (marker - 0xDC))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readArray(length, depth)
+	case 0xDE, 0xDF:
+		length, err := dec.readUint(2 << (marker - 0xDE))
+		if err != nil {
+			return nil, err
+		}
+		return dec.readMap(length, depth)
+	}
+	return nil, fmt.Errorf("unknown marker 0x%02X", marker)
+}
+
+func (dec *msgpackDecoder) readStr(length uint64) (*CandidateNode, error) {
+	text, err := dec.readBytes(length)
+	if err != nil {
+		return nil, err
+	}
+	return createStringScalarNode(string(text)), nil
+}
+
+func (dec *msgpackDecoder) readArray(length uint64, depth int) (*CandidateNode, error) {
+	array := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	for index := uint64(0); index < length; index++ {
+		value, err := dec.readObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array.AddChild(value)
+	}
+	return array, nil
+}
+
+func (dec *msgpackDecoder) readMap(length uint64, depth int) (*CandidateNode, error) {
+	dict := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+	for index := uint64(0); index < length; index++ {
+		key, err := dec.readObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if key.Kind != ScalarNode {
+			return nil, fmt.Errorf("unsupported map key of kind %v", KindString(key.Kind))
+		}
+		value, err := dec.readObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		dict.AddKeyValueChild(key, value)
+	}
+	return dict, nil
+}
+
+// readExt reads an extension, of which only timestamps are supported.
+func (dec *msgpackDecoder) readExt(length uint64) (*CandidateNode, error) {
+	extType, err := dec.reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := dec.readBytes(length)
+	if err != nil {
+		return nil, err
+	}
+	if int8(extType) != msgpackTimestampType {
+		return nil, fmt.Errorf("unsupported extension type %v", int8(extType))
+	}
+
+	var seconds int64
+	var nanoseconds uint64
+	switch len(data) {
+	case 4:
+		seconds = int64(readBigEndianUint(data))
+	case 8:
+		value := readBigEndianUint(data)
+		nanoseconds, seconds = value>>34, int64(value&0x3FFFFFFFF)
+	case 12:
+		nanoseconds, seconds = readBigEndianUint(data[:4]), int64(readBigEndianUint(data[4:]))
+	default:
+		return nil, fmt.Errorf("invalid timestamp length %v", len(data))
+	}
+	if nanoseconds > 999999999 {
+		return nil, fmt.Errorf("invalid timestamp nanoseconds %v", nanoseconds)
+	}
+	date := time.Unix(seconds, int64(nanoseconds)).UTC()
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!timestamp", Value: date.Format(time.RFC3339Nano)}, nil
+}
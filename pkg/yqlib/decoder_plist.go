@@ -0,0 +1,481 @@
+//go:build !yq_noplist
+
+package yqlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// plistEpoch is the reference date of binary plist dates.
+var plistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+const plistBinaryHeader = "bplist00"
+
+// plistDecoder reads XML and binary property lists, binary plists are detected by their header.
+type plistDecoder struct {
+	reader   io.Reader
+	finished bool
+}
+
+func NewPlistDecoder() Decoder {
+	return &plistDecoder{}
+}
+
+func (dec *plistDecoder) Init(reader io.Reader) error {
+	dec.reader = reader
+	dec.finished = false
+	return nil
+}
+
+func (dec *plistDecoder) Decode() (*CandidateNode, error) {
+	if dec.finished {
+		return nil, io.EOF
+	}
+	dec.finished = true
+	data, err := io.ReadAll(dec.reader)
+	if err != nil {
+		return nil, err
+	} else if len(bytes.TrimSpace(data)) == 0 {
+		return nil, io.EOF
+	}
+
+	if bytes.HasPrefix(data, []byte(plistBinaryHeader)) {
+		return decodeBinaryPlist(data)
+	}
+	return decodeXMLPlist(data)
+}
+
+func decodeXMLPlist(data []byte) (*CandidateNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("failed to parse plist: no plist element found")
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse plist: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "plist" {
+			// be lenient with a plist value that is missing the <plist> element
+			return decodePlistElement(decoder, start)
+		}
+		return decodePlistRoot(decoder)
+	}
+}
+
+func decodePlistRoot(decoder *xml.Decoder) (*CandidateNode, error) {
+	var root *CandidateNode
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plist: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, fmt.Errorf("a plist can only have one root value, but found <%v>", token.Name.Local)
+			}
+			root, err = decodePlistElement(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if root == nil {
+				return nil, errors.New("empty plist")
+			}
+			return root, nil
+		}
+	}
+}
+
+// readPlistText returns the text of the element, which cannot have any child elements.
+func readPlistText(decoder *xml.Decoder, start xml.StartElement) (string, error) {
+	var sb strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to parse plist: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.CharData:
+			sb.Write(token)
+		case xml.StartElement:
+			return "", fmt.Errorf("unexpected <%v> in <%v>", token.Name.Local, start.Name.Local)
+		case xml.EndElement:
+			return sb.String(), nil
+		}
+	}
+}
+
+func decodePlistElement(decoder *xml.Decoder, start xml.StartElement) (*CandidateNode, error) {
+	switch start.Name.Local {
+	case "dict":
+		return decodePlistDict(decoder)
+	case "array":
+		return decodePlistArray(decoder)
+	case "true", "false":
+		if _, err := readPlistText(decoder, start); err != nil {
+			return nil, err
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: start.Name.Local}, nil
+	}
+
+	text, err := readPlistText(decoder, start)
+	if err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return createStringScalarNode(text), nil
+	case "integer":
+		text = strings.TrimSpace(text)
+		if _, _, err := parseInt64(text); err != nil {
+			return nil, fmt.Errorf("invalid plist integer '%v'", text)
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: text}, nil
+	case "real":
+		number, err := parsePlistReal(strings.TrimSpace(text))
+		if err != nil {
+			return nil, err
+		}
+		return createPlistRealNode(number), nil
+	case "date":
+		date, err := time.Parse(time.RFC3339, strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist date '%v'", text)
+		}
+		return createPlistDateNode(date), nil
+	case "data":
+		encoded := strings.Join(strings.Fields(text), "")
+		if _, err := base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("invalid plist data: %w", err)
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!binary", Value: encoded}, nil
+	}
+	return nil, fmt.Errorf("unknown plist element <%v>", start.Name.Local)
+}
+
+func parsePlistReal(text string) (float64, error) {
+	switch strings.ToLower(text) {
+	case "inf", "+inf", "infinity", "+infinity":
+		return math.Inf(1), nil
+	case "-inf", "-infinity":
+		return math.Inf(-1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid plist real '%v'", text)
+	}
+	return number, nil
+}
+
+func createPlistRealNode(number float64) *CandidateNode {
+	value := formatFloatValue(number)
+	if !strings.ContainsAny(value, ".eEn") {
+		// keep it a float
+		value = value + ".0"
+	}
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: value}
+}
+
+func createPlistDateNode(date time.Time) *CandidateNode {
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!timestamp", Value: date.UTC().Format(time.RFC3339Nano)}
+}
+
+func decodePlistDict(decoder *xml.Decoder) (*CandidateNode, error) {
+	dict := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+	var key *CandidateNode
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plist: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if key == nil {
+				if token.Name.Local != "key" {
+					return nil, fmt.Errorf("expected a <key> in <dict> but got <%v>", token.Name.Local)
+				}
+				text, err := readPlistText(decoder, token)
+				if err != nil {
+					return nil, err
+				}
+				key = createStringScalarNode(text)
+				continue
+			}
+			value, err := decodePlistElement(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+			dict.AddKeyValueChild(key, value)
+			key = nil
+		case xml.EndElement:
+			if key != nil {
+				return nil, fmt.Errorf("missing value for key '%v'", key.Value)
+			}
+			return dict, nil
+		}
+	}
+}
+
+func decodePlistArray(decoder *xml.Decoder) (*CandidateNode, error) {
+	array := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plist: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			value, err := decodePlistElement(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+			array.AddChild(value)
+		case xml.EndElement:
+			return array, nil
+		}
+	}
+}
+
+// binaryPlistReader reads the objects of a bplist00 file, see CFBinaryPList.c for the format.
+type binaryPlistReader struct {
+	data          []byte
+	offsets       []uint64
+	objectRefSize int
+	// the objects being decoded, to detect cycles
+	decoding map[uint64]bool
+}
+
+func decodeBinaryPlist(data []byte) (*CandidateNode, error) {
+	if len(data) < len(plistBinaryHeader)+32 {
+		return nil, errors.New("invalid binary plist: too short")
+	}
+	trailer := data[len(data)-32:]
+	offsetIntSize := int(trailer[6])
+	objectRefSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	topObject := binary.BigEndian.Uint64(trailer[16:24])
+	offsetTableOffset := binary.BigEndian.Uint64(trailer[24:32])
+
+	if offsetIntSize < 1 || offsetIntSize > 8 || objectRefSize < 1 || objectRefSize > 8 ||
+		numObjects == 0 || topObject >= numObjects ||
+		offsetTableOffset > uint64(len(data)-32) ||
+		numObjects > (uint64(len(data)-32)-offsetTableOffset)/uint64(offsetIntSize) {
+		return nil, errors.New("invalid binary plist: bad trailer")
+	}
+
+	reader := &binaryPlistReader{
+		data:          data[:len(data)-32],
+		offsets:       make([]uint64, numObjects),
+		objectRefSize: objectRefSize,
+		decoding:      make(map[uint64]bool),
+	}
+	for index := range reader.offsets {
+		start := offsetTableOffset + uint64(index*offsetIntSize)
+		reader.offsets[index] = readBigEndianUint(data[start : start+uint64(offsetIntSize)])
+		if reader.offsets[index] < uint64(len(plistBinaryHeader)) || reader.offsets[index] >= offsetTableOffset {
+			return nil, fmt.Errorf("invalid binary plist: bad offset for object %v", index)
+		}
+	}
+	return reader.readObject(topObject)
+}
+
+func (r *binaryPlistReader) bytesAt(offset uint64, length uint64) ([]byte, error) {
+	if offset > uint64(len(r.data)) || length > uint64(len(r.data))-offset {
+		return nil, errors.New("invalid binary plist: object out of range")
+	}
+	return r.data[offset : offset+length], nil
+}
+
+// readCount reads the count in the low nibble of the marker, or in the int that follows it.
+func (r *binaryPlistReader) readCount(offset uint64) (uint64, uint64, error) {
+	marker := r.data[offset]
+	if marker&0x0F != 0x0F {
+		return uint64(marker & 0x0F), offset + 1, nil
+	}
+	intMarker, err := r.bytesAt(offset+1, 1)
+	if err != nil {
+		return 0, 0, err
+	} else if intMarker[0]&0xF0 != 0x10 {
+		return 0, 0, errors.New("invalid binary plist: bad count")
+	}
+	size := uint64(1) << (intMarker[0] & 0x0F)
+	countBytes, err := r.bytesAt(offset+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return readBigEndianUint(countBytes), offset + 2 + size, nil
+}
+
+func (r *binaryPlistReader) readRefs(offset uint64, count uint64) ([]uint64, error) {
+	if count > uint64(len(r.data)) {
+		return nil, errors.New("invalid binary plist: object out of range")
+	}
+	refBytes, err := r.bytesAt(offset, count*uint64(r.objectRefSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, count)
+	for index := range refs {
+		refs[index] = readBigEndianUint(refBytes[index*r.objectRefSize : (index+1)*r.objectRefSize])
+	}
+	return refs, nil
+}
+
+func (r *binaryPlistReader) readObject(ref uint64) (*CandidateNode, error) {
+	if ref >= uint64(len(r.offsets)) {
+		return nil, fmt.Errorf("invalid binary plist: bad object reference %v", ref)
+	} else if r.decoding[ref] {
+		return nil, errors.New("invalid binary plist: cyclic reference")
+	}
+	r.decoding[ref] = true
+	defer delete(r.decoding, ref)
+
+	offset := r.offsets[ref]
+	marker := r.data[offset]
+	info := marker & 0x0F
+
+	switch marker & 0xF0 {
+	case 0x00:
+		switch marker {
+		case 0x08:
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: "false"}, nil
+		case 0x09:
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!bool", Value: "true"}, nil
+		case 0x00:
+			return createScalarNode(nil, "null"), nil
+		}
+	case 0x10:
+		size := uint64(1) << info
+		intBytes, err := r.bytesAt(offset+1, size)
+		if err != nil {
+			return nil, err
+		}
+		value := fmt.Sprintf("%v", readBigEndianUint(intBytes))
+		switch size {
+		case 8:
+			value = fmt.Sprintf("%v", int64(readBigEndianUint(intBytes)))
+		case 16:
+			// 128 bit ints are only used for unsigned 64 bit values
+			value = fmt.Sprintf("%v", readBigEndianUint(intBytes[8:]))
+		}
+		return &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: value}, nil
+	case 0x20:
+		switch info {
+		case 2:
+			realBytes, err := r.bytesAt(offset+1, 4)
+			if err != nil {
+				return nil, err
+			}
+			return createPlistRealNode(float64(math.Float32frombits(binary.BigEndian.Uint32(realBytes)))), nil
+		case 3:
+			realBytes, err := r.bytesAt(offset+1, 8)
+			if err != nil {
+				return nil, err
+			}
+			return createPlistRealNode(math.Float64frombits(binary.BigEndian.Uint64(realBytes))), nil
+		}
+	case 0x30:
+		if marker == 0x33 {
+			dateBytes, err := r.bytesAt(offset+1, 8)
+			if err != nil {
+				return nil, err
+			}
+			seconds := math.Float64frombits(binary.BigEndian.Uint64(dateBytes))
+			return createPlistDateNode(plistEpoch.Add(time.Duration(seconds * float64(time.Second)))), nil
+		}
+	case 0x40, 0x50, 0x60:
+		count, start, err := r.readCount(offset)
+		if err != nil {
+			return nil, err
+		}
+		if marker&0xF0 == 0x60 {
+			count = count * 2
+		}
+		content, err := r.bytesAt(start, count)
+		if err != nil {
+			return nil, err
+		}
+		switch marker & 0xF0 {
+		case 0x40:
+			return &CandidateNode{Kind: ScalarNode, Tag: "!!binary", Value: base64.StdEncoding.EncodeToString(content)}, nil
+		case 0x50:
+			return createStringScalarNode(string(content)), nil
+		}
+		units := make([]uint16, len(content)/2)
+		for index := range units {
+			units[index] = binary.BigEndian.Uint16(content[index*2:])
+		}
+		return createStringScalarNode(string(utf16.Decode(units))), nil
+	case 0x80:
+		uidBytes, err := r.bytesAt(offset+1, uint64(info)+1)
+		if err != nil {
+			return nil, err
+		}
+		// the same as plutil shows UIDs in xml
+		uid := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+		value := fmt.Sprintf("%v", readBigEndianUint(uidBytes))
+		uid.AddKeyValueChild(createStringScalarNode("CF$UID"), &CandidateNode{Kind: ScalarNode, Tag: "!!int", Value: value})
+		return uid, nil
+	case 0xA0, 0xC0:
+		count, start, err := r.readCount(offset)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := r.readRefs(start, count)
+		if err != nil {
+			return nil, err
+		}
+		array := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+		for _, ref := range refs {
+			value, err := r.readObject(ref)
+			if err != nil {
+				return nil, err
+			}
+			array.AddChild(value)
+		}
+		return array, nil
+	case 0xD0:
+		count, start, err := r.readCount(offset)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := r.readRefs(start, count*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+		for index := uint64(0); index < count; index++ {
+			key, err := r.readObject(refs[index])
+			if err != nil {
+				return nil, err
+			} else if key.Kind != ScalarNode || key.Tag != "!!str" {
+				return nil, fmt.Errorf("invalid binary plist: dict keys must be strings, but got %v", key.Tag)
+			}
+			value, err := r.readObject(refs[count+index])
+			if err != nil {
+				return nil, err
+			}
+			dict.AddKeyValueChild(key, value)
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("invalid binary plist: unknown object type 0x%02x", marker)
+}
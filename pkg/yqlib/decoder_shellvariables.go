@@ -0,0 +1,380 @@
+//go:build !yq_noshell
+
+package yqlib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the most null entries that will be added before an un-flattened array index, so that
+// a large index like a_50000000 cannot use up all of the memory
+const shellVariablesMaxArrayGap = 1000
+
+type shellVariablesDecoder struct {
+	reader   io.Reader
+	finished bool
+	prefs    ShellVariablesPreferences
+
+	input   []rune
+	pos     int
+	line    int
+	comment []string
+}
+
+func NewShellVariablesDecoder() Decoder {
+	return &shellVariablesDecoder{prefs: ConfiguredShellVariablesPreferences}
+}
+
+func (dec *shellVariablesDecoder) Init(reader io.Reader) error {
+	dec.reader = reader
+	dec.finished = false
+	return nil
+}
+
+func (dec *shellVariablesDecoder) Decode() (*CandidateNode, error) {
+	if dec.finished {
+		return nil, io.EOF
+	}
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(dec.reader); err != nil {
+		return nil, err
+	}
+	dec.finished = true
+	if buf.Len() == 0 {
+		return nil, io.EOF
+	}
+	dec.input = []rune(buf.String())
+	dec.pos = 0
+	dec.line = 1
+	dec.comment = nil
+
+	rootMap := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+	for {
+		name, value, err := dec.nextVariable()
+		if err != nil {
+			return nil, err
+		} else if value == nil {
+			break
+		}
+		if err := dec.setVariable(rootMap, name, value); err != nil {
+			return nil, err
+		}
+	}
+	// comments after the last variable
+	rootMap.FootComment = strings.Join(dec.comment, "\n")
+	return rootMap, nil
+}
+
+func (dec *shellVariablesDecoder) peek(offset int) rune {
+	if dec.pos+offset >= len(dec.input) {
+		return 0
+	}
+	return dec.input[dec.pos+offset]
+}
+
+func (dec *shellVariablesDecoder) next() rune {
+	r := dec.peek(0)
+	dec.pos++
+	if r == '\n' {
+		dec.line++
+	}
+	return r
+}
+
+func (dec *shellVariablesDecoder) atEnd() bool {
+	return dec.pos >= len(dec.input)
+}
+
+func (dec *shellVariablesDecoder) skipBlanks() {
+	for dec.peek(0) == ' ' || dec.peek(0) == '\t' || dec.peek(0) == '\r' {
+		dec.next()
+	}
+}
+
+func (dec *shellVariablesDecoder) readLine() string {
+	var sb strings.Builder
+	for !dec.atEnd() && dec.peek(0) != '\n' {
+		sb.WriteRune(dec.next())
+	}
+	return strings.TrimRight(sb.String(), "\r")
+}
+
+// nextVariable reads the next `[export] NAME=value`, collecting the comments before it.
+// The returned value is nil when there are no more variables.
+func (dec *shellVariablesDecoder) nextVariable() (string, *CandidateNode, error) {
+	for {
+		dec.skipBlanks()
+		switch {
+		case dec.atEnd():
+			return "", nil, nil
+		case dec.peek(0) == '\n':
+			dec.next()
+		case dec.peek(0) == '#':
+			dec.comment = append(dec.comment, dec.readLine())
+		default:
+			return dec.readVariable()
+		}
+	}
+}
+
+func (dec *shellVariablesDecoder) readName() string {
+	var sb strings.Builder
+	for !dec.atEnd() && !unicode.IsSpace(dec.peek(0)) && dec.peek(0) != '=' {
+		sb.WriteRune(dec.next())
+	}
+	return sb.String()
+}
+
+func (dec *shellVariablesDecoder) readVariable() (string, *CandidateNode, error) {
+	line := dec.line
+	name := dec.readName()
+	if name == "export" && (dec.peek(0) == ' ' || dec.peek(0) == '\t') {
+		dec.skipBlanks()
+		name = dec.readName()
+	}
+	if dec.peek(0) != '=' {
+		return "", nil, fmt.Errorf("line %v: expected NAME=value, but got '%v'", line, name+dec.readLine())
+	} else if !isValidShellVariableName(name) {
+		return "", nil, fmt.Errorf("line %v: '%v' is not a valid variable name", line, name)
+	}
+	dec.next()
+
+	value, err := dec.readValue()
+	if err != nil {
+		return "", nil, fmt.Errorf("line %v: %w", line, err)
+	}
+	// like environment variables, all values are strings
+	valueNode := createStringScalarNode(value)
+
+	dec.skipBlanks()
+	if dec.peek(0) == '#' {
+		valueNode.LineComment = dec.readLine()
+	} else if !dec.atEnd() && dec.peek(0) != '\n' {
+		return "", nil, fmt.Errorf("line %v: unexpected '%v' after the value of %v", line, dec.readLine(), name)
+	}
+	return name, valueNode, nil
+}
+
+func isValidShellVariableName(name string) bool {
+	for index, r := range name {
+		if !isAlphaNumericOrUnderscore(r) || (index == 0 && !isAlphaOrUnderscore(r)) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// readValue reads the (possibly quoted) value up to the end of the line, a comment,
+// or whitespace. Adjacent quoted and unquoted parts are joined, as they are in shells.
+func (dec *shellVariablesDecoder) readValue() (string, error) {
+	var sb strings.Builder
+	for !dec.atEnd() {
+		r := dec.peek(0)
+		switch {
+		case r == '\'':
+			dec.next()
+			if err := dec.readUntilQuote(&sb, '\''); err != nil {
+				return "", err
+			}
+		case r == '$' && dec.peek(1) == '\'':
+			dec.next()
+			dec.next()
+			if err := dec.readAnsiCQuoted(&sb); err != nil {
+				return "", err
+			}
+		case r == '"':
+			dec.next()
+			if err := dec.readDoubleQuoted(&sb); err != nil {
+				return "", err
+			}
+		case r == '\\' && dec.peek(1) == '\n':
+			// line continuation
+			dec.next()
+			dec.next()
+		case r == '\\' && dec.peek(1) == '\r' && dec.peek(2) == '\n':
+			dec.next()
+			dec.next()
+			dec.next()
+		case r == '\\':
+			dec.next()
+			if !dec.atEnd() {
+				sb.WriteRune(dec.next())
+			}
+		case unicode.IsSpace(r):
+			return sb.String(), nil
+		default:
+			sb.WriteRune(dec.next())
+		}
+	}
+	return sb.String(), nil
+}
+
+func (dec *shellVariablesDecoder) readUntilQuote(sb *strings.Builder, quote rune) error {
+	for !dec.atEnd() {
+		r := dec.next()
+		if r == quote {
+			return nil
+		}
+		sb.WriteRune(r)
+	}
+	return fmt.Errorf("missing closing %c quote", quote)
+}
+
+// readDoubleQuoted reads a "..." value, where only \", \\, \$, \` and line continuations are escaped.
+// Variables are not expanded.
+func (dec *shellVariablesDecoder) readDoubleQuoted(sb *strings.Builder) error {
+	for !dec.atEnd() {
+		r := dec.next()
+		switch {
+		case r == '"':
+			return nil
+		case r == '\\' && strings.ContainsRune("\"\\$`", dec.peek(0)):
+			sb.WriteRune(dec.next())
+		case r == '\\' && dec.peek(0) == '\n':
+			dec.next()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return fmt.Errorf("missing closing \" quote")
+}
+
+var ansiCEscapes = map[rune]string{
+	'a': "\a", 'b': "\b", 'e': "\x1b", 'E': "\x1b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+	'\\': "\\", '\'': "'", '"': "\"", '?': "?",
+}
+
+// readAnsiCQuoted reads a $'...' value, which supports C style escapes like \n, \t, \xHH and \uHHHH.
+func (dec *shellVariablesDecoder) readAnsiCQuoted(sb *strings.Builder) error {
+	for !dec.atEnd() {
+		r := dec.next()
+		if r == '\'' {
+			return nil
+		} else if r != '\\' {
+			sb.WriteRune(r)
+			continue
+		}
+
+		escape := dec.next()
+		if replacement, ok := ansiCEscapes[escape]; ok {
+			sb.WriteString(replacement)
+			continue
+		}
+		base, maxDigits := 0, 0
+		switch escape {
+		case 'x':
+			base, maxDigits = 16, 2
+		case 'u':
+			base, maxDigits = 16, 4
+		case 'U':
+			base, maxDigits = 16, 8
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			base, maxDigits = 8, 3
+			dec.pos--
+		default:
+			sb.WriteRune('\\')
+			sb.WriteRune(escape)
+			continue
+		}
+		digits := ""
+		for len(digits) < maxDigits && isDigitInBase(dec.peek(0), base) {
+			digits += string(dec.next())
+		}
+		code, err := strconv.ParseInt(digits, base, 32)
+		if err != nil {
+			return fmt.Errorf("invalid escape \\%c%v", escape, digits)
+		}
+		if base == 8 || escape == 'x' {
+			sb.WriteByte(byte(code))
+		} else {
+			sb.WriteRune(rune(code))
+		}
+	}
+	return fmt.Errorf("missing closing ' quote")
+}
+
+// setVariable adds the variable to the map, un-flattening the name into nested maps and
+// arrays on the key separator if configured.
+func (dec *shellVariablesDecoder) setVariable(rootMap *CandidateNode, name string, value *CandidateNode) error {
+	path := []string{name}
+	if dec.prefs.UnflattenKeys && dec.prefs.KeySeparator != "" {
+		path = strings.Split(name, dec.prefs.KeySeparator)
+	}
+	comment := strings.Join(dec.comment, "\n")
+	dec.comment = nil
+
+	current := rootMap
+	for index, segment := range path[:len(path)-1] {
+		existing := findShellVariableChild(current, segment)
+		if existing == nil {
+			existing = &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+			if _, err := strconv.ParseUint(path[index+1], 10, 31); err == nil {
+				existing = &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+			}
+			if err := setShellVariableChild(current, segment, existing, ""); err != nil {
+				return fmt.Errorf("cannot un-flatten %v: %w", name, err)
+			}
+		} else if existing.Kind != MappingNode && existing.Kind != SequenceNode {
+			return fmt.Errorf("cannot un-flatten %v, %v is already set", name, strings.Join(path[:index+1], dec.prefs.KeySeparator))
+		}
+		current = existing
+	}
+
+	segment := path[len(path)-1]
+	if findShellVariableChild(current, segment) != nil {
+		return fmt.Errorf("%v is set more than once", name)
+	}
+	if err := setShellVariableChild(current, segment, value, comment); err != nil {
+		return fmt.Errorf("cannot un-flatten %v: %w", name, err)
+	}
+	return nil
+}
+
+// findShellVariableChild returns the child of the map or array, nulls in arrays are treated as unset.
+func findShellVariableChild(parent *CandidateNode, segment string) *CandidateNode {
+	if parent.Kind == SequenceNode {
+		index, err := strconv.Atoi(segment)
+		if err != nil || index >= len(parent.Content) || parent.Content[index].Tag == "!!null" {
+			return nil
+		}
+		return parent.Content[index]
+	}
+	for index := 0; index < len(parent.Content); index = index + 2 {
+		if parent.Content[index].Value == segment {
+			return parent.Content[index+1]
+		}
+	}
+	return nil
+}
+
+// setShellVariableChild adds the child without copying it, so nested variables can be added to it.
+func setShellVariableChild(parent *CandidateNode, segment string, child *CandidateNode, comment string) error {
+	child.SetParent(parent)
+	if parent.Kind == MappingNode {
+		key := createStringScalarNode(segment)
+		key.HeadComment = comment
+		key.IsMapKey = true
+		key.SetParent(parent)
+		child.Key = key
+		parent.Content = append(parent.Content, key, child)
+		return nil
+	}
+
+	index, err := strconv.ParseUint(segment, 10, 31)
+	if err != nil {
+		return fmt.Errorf("%v is not an array index", segment)
+	} else if index > uint64(len(parent.Content)+shellVariablesMaxArrayGap) {
+		return fmt.Errorf("array index %v is more than %v past the end of the array", segment, shellVariablesMaxArrayGap)
+	}
+	for uint64(len(parent.Content)) <= index {
+		parent.AddChild(createScalarNode(nil, "null"))
+	}
+	child.HeadComment = comment
+	replaceChild(parent, int(index), child)
+	return nil
+}
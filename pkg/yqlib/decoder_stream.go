@@ -0,0 +1,35 @@
+package yqlib
+
+import (
+	"container/list"
+	"io"
+)
+
+// streamDecoder wraps another decoder, returning each document it reads as a
+// series of [path, leaf] events (like jq's --stream) rather than a single tree.
+// Each document is still decoded in full; see NewJSONStreamDecoder for json
+// input that is read incrementally.
+type streamDecoder struct {
+	decoder Decoder
+	events  *list.List
+}
+
+func NewStreamDecoder(decoder Decoder) Decoder {
+	return &streamDecoder{decoder: decoder}
+}
+
+func (dec *streamDecoder) Init(reader io.Reader) error {
+	dec.events = list.New()
+	return dec.decoder.Init(reader)
+}
+
+func (dec *streamDecoder) Decode() (*CandidateNode, error) {
+	for dec.events.Len() == 0 {
+		node, err := dec.decoder.Decode()
+		if err != nil {
+			return nil, err
+		}
+		appendStreamEvents(node, []interface{}{}, dec.events)
+	}
+	return dec.events.Remove(dec.events.Front()).(*CandidateNode), nil
+}
@@ -3,6 +3,7 @@ package yqlib
 import (
 	"bufio"
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
@@ -74,3 +75,17 @@ func mustProcessFormatScenario(s formatScenario, decoder Decoder, encoder Encode
 	return result
 
 }
+
+// fromHex decodes the hex of the scenario, which can have spaces to make it readable.
+func fromHex(hexString string) string {
+	data, err := hex.DecodeString(strings.ReplaceAll(hexString, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func withHexInput(s formatScenario) formatScenario {
+	s.input = fromHex(s.input)
+	return s
+}
@@ -0,0 +1,37 @@
+//go:build !yq_nobase32
+
+package yqlib
+
+import (
+	"encoding/base32"
+	"fmt"
+	"io"
+)
+
+type base32Encoder struct {
+	encoding base32.Encoding
+}
+
+func NewBase32Encoder() Encoder {
+	return &base32Encoder{encoding: *base32.StdEncoding}
+}
+
+func (e *base32Encoder) CanHandleAliases() bool {
+	return false
+}
+
+func (e *base32Encoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (e *base32Encoder) PrintLeadingContent(_ io.Writer, _ string) error {
+	return nil
+}
+
+func (e *base32Encoder) Encode(writer io.Writer, node *CandidateNode) error {
+	if node.guessTagFromCustomType() != "!!str" {
+		return fmt.Errorf("cannot encode %v as base32, can only operate on strings", node.Tag)
+	}
+	_, err := writer.Write([]byte(e.encoding.EncodeToString([]byte(node.Value))))
+	return err
+}
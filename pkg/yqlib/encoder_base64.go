@@ -16,6 +16,10 @@ func NewBase64Encoder() Encoder {
 	return &base64Encoder{encoding: *base64.StdEncoding}
 }
 
+func NewBase64URLEncoder() Encoder {
+	return &base64Encoder{encoding: *base64.URLEncoding}
+}
+
 func (e *base64Encoder) CanHandleAliases() bool {
 	return false
 }
@@ -0,0 +1,176 @@
+//go:build !yq_nocbor
+
+package yqlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// cborEncoder writes each document as a CBOR item, using the smallest encoding of each value.
+type cborEncoder struct {
+}
+
+func NewCBOREncoder() Encoder {
+	return &cborEncoder{}
+}
+
+func (ce *cborEncoder) CanHandleAliases() bool {
+	return false
+}
+
+func (ce *cborEncoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (ce *cborEncoder) PrintLeadingContent(_ io.Writer, _ string) error {
+	return nil
+}
+
+func (ce *cborEncoder) Encode(writer io.Writer, node *CandidateNode) error {
+	var buf bytes.Buffer
+	if err := encodeCBORNode(&buf, node); err != nil {
+		return err
+	}
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+// writeCBORHead writes the major type with the argument in the fewest bytes.
+func writeCBORHead(buf *bytes.Buffer, major byte, argument uint64) {
+	major = major << 5
+	switch {
+	case argument < 24:
+		buf.WriteByte(major | byte(argument))
+	case argument <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(argument))
+	case argument <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		writeBigEndianUint(buf, argument, 2)
+	case argument <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		writeBigEndianUint(buf, argument, 4)
+	default:
+		buf.WriteByte(major | 27)
+		writeBigEndianUint(buf, argument, 8)
+	}
+}
+
+func encodeCBORNode(buf *bytes.Buffer, node *CandidateNode) error {
+	switch node.Kind {
+	case MappingNode:
+		writeCBORHead(buf, cborMap, uint64(len(node.Content)/2))
+		for _, child := range node.Content {
+			if err := encodeCBORNode(buf, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case SequenceNode:
+		writeCBORHead(buf, cborArray, uint64(len(node.Content)))
+		for _, child := range node.Content {
+			if err := encodeCBORNode(buf, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch node.guessTagFromCustomType() {
+	case "!!null":
+		buf.WriteByte(0xF6)
+	case "!!bool":
+		if isTruthyNode(node) {
+			buf.WriteByte(0xF5)
+		} else {
+			buf.WriteByte(0xF4)
+		}
+	case "!!int":
+		value, err := parseBigInt(node.Value)
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as a cbor integer (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		encodeCBORInt(buf, value)
+	case "!!float":
+		value, err := parseFloatValue(node.Value)
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as a cbor float (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		encodeCBORFloat(buf, value)
+	case "!!timestamp":
+		date, err := parseDateTime(time.RFC3339, node.Value)
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as a cbor date (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		encodeCBORDate(buf, date)
+	case "!!binary":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as a cbor byte string: %w", node.GetNicePath(), err)
+		}
+		writeCBORHead(buf, cborByteString, uint64(len(data)))
+		buf.Write(data)
+	default:
+		writeCBORHead(buf, cborTextString, uint64(len(node.Value)))
+		buf.WriteString(node.Value)
+	}
+	return nil
+}
+
+func encodeCBORInt(buf *bytes.Buffer, value *big.Int) {
+	major, tag := byte(cborUnsignedInt), uint64(cborTagPositiveBig)
+	if value.Sign() < 0 {
+		// negative integers are encoded as -1 - n
+		major, tag = cborNegativeInt, cborTagNegativeBig
+		value = new(big.Int).Not(value)
+	}
+	if value.IsUint64() {
+		writeCBORHead(buf, major, value.Uint64())
+		return
+	}
+	writeCBORHead(buf, cborTag, tag)
+	data := value.Bytes()
+	writeCBORHead(buf, cborByteString, uint64(len(data)))
+	buf.Write(data)
+}
+
+func encodeCBORFloat(buf *bytes.Buffer, value float64) {
+	if half, ok := float64ToFloat16(value); ok {
+		buf.WriteByte(cborSimple<<5 | 25)
+		writeBigEndianUint(buf, uint64(half), 2)
+	} else if float64(float32(value)) == value {
+		buf.WriteByte(cborSimple<<5 | 26)
+		writeBigEndianUint(buf, uint64(math.Float32bits(float32(value))), 4)
+	} else {
+		buf.WriteByte(cborSimple<<5 | 27)
+		writeBigEndianUint(buf, math.Float64bits(value), 8)
+	}
+}
+
+// encodeCBORDate writes an epoch date, as they are smaller than date/time strings, unless
+// the fractional seconds would lose precision as a float.
+func encodeCBORDate(buf *bytes.Buffer, date time.Time) {
+	if date.Nanosecond() == 0 {
+		writeCBORHead(buf, cborTag, cborTagEpoch)
+		encodeCBORInt(buf, big.NewInt(date.Unix()))
+		return
+	}
+	seconds := float64(date.Unix()) + float64(date.Nanosecond())/1e9
+	whole, fraction := math.Modf(seconds)
+	if time.Unix(int64(whole), int64(math.Round(fraction*1e9))).Equal(date) {
+		writeCBORHead(buf, cborTag, cborTagEpoch)
+		encodeCBORFloat(buf, seconds)
+		return
+	}
+	text := date.Format(time.RFC3339Nano)
+	writeCBORHead(buf, cborTag, cborTagDateTime)
+	writeCBORHead(buf, cborTextString, uint64(len(text)))
+	buf.WriteString(text)
+}
@@ -0,0 +1,56 @@
+package yqlib
+
+import (
+	"crypto/md5"  //nolint:gosec // for checksums, not security
+	"crypto/sha1" //nolint:gosec // for checksums, not security
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+type hashEncoder struct {
+	newHash func() hash.Hash
+}
+
+// NewHashEncoder creates an encoder that hashes scalar values, or the
+// canonical json encoding of maps and arrays, as a hex string.
+func NewHashEncoder(newHash func() hash.Hash) Encoder {
+	return &hashEncoder{newHash: newHash}
+}
+
+func NewSha256Encoder() Encoder {
+	return NewHashEncoder(sha256.New)
+}
+
+func NewSha1Encoder() Encoder {
+	return NewHashEncoder(sha1.New)
+}
+
+func NewMd5Encoder() Encoder {
+	return NewHashEncoder(md5.New)
+}
+
+func (e *hashEncoder) CanHandleAliases() bool {
+	return false
+}
+
+func (e *hashEncoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (e *hashEncoder) PrintLeadingContent(_ io.Writer, _ string) error {
+	return nil
+}
+
+func (e *hashEncoder) Encode(writer io.Writer, node *CandidateNode) error {
+	value, err := encodeToText(node, true)
+	if err != nil {
+		return err
+	}
+	hasher := e.newHash()
+	if _, err := hasher.Write([]byte(value)); err != nil {
+		return err
+	}
+	return writeString(writer, hex.EncodeToString(hasher.Sum(nil)))
+}
@@ -0,0 +1,218 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// json5Encoder writes JSON with the comments as // comments (or /* */ comments when
+// printing on a single line), which is valid JSONC and JSON5.
+type json5Encoder struct {
+	prefs        JsonPreferences
+	indentString string
+}
+
+func NewJSON5Encoder(prefs JsonPreferences) Encoder {
+	return &json5Encoder{prefs, strings.Repeat(" ", prefs.Indent)}
+}
+
+func (je *json5Encoder) CanHandleAliases() bool {
+	return false
+}
+
+func (je *json5Encoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (je *json5Encoder) PrintLeadingContent(writer io.Writer, content string) error {
+	reader := bufio.NewReader(strings.NewReader(content))
+	var buf bytes.Buffer
+	for {
+		line, errReading := reader.ReadString('\n')
+		if strings.HasPrefix(line, "#") {
+			je.writeComment(&buf, strings.TrimRight(line, "\r\n"))
+			buf.WriteString("\n")
+		}
+		if errReading != nil {
+			break
+		}
+	}
+	return writeString(writer, buf.String())
+}
+
+func (je *json5Encoder) Encode(writer io.Writer, node *CandidateNode) error {
+	log.Debugf("I need to encode %v", NodeToString(node))
+
+	if node.Kind == ScalarNode && je.prefs.UnwrapScalar {
+		return writeString(writer, node.Value+"\n")
+	}
+
+	var buf bytes.Buffer
+	for _, comment := range commentLines(node.HeadComment) {
+		je.writeComment(&buf, comment)
+		buf.WriteString(je.newLine(""))
+	}
+	if err := je.encodeNode(&buf, node, "", node.LineComment); err != nil {
+		return err
+	}
+	if node.Kind == ScalarNode {
+		je.writeLineComment(&buf, node.LineComment)
+	}
+	if !isEmptyCollection(node) {
+		je.writeComments(&buf, node.FootComment, "")
+	}
+	buf.WriteString("\n")
+	// colours are not supported, as the yaml based colouriser does not understand // comments
+	return writeString(writer, buf.String())
+}
+
+func (je *json5Encoder) newLine(indent string) string {
+	if je.prefs.Indent == 0 {
+		return ""
+	}
+	return "\n" + indent
+}
+
+// writeComment converts the yaml style comment line to a // (or /* */) comment.
+func (je *json5Encoder) writeComment(buf *bytes.Buffer, comment string) {
+	text := strings.TrimPrefix(strings.TrimSpace(comment), "#")
+	if je.prefs.Indent == 0 {
+		buf.WriteString("/*" + strings.ReplaceAll(text, "*/", "* /") + " */")
+		return
+	}
+	buf.WriteString("//" + text)
+}
+
+func commentLines(comments string) []string {
+	lines := make([]string, 0)
+	for _, comment := range strings.Split(comments, "\n") {
+		if strings.TrimSpace(comment) != "" {
+			lines = append(lines, comment)
+		}
+	}
+	return lines
+}
+
+// writeComments writes each comment line on a new line at the given indent.
+func (je *json5Encoder) writeComments(buf *bytes.Buffer, comments string, indent string) {
+	for _, comment := range commentLines(comments) {
+		buf.WriteString(je.newLine(indent))
+		je.writeComment(buf, comment)
+	}
+}
+
+func (je *json5Encoder) writeLineComment(buf *bytes.Buffer, comments string) {
+	for _, comment := range commentLines(comments) {
+		buf.WriteString(" ")
+		je.writeComment(buf, comment)
+	}
+}
+
+func marshalJSON5(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false) // do not escape html chars e.g. &, <, >
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func (je *json5Encoder) encodeScalar(buf *bytes.Buffer, node *CandidateNode) error {
+	if node.guessTagFromCustomType() == "!!float" {
+		// json5 supports Infinity and NaN
+		switch value := strings.ToLower(node.Value); value {
+		case ".inf", "+.inf", "-.inf":
+			buf.WriteString(strings.TrimSuffix(value, ".inf") + "Infinity")
+			return nil
+		case ".nan":
+			buf.WriteString("NaN")
+			return nil
+		}
+	}
+	encoded, err := marshalJSON5(node)
+	if err != nil {
+		return err
+	}
+	buf.Write(encoded)
+	return nil
+}
+
+func isEmptyCollection(node *CandidateNode) bool {
+	return (node.Kind == MappingNode || node.Kind == SequenceNode) && len(node.Content) == 0
+}
+
+// encodeNode writes the node, where openComment is written after the opening bracket of maps and arrays.
+func (je *json5Encoder) encodeNode(buf *bytes.Buffer, node *CandidateNode, indent string, openComment string) error {
+	if node.Kind == ScalarNode || node.Kind == AliasNode {
+		return je.encodeScalar(buf, node)
+	}
+
+	opening, closing, step := "[", "]", 1
+	if node.Kind == MappingNode {
+		opening, closing, step = "{", "}", 2
+	}
+	if len(node.Content) == 0 && openComment == "" && node.FootComment == "" {
+		buf.WriteString(opening + closing)
+		return nil
+	}
+
+	buf.WriteString(opening)
+	je.writeLineComment(buf, openComment)
+	childIndent := indent + je.indentString
+	for index := 0; index < len(node.Content); index = index + step {
+		key, value := (*CandidateNode)(nil), node.Content[index]
+		headComment := value.HeadComment
+		if node.Kind == MappingNode {
+			key, value = node.Content[index], node.Content[index+1]
+			headComment = joinCommentLines(key.HeadComment, value.HeadComment)
+		}
+		je.writeComments(buf, headComment, childIndent)
+		buf.WriteString(je.newLine(childIndent))
+
+		valueOpenComment, lineComment := "", value.LineComment
+		if key != nil {
+			keyBytes, err := marshalJSON5(key.Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(keyBytes)
+			buf.WriteString(":")
+			if je.prefs.Indent > 0 {
+				buf.WriteString(" ")
+			}
+			lineComment = joinCommentLines(key.LineComment, value.LineComment)
+		}
+		if value.Kind == MappingNode || value.Kind == SequenceNode {
+			valueOpenComment, lineComment = lineComment, ""
+		}
+		if err := je.encodeNode(buf, value, childIndent, valueOpenComment); err != nil {
+			return err
+		}
+		if index+step < len(node.Content) {
+			buf.WriteString(",")
+		}
+		je.writeLineComment(buf, lineComment)
+
+		footComment := value.FootComment
+		if isEmptyCollection(value) {
+			// written inside the brackets
+			footComment = ""
+		}
+		if key != nil {
+			footComment = joinCommentLines(key.FootComment, footComment)
+		}
+		je.writeComments(buf, footComment, childIndent)
+	}
+	if len(node.Content) == 0 {
+		je.writeComments(buf, node.FootComment, childIndent)
+	}
+	buf.WriteString(je.newLine(indent) + closing)
+	return nil
+}
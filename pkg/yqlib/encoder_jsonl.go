@@ -0,0 +1,12 @@
+//go:build !yq_nojson
+
+package yqlib
+
+// NewJSONLEncoder writes each document as compact JSON on its own line,
+// scalars are always quoted so each line is valid JSON.
+func NewJSONLEncoder(prefs JsonPreferences) Encoder {
+	jsonlPrefs := prefs.Copy()
+	jsonlPrefs.Indent = 0
+	jsonlPrefs.UnwrapScalar = false
+	return NewJSONEncoder(jsonlPrefs)
+}
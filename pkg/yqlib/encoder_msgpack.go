@@ -0,0 +1,198 @@
+//go:build !yq_nomsgpack
+
+package yqlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// msgpackEncoder writes each document as a MessagePack object, using the smallest encoding of each value.
+type msgpackEncoder struct {
+}
+
+func NewMsgpackEncoder() Encoder {
+	return &msgpackEncoder{}
+}
+
+func (me *msgpackEncoder) CanHandleAliases() bool {
+	return false
+}
+
+func (me *msgpackEncoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (me *msgpackEncoder) PrintLeadingContent(_ io.Writer, _ string) error {
+	return nil
+}
+
+func (me *msgpackEncoder) Encode(writer io.Writer, node *CandidateNode) error {
+	var buf bytes.Buffer
+	if err := encodeMsgpackNode(&buf, node); err != nil {
+		return err
+	}
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+// writeMsgpackLength writes the marker for the length, fixMarker is used when the length is below fixLimit,
+// otherwise the first of the 8 (if supported), 16 or 32 bit markers that fits.
+func writeMsgpackLength(buf *bytes.Buffer, length int, fixMarker byte, fixLimit int, markers []byte) error {
+	if length < fixLimit {
+		buf.WriteByte(fixMarker | byte(length))
+		return nil
+	}
+	sizes := []int{1, 2, 4}[3-len(markers):]
+	for index, size := range sizes {
+		if uint64(length) < 1<<(8*size) {
+			buf.WriteByte(markers[index])
+			writeBigEndianUint(buf, uint64(length), size)
+			return nil
+		}
+	}
+	return fmt.Errorf("length %v is too long for msgpack", length)
+}
+
+func encodeMsgpackNode(buf *bytes.Buffer, node *CandidateNode) error {
+	switch node.Kind {
+	case MappingNode:
+		if err := writeMsgpackLength(buf, len(node.Content)/2, 0x80, 16, []byte{0xDE, 0xDF}); err != nil {
+			return err
+		}
+		for _, child := range node.Content {
+			if err := encodeMsgpackNode(buf, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case SequenceNode:
+		if err := writeMsgpackLength(buf, len(node.Content), 0x90, 16, []byte{0xDC, 0xDD}); err != nil {
+			return err
+		}
+		for _, child := range node.Content {
+			if err := encodeMsgpackNode(buf, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch node.guessTagFromCustomType() {
+	case "!!null":
+		buf.WriteByte(0xC0)
+	case "!!bool":
+		if isTruthyNode(node) {
+			buf.WriteByte(0xC3)
+		} else {
+			buf.WriteByte(0xC2)
+		}
+	case "!!int":
+		return encodeMsgpackInt(buf, node)
+	case "!!float":
+		value, err := parseFloatValue(node.Value)
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as a msgpack float (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		if math.IsNaN(value) || float64(float32(value)) == value {
+			buf.WriteByte(0xCA)
+			writeBigEndianUint(buf, uint64(math.Float32bits(float32(value))), 4)
+		} else {
+			buf.WriteByte(0xCB)
+			writeBigEndianUint(buf, math.Float64bits(value), 8)
+		}
+	case "!!timestamp":
+		date, err := parseDateTime(time.RFC3339, node.Value)
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as a msgpack timestamp (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		encodeMsgpackTimestamp(buf, date)
+	case "!!binary":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+		if err != nil {
+			return fmt.Errorf("cannot encode %v as msgpack bin: %w", node.GetNicePath(), err)
+		}
+		// there is no fixbin, so the limit of 0 always uses a bin marker
+		if err := writeMsgpackLength(buf, len(data), 0, 0, []byte{0xC4, 0xC5, 0xC6}); err != nil {
+			return err
+		}
+		buf.Write(data)
+	default:
+		if err := writeMsgpackLength(buf, len(node.Value), 0xA0, 32, []byte{0xD9, 0xDA, 0xDB}); err != nil {
+			return err
+		}
+		buf.WriteString(node.Value)
+	}
+	return nil
+}
+
+func encodeMsgpackInt(buf *bytes.Buffer, node *CandidateNode) error {
+	value, err := parseBigInt(node.Value)
+	if err != nil {
+		return fmt.Errorf("cannot encode %v as a msgpack integer (%v): %w", node.Value, node.GetNicePath(), err)
+	}
+
+	switch {
+	case value.Sign() >= 0 && value.IsUint64():
+		unsigned := value.Uint64()
+		switch {
+		case unsigned <= 0x7F:
+			buf.WriteByte(byte(unsigned))
+		case unsigned <= math.MaxUint8:
+			buf.WriteByte(0xCC)
+			writeBigEndianUint(buf, unsigned, 1)
+		case unsigned <= math.MaxUint16:
+			buf.WriteByte(0xCD)
+			writeBigEndianUint(buf, unsigned, 2)
+		case unsigned <= math.MaxUint32:
+			buf.WriteByte(0xCE)
+			writeBigEndianUint(buf, unsigned, 4)
+		default:
+			buf.WriteByte(0xCF)
+			writeBigEndianUint(buf, unsigned, 8)
+		}
+	case value.IsInt64():
+		signed := value.Int64()
+		switch {
+		case signed >= -32:
+			buf.WriteByte(byte(signed))
+		case signed >= math.MinInt8:
+			buf.WriteByte(0xD0)
+			writeBigEndianUint(buf, uint64(signed), 1)
+		case signed >= math.MinInt16:
+			buf.WriteByte(0xD1)
+			writeBigEndianUint(buf, uint64(signed), 2)
+		case signed >= math.MinInt32:
+			buf.WriteByte(0xD2)
+			writeBigEndianUint(buf, uint64(signed), 4)
+		default:
+			buf.WriteByte(0xD3)
+			writeBigEndianUint(buf, uint64(signed), 8)
+		}
+	default:
+		return fmt.Errorf("cannot encode %v as a msgpack integer (%v): it does not fit in 64 bits", node.Value, node.GetNicePath())
+	}
+	return nil
+}
+
+// encodeMsgpackTimestamp writes the timestamp extension, in its 32, 64 or 96 bit form.
+func encodeMsgpackTimestamp(buf *bytes.Buffer, date time.Time) {
+	seconds, nanoseconds := date.Unix(), uint64(date.Nanosecond())
+	switch {
+	case nanoseconds == 0 && seconds >= 0 && seconds <= math.MaxUint32:
+		buf.Write([]byte{0xD6, 0xFF})
+		writeBigEndianUint(buf, uint64(seconds), 4)
+	case seconds >= 0 && seconds < 1<<34:
+		buf.Write([]byte{0xD7, 0xFF})
+		writeBigEndianUint(buf, nanoseconds<<34|uint64(seconds), 8)
+	default:
+		buf.Write([]byte{0xC7, 12, 0xFF})
+		writeBigEndianUint(buf, nanoseconds, 4)
+		writeBigEndianUint(buf, uint64(seconds), 8)
+	}
+}
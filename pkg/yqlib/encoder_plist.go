@@ -0,0 +1,338 @@
+//go:build !yq_noplist
+
+package yqlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const plistXMLHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+type plistEncoder struct {
+	prefs PlistPreferences
+}
+
+func NewPlistEncoder(prefs PlistPreferences) Encoder {
+	return &plistEncoder{prefs}
+}
+
+func (pe *plistEncoder) CanHandleAliases() bool {
+	return false
+}
+
+func (pe *plistEncoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (pe *plistEncoder) PrintLeadingContent(_ io.Writer, _ string) error {
+	return nil
+}
+
+func (pe *plistEncoder) Encode(writer io.Writer, node *CandidateNode) error {
+	if pe.prefs.Binary {
+		encoded, err := encodeBinaryPlist(node)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(encoded)
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(plistXMLHeader)
+	if err := encodePlistXMLNode(&buf, node, ""); err != nil {
+		return err
+	}
+	buf.WriteString("</plist>\n")
+	return writeString(writer, buf.String())
+}
+
+// plistScalar is a scalar converted to its plist type (string, integer, real, true,
+// false, date or data) and value.
+type plistScalar struct {
+	plistType string
+	value     string
+	intValue  int64
+	realValue float64
+	dateValue time.Time
+	dataValue []byte
+}
+
+func toPlistScalar(node *CandidateNode) (plistScalar, error) {
+	switch node.guessTagFromCustomType() {
+	case "!!null":
+		return plistScalar{}, fmt.Errorf("plist does not support null (%v)", node.GetNicePath())
+	case "!!int":
+		_, intValue, err := parseInt64(node.Value)
+		if err != nil {
+			return plistScalar{}, fmt.Errorf("cannot encode %v as a plist integer (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		return plistScalar{plistType: "integer", value: fmt.Sprintf("%v", intValue), intValue: intValue}, nil
+	case "!!float":
+		realValue, err := parseFloatValue(node.Value)
+		if err != nil {
+			return plistScalar{}, fmt.Errorf("cannot encode %v as a plist real (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		value := strconv.FormatFloat(realValue, 'g', -1, 64)
+		switch {
+		case math.IsInf(realValue, 1):
+			value = "+infinity"
+		case math.IsInf(realValue, -1):
+			value = "-infinity"
+		case math.IsNaN(realValue):
+			value = "nan"
+		}
+		return plistScalar{plistType: "real", value: value, realValue: realValue}, nil
+	case "!!bool":
+		if isTruthyNode(node) {
+			return plistScalar{plistType: "true"}, nil
+		}
+		return plistScalar{plistType: "false"}, nil
+	case "!!timestamp":
+		date, err := parseDateTime(time.RFC3339, node.Value)
+		if err != nil {
+			return plistScalar{}, fmt.Errorf("cannot encode %v as a plist date (%v): %w", node.Value, node.GetNicePath(), err)
+		}
+		date = date.UTC()
+		return plistScalar{plistType: "date", value: date.Format(time.RFC3339), dateValue: date}, nil
+	case "!!binary":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+		if err != nil {
+			return plistScalar{}, fmt.Errorf("cannot encode %v as plist data: %w", node.GetNicePath(), err)
+		}
+		return plistScalar{plistType: "data", value: base64.StdEncoding.EncodeToString(data), dataValue: data}, nil
+	}
+	return plistScalar{plistType: "string", value: node.Value}, nil
+}
+
+func writePlistText(buf *bytes.Buffer, text string) error {
+	return xml.EscapeText(buf, []byte(text))
+}
+
+// encodePlistXMLNode writes the node as canonical (tab indented) plist xml.
+func encodePlistXMLNode(buf *bytes.Buffer, node *CandidateNode, indent string) error {
+	switch node.Kind {
+	case MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString(indent + "<dict/>\n")
+			return nil
+		}
+		buf.WriteString(indent + "<dict>\n")
+		for index := 0; index < len(node.Content); index = index + 2 {
+			buf.WriteString(indent + "\t<key>")
+			if err := writePlistText(buf, node.Content[index].Value); err != nil {
+				return err
+			}
+			buf.WriteString("</key>\n")
+			if err := encodePlistXMLNode(buf, node.Content[index+1], indent+"\t"); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</dict>\n")
+		return nil
+	case SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString(indent + "<array/>\n")
+			return nil
+		}
+		buf.WriteString(indent + "<array>\n")
+		for _, child := range node.Content {
+			if err := encodePlistXMLNode(buf, child, indent+"\t"); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</array>\n")
+		return nil
+	}
+
+	scalar, err := toPlistScalar(node)
+	if err != nil {
+		return err
+	}
+	if scalar.plistType == "true" || scalar.plistType == "false" {
+		buf.WriteString(fmt.Sprintf("%v<%v/>\n", indent, scalar.plistType))
+		return nil
+	}
+	buf.WriteString(fmt.Sprintf("%v<%v>", indent, scalar.plistType))
+	if err := writePlistText(buf, scalar.value); err != nil {
+		return err
+	}
+	buf.WriteString(fmt.Sprintf("</%v>\n", scalar.plistType))
+	return nil
+}
+
+// binaryPlistWriter writes the bplist00 format, each node is written as its own object.
+type binaryPlistWriter struct {
+	objects       bytes.Buffer
+	offsets       []uint64
+	objectRefSize int
+}
+
+func countPlistObjects(node *CandidateNode) int {
+	count := 1
+	for _, child := range node.Content {
+		count = count + countPlistObjects(child)
+	}
+	return count
+}
+
+func minimumBytes(value uint64) int {
+	switch {
+	case value <= math.MaxUint8:
+		return 1
+	case value <= math.MaxUint16:
+		return 2
+	case value <= math.MaxUint32:
+		return 4
+	}
+	return 8
+}
+
+func encodeBinaryPlist(node *CandidateNode) ([]byte, error) {
+	numObjects := countPlistObjects(node)
+	writer := &binaryPlistWriter{objectRefSize: minimumBytes(uint64(numObjects))}
+	writer.objects.WriteString(plistBinaryHeader)
+	if _, err := writer.writeObject(node); err != nil {
+		return nil, err
+	}
+
+	offsetTableOffset := uint64(writer.objects.Len())
+	offsetIntSize := minimumBytes(offsetTableOffset)
+	result := &writer.objects
+	for _, offset := range writer.offsets {
+		writeBigEndianUint(result, offset, offsetIntSize)
+	}
+	// trailer: 6 unused bytes, the int sizes, the number of objects, the top object and the offset table offset
+	result.Write(make([]byte, 6))
+	result.WriteByte(byte(offsetIntSize))
+	result.WriteByte(byte(writer.objectRefSize))
+	writeBigEndianUint(result, uint64(len(writer.offsets)), 8)
+	writeBigEndianUint(result, 0, 8)
+	writeBigEndianUint(result, offsetTableOffset, 8)
+	return result.Bytes(), nil
+}
+
+// writeMarker writes the object type with its count, which follows as an int when it does not fit in the marker.
+func (w *binaryPlistWriter) writeMarker(objectType byte, count int) {
+	if count < 0x0F {
+		w.objects.WriteByte(objectType | byte(count))
+		return
+	}
+	w.objects.WriteByte(objectType | 0x0F)
+	w.writeInt(int64(count))
+}
+
+func (w *binaryPlistWriter) writeInt(value int64) {
+	size := 8
+	if value >= 0 {
+		size = minimumBytes(uint64(value))
+	}
+	// the low nibble is log2 of the size
+	w.objects.WriteByte(0x10 | byte(bits.TrailingZeros(uint(size))))
+	writeBigEndianUint(&w.objects, uint64(value), size)
+}
+
+// writeObject writes the node and its children, returning the reference of the node.
+func (w *binaryPlistWriter) writeObject(node *CandidateNode) (int, error) {
+	ref := len(w.offsets)
+	w.offsets = append(w.offsets, 0)
+
+	switch node.Kind {
+	case MappingNode, SequenceNode:
+		// children are written after this object, so work out their references first
+		refs := make([]int, 0, len(node.Content))
+		childRef := ref + 1
+		for _, child := range node.Content {
+			refs = append(refs, childRef)
+			childRef = childRef + countPlistObjects(child)
+		}
+		count := len(node.Content)
+		objectType := byte(0xA0)
+		if node.Kind == MappingNode {
+			// keys first, then values
+			count = count / 2
+			objectType = 0xD0
+			ordered := make([]int, 0, len(refs))
+			for index := 0; index < len(refs); index = index + 2 {
+				ordered = append(ordered, refs[index])
+			}
+			for index := 1; index < len(refs); index = index + 2 {
+				ordered = append(ordered, refs[index])
+			}
+			refs = ordered
+		}
+		w.offsets[ref] = uint64(w.objects.Len())
+		w.writeMarker(objectType, count)
+		for _, childRef := range refs {
+			writeBigEndianUint(&w.objects, uint64(childRef), w.objectRefSize)
+		}
+		for index, child := range node.Content {
+			if node.Kind == MappingNode && index%2 == 0 {
+				// keys are always strings
+				child = createStringScalarNode(child.Value)
+			}
+			if _, err := w.writeObject(child); err != nil {
+				return 0, err
+			}
+		}
+		return ref, nil
+	}
+
+	scalar, err := toPlistScalar(node)
+	if err != nil {
+		return 0, err
+	}
+	w.offsets[ref] = uint64(w.objects.Len())
+	switch scalar.plistType {
+	case "true":
+		w.objects.WriteByte(0x09)
+	case "false":
+		w.objects.WriteByte(0x08)
+	case "integer":
+		w.writeInt(scalar.intValue)
+	case "real":
+		w.objects.WriteByte(0x23)
+		writeBigEndianUint(&w.objects, math.Float64bits(scalar.realValue), 8)
+	case "date":
+		w.objects.WriteByte(0x33)
+		seconds := scalar.dateValue.Sub(plistEpoch).Seconds()
+		writeBigEndianUint(&w.objects, math.Float64bits(seconds), 8)
+	case "data":
+		w.writeMarker(0x40, len(scalar.dataValue))
+		w.objects.Write(scalar.dataValue)
+	default:
+		if isASCII(scalar.value) {
+			w.writeMarker(0x50, len(scalar.value))
+			w.objects.WriteString(scalar.value)
+		} else {
+			units := utf16.Encode([]rune(scalar.value))
+			w.writeMarker(0x60, len(units))
+			for _, unit := range units {
+				writeBigEndianUint(&w.objects, uint64(unit), 2)
+			}
+		}
+	}
+	return ref, nil
+}
+
+func isASCII(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if value[index] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package yqlib
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+type textEncoder struct {
+	transform func(string) string
+}
+
+// the same escapes as jq's @html
+var htmlEscaper = strings.NewReplacer("<", "&lt;", ">", "&gt;", "&", "&amp;", "'", "&#39;", "\"", "&quot;")
+
+// NewTextEncoder creates an encoder that writes scalar values, or the json
+// encoding of maps and arrays, as they are - like jq's @text.
+func NewTextEncoder() Encoder {
+	return &textEncoder{transform: func(value string) string { return value }}
+}
+
+func NewHTMLEncoder() Encoder {
+	return &textEncoder{transform: htmlEscaper.Replace}
+}
+
+func NewHexEncoder() Encoder {
+	return &textEncoder{transform: func(value string) string { return hex.EncodeToString([]byte(value)) }}
+}
+
+func (e *textEncoder) CanHandleAliases() bool {
+	return false
+}
+
+func (e *textEncoder) PrintDocumentSeparator(_ io.Writer) error {
+	return nil
+}
+
+func (e *textEncoder) PrintLeadingContent(_ io.Writer, _ string) error {
+	return nil
+}
+
+func (e *textEncoder) Encode(writer io.Writer, node *CandidateNode) error {
+	value, err := encodeToText(node, false)
+	if err != nil {
+		return err
+	}
+	return writeString(writer, e.transform(value))
+}
+
+func sortKeysRecursively(node *CandidateNode) {
+	if node.Kind == MappingNode {
+		sortKeys(node)
+	}
+	for _, child := range node.Content {
+		sortKeysRecursively(child)
+	}
+}
+
+// encodeToText returns the value of scalars, and the compact json encoding of maps and arrays.
+// Canonical encodings have their keys sorted, so they don't depend on the order of the keys.
+func encodeToText(node *CandidateNode, canonical bool) (string, error) {
+	if node.Kind == ScalarNode {
+		return node.Value, nil
+	}
+	if canonical {
+		node = node.Copy()
+		sortKeysRecursively(node)
+	}
+	encoded, err := encodeToString(node, encoderPreferences{format: JSONFormat, indent: 0})
+	if err != nil {
+		return "", err
+	}
+	return chomper.ReplaceAllString(encoded, ""), nil
+}
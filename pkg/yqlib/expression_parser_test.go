@@ -16,6 +16,21 @@ func TestParserCreateMapColonOnItsOwn(t *testing.T) {
 	test.AssertResultComplex(t, "':' expects 2 args but there is 0", err.Error())
 }
 
+func TestParserFunctionDefinitionWithoutSemicolon(t *testing.T) {
+	_, err := getExpressionParser().ParseExpression("def f: 1")
+	test.AssertResultComplex(t, "bad expression, definition of f must end with ';'", err.Error())
+}
+
+func TestParserFunctionDefinitionWithoutExpression(t *testing.T) {
+	_, err := getExpressionParser().ParseExpression("def f: 1;")
+	test.AssertResultComplex(t, "bad expression, definition of f must be followed by an expression", err.Error())
+}
+
+func TestParserForeachWithoutBlock(t *testing.T) {
+	_, err := getExpressionParser().ParseExpression("foreach .[] as $x 0")
+	test.AssertResultComplex(t, "bad expression, foreach must be of the form `foreach <exp> as $<name> (<init>; <update>; <extract>)`", err.Error())
+}
+
 func TestParserNoMatchingCloseBracket(t *testing.T) {
 	_, err := getExpressionParser().ParseExpression(".cat | with(.;.bob")
 	test.AssertResultComplex(t, "bad expression - probably missing close bracket on WITH", err.Error())
@@ -71,8 +86,8 @@ func TestParserTwoArgsForTwoArgOp(t *testing.T) {
 }
 
 func TestParserNoArgsForOneArgOp(t *testing.T) {
-	_, err := getExpressionParser().ParseExpression("explode")
-	test.AssertResultComplex(t, "'explode' expects 1 arg but received none", err.Error())
+	_, err := getExpressionParser().ParseExpression("sortKeys")
+	test.AssertResultComplex(t, "'sortKeys' expects 1 arg but received none", err.Error())
 }
 
 func TestParserOneArgForOneArgOp(t *testing.T) {
@@ -310,6 +310,36 @@ var pathTests = []struct {
 		append(make([]interface{}, 0), "foo*", "PIPE", "(", "SELF", "ASSIGN_STYLE", "flow (string)", ")"),
 		append(make([]interface{}, 0), "foo*", "SELF", "flow (string)", "ASSIGN_STYLE", "PIPE"),
 	},
+	{
+		`def f: .a; f | g(.b)`,
+		append(make([]interface{}, 0), "(", "(", "a", ")", "DEF", "CALL (f)", "PIPE", "CALL_WITH_ARGS (g)", "(", "b", ")", ")"),
+		append(make([]interface{}, 0), "a", "CALL (f)", "b", "CALL_WITH_ARGS (g)", "PIPE", "DEF"),
+	},
+	{
+		`def f: def g: 1; g; f`,
+		append(make([]interface{}, 0), "(", "(", "(", "(", "1 (int64)", ")", "DEF", "CALL (g)", ")", ")", "DEF", "CALL (f)", ")"),
+		append(make([]interface{}, 0), "1 (int64)", "CALL (g)", "DEF", "CALL (f)", "DEF"),
+	},
+	{
+		`mapper`,
+		append(make([]interface{}, 0), "CALL (mapper)"),
+		append(make([]interface{}, 0), "CALL (mapper)"),
+	},
+	{
+		`recurse | recurse(.a)`,
+		append(make([]interface{}, 0), "CALL (recurse)", "PIPE", "RECURSE", "(", "a", ")"),
+		append(make([]interface{}, 0), "CALL (recurse)", "a", "RECURSE", "PIPE"),
+	},
+	{
+		`foreach .[] as $x (0; . + $x)`,
+		append(make([]interface{}, 0), "(", "SELF", "TRAVERSE_ARRAY", "[", "EMPTY", "]", "ASSIGN_VARIABLE", "GET_VARIABLE", "FOREACH", "(", "0 (int64)", "BLOCK", "SELF", "ADD", "GET_VARIABLE", ")", ")"),
+		append(make([]interface{}, 0), "SELF", "EMPTY", "COLLECT", "TRAVERSE_ARRAY", "GET_VARIABLE", "ASSIGN_VARIABLE", "0 (int64)", "SELF", "GET_VARIABLE", "ADD", "BLOCK", "FOREACH"),
+	},
+	{
+		`. as [$a] ?// $a | $a`,
+		append(make([]interface{}, 0), "SELF", "ASSIGN_VARIABLE", "[", "GET_VARIABLE", "]", "DESTRUCTURE_ALTERNATIVE", "GET_VARIABLE", "PIPE", "GET_VARIABLE"),
+		append(make([]interface{}, 0), "SELF", "GET_VARIABLE", "COLLECT", "GET_VARIABLE", "DESTRUCTURE_ALTERNATIVE", "ASSIGN_VARIABLE", "GET_VARIABLE", "PIPE"),
+	},
 }
 
 var tokeniser = newParticipleLexer()
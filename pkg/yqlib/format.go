@@ -33,6 +33,16 @@ var JSONFormat = &Format{"json", []string{"j"},
 	func() Decoder { return NewJSONDecoder() },
 }
 
+var JSONLFormat = &Format{"jsonl", []string{"ndjson", "jl"},
+	func() Encoder { return NewJSONLEncoder(ConfiguredJSONPreferences) },
+	func() Decoder { return NewJSONLDecoder(ConfiguredJSONLPreferences) },
+}
+
+var JSON5Format = &Format{"json5", []string{"jsonc"},
+	func() Encoder { return NewJSON5Encoder(ConfiguredJSONPreferences) },
+	func() Decoder { return NewJSON5Decoder() },
+}
+
 var PropertiesFormat = &Format{"props", []string{"p", "properties"},
 	func() Encoder { return NewPropertiesEncoder(ConfiguredPropertiesPreferences) },
 	func() Decoder { return NewPropertiesDecoder() },
@@ -58,6 +68,16 @@ var Base64Format = &Format{"base64", []string{},
 	func() Decoder { return NewBase64Decoder() },
 }
 
+var Base64URLFormat = &Format{"base64url", []string{},
+	func() Encoder { return NewBase64URLEncoder() },
+	func() Decoder { return NewBase64URLDecoder() },
+}
+
+var Base32Format = &Format{"base32", []string{},
+	func() Encoder { return NewBase32Encoder() },
+	func() Decoder { return NewBase32Decoder() },
+}
+
 var UriFormat = &Format{"uri", []string{},
 	func() Encoder { return NewUriEncoder() },
 	func() Decoder { return NewUriDecoder() },
@@ -68,6 +88,37 @@ var ShFormat = &Format{"", nil,
 	nil,
 }
 
+// these are only available as @format operators, like @sha256
+var Sha256Format = &Format{"", nil,
+	func() Encoder { return NewSha256Encoder() },
+	nil,
+}
+
+var Sha1Format = &Format{"", nil,
+	func() Encoder { return NewSha1Encoder() },
+	nil,
+}
+
+var Md5Format = &Format{"", nil,
+	func() Encoder { return NewMd5Encoder() },
+	nil,
+}
+
+var HexFormat = &Format{"", nil,
+	func() Encoder { return NewHexEncoder() },
+	nil,
+}
+
+var HTMLFormat = &Format{"", nil,
+	func() Encoder { return NewHTMLEncoder() },
+	nil,
+}
+
+var TextFormat = &Format{"", nil,
+	func() Encoder { return NewTextEncoder() },
+	nil,
+}
+
 var TomlFormat = &Format{"toml", []string{},
 	func() Encoder { return NewTomlEncoderWithPrefs(ConfiguredTomlPreferences) },
 	func() Decoder { return NewTomlDecoder() },
@@ -80,7 +131,7 @@ var HclFormat = &Format{"hcl", []string{"h", "tf"},
 
 var ShellVariablesFormat = &Format{"shell", []string{"s", "sh"},
 	func() Encoder { return NewShellVariablesEncoder() },
-	nil,
+	func() Decoder { return NewShellVariablesDecoder() },
 }
 
 var LuaFormat = &Format{"lua", []string{"l"},
@@ -88,6 +139,21 @@ var LuaFormat = &Format{"lua", []string{"l"},
 	func() Decoder { return NewLuaDecoder(ConfiguredLuaPreferences) },
 }
 
+var PlistFormat = &Format{"plist", []string{},
+	func() Encoder { return NewPlistEncoder(ConfiguredPlistPreferences) },
+	func() Decoder { return NewPlistDecoder() },
+}
+
+var CBORFormat = &Format{"cbor", []string{},
+	func() Encoder { return NewCBOREncoder() },
+	func() Decoder { return NewCBORDecoder() },
+}
+
+var MsgpackFormat = &Format{"msgpack", []string{"mp"},
+	func() Encoder { return NewMsgpackEncoder() },
+	func() Decoder { return NewMsgpackDecoder() },
+}
+
 var INIFormat = &Format{"ini", []string{"i"},
 	func() Encoder { return NewINIEncoder() },
 	func() Decoder { return NewINIDecoder(ConfiguredINIPreferences) },
@@ -97,11 +163,15 @@ var Formats = []*Format{
 	YamlFormat,
 	KYamlFormat,
 	JSONFormat,
+	JSONLFormat,
+	JSON5Format,
 	PropertiesFormat,
 	CSVFormat,
 	TSVFormat,
 	XMLFormat,
 	Base64Format,
+	Base64URLFormat,
+	Base32Format,
 	UriFormat,
 	ShFormat,
 	TomlFormat,
@@ -109,6 +179,9 @@ var Formats = []*Format{
 	ShellVariablesFormat,
 	LuaFormat,
 	INIFormat,
+	PlistFormat,
+	CBORFormat,
+	MsgpackFormat,
 }
 
 func (f *Format) MatchesName(name string) bool {
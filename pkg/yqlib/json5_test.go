@@ -0,0 +1,274 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"bufio"
+	"fmt"
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+const sampleJSONC = `// Compiler settings
+{
+  "compilerOptions": {
+    "target": "es2020", // keep in sync with the node version
+    /* strict mode
+       catches more bugs */
+    "strict": true,
+    "paths": [
+      "src", // sources
+      "test",
+    ],
+  },
+}
+`
+
+const expectedJSONCYaml = `# Compiler settings
+compilerOptions:
+  target: es2020 # keep in sync with the node version
+  # strict mode
+  # catches more bugs
+  strict: true
+  paths:
+    - src # sources
+    - test
+`
+
+const expectedJSONC = `// Compiler settings
+{
+  "compilerOptions": {
+    "target": "es2021", // keep in sync with the node version
+    // strict mode
+    // catches more bugs
+    "strict": true,
+    "paths": [
+      "src", // sources
+      "test"
+    ]
+  }
+}
+`
+
+var json5Scenarios = []formatScenario{
+	{
+		description:    "Parse JSONC",
+		subdescription: "Comments are kept and trailing commas are allowed.",
+		input:          sampleJSONC,
+		expected:       expectedJSONCYaml,
+		scenarioType:   "decode",
+	},
+	{
+		description:    "Update JSONC, keeping the comments",
+		subdescription: "Note that trailing commas are not kept.",
+		input:          sampleJSONC,
+		expression:     `.compilerOptions.target = "es2021"`,
+		expected:       expectedJSONC,
+		scenarioType:   "roundtrip",
+	},
+	{
+		description:    "Parse JSON5",
+		subdescription: "Keys can be unquoted, strings single quoted and numbers can be hex, have leading or trailing decimal points, or be Infinity and NaN.",
+		input:          "{unquoted: 'and you can quote me on that', hex: 0xDECAF, half: .5, five: 5., big: +Infinity, 'multi line': 'one \\\ntwo'}\n",
+		expected:       "unquoted: and you can quote me on that\nhex: 0xDECAF\nhalf: 0.5\nfive: 5.0\nbig: .inf\nmulti line: one two\n",
+		scenarioType:   "decode",
+	},
+	{
+		description:    "Encode JSONC",
+		subdescription: "Yaml comments are written as // comments.",
+		input:          "# the app\nname: yq # the name\nports:\n  # http\n  - 80\n  - 443\n# the end\n",
+		expected:       "// the app\n{\n  \"name\": \"yq\", // the name\n  \"ports\": [\n    // http\n    80,\n    443\n  ]\n  // the end\n}\n",
+		scenarioType:   "encode",
+	},
+	{
+		description:  "Encode JSONC on one line",
+		skipDoc:      true,
+		input:        "# the app\nname: yq # the name\n",
+		indent:       0,
+		expected:     "/* the app */\n{\"name\":\"yq\" /* the name */}\n",
+		scenarioType: "encode-compact",
+	},
+	{
+		description:  "Encode infinity",
+		skipDoc:      true,
+		input:        "[.inf, -.Inf, .nan, 0x10, 'a<b']",
+		expected:     "[\n  Infinity,\n  -Infinity,\n  NaN,\n  16,\n  \"a<b\"\n]\n",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Encode empty collections with comments",
+		skipDoc:      true,
+		input:        "{a: [ // none yet\n], b: {}}\n",
+		expected:     "{\n  \"a\": [ // none yet\n  ],\n  \"b\": {}\n}\n",
+		scenarioType: "roundtrip",
+	},
+	{
+		description:  "Comments after collection values stay on their keys",
+		skipDoc:      true,
+		input:        "{\n  a: [1], // about a\n  b: 2,\n  c: { // open c\n    d: 1\n  }, // after c\n}\n",
+		expected:     "{\n  \"a\": [ // about a\n    1\n  ],\n  \"b\": 2,\n  \"c\": { // open c # after c\n    \"d\": 1\n  }\n}\n",
+		scenarioType: "roundtrip",
+	},
+	{
+		description:  "Decode comments after collection values",
+		skipDoc:      true,
+		input:        "{\n  a: [1], // about a\n  b: 2,\n  c: [[1], // after c0\n    2]\n}\n",
+		expected:     "a: # about a\n  - 1\nb: 2\nc:\n  # after c0\n  - - 1\n  - 2\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Parse multiple documents",
+		skipDoc:      true,
+		input:        "{a: 1}\n// second\n{b: 2} // end\n",
+		expected:     "{\n  \"a\": 1\n}\n// second\n{ // end\n  \"b\": 2\n}\n",
+		scenarioType: "roundtrip",
+	},
+	{
+		description:  "Parse strings with escapes",
+		skipDoc:      true,
+		input:        `["\x41\u00e9\ud83d\ude00\t\'\"\0", 'it\'s']`,
+		expected:     "- \"Aé\\U0001F600\\t'\\\"\\0\"\n- it's\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Parse numbers",
+		skipDoc:      true,
+		input:        "[-1, +2, -0x1f, 1e3, -.5e-2, 2.E1, -Infinity, NaN]",
+		expected:     "- -1\n- 2\n- -0x1f\n- 1e3\n- -0.5e-2\n- 2.0E1\n- -.inf\n- .nan\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Parse unquoted key with escapes",
+		skipDoc:      true,
+		input:        `{$a_\u0062: 1}`,
+		expected:     "$a_b: 1\n",
+		scenarioType: "decode",
+	},
+	{
+		description:   "Missing comma",
+		skipDoc:       true,
+		input:         "{a: 1\nb: 2}",
+		expectedError: "bad file 'sample.yml': line 2: expected ',' or '}' but got 'b'",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Missing colon",
+		skipDoc:       true,
+		input:         "{a 1}",
+		expectedError: "bad file 'sample.yml': line 1: expected ':' after key 'a' but got '1'",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Unclosed comment",
+		skipDoc:       true,
+		input:         "{a: 1}\n/* oops",
+		expectedError: "bad file 'sample.yml': line 2: unclosed /* comment",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Unclosed string",
+		skipDoc:       true,
+		input:         "['a\n']",
+		expectedError: "bad file 'sample.yml': line 1: new line in string, use \\n or a \\ at the end of the line",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Invalid number",
+		skipDoc:       true,
+		input:         "[012]",
+		expectedError: "bad file 'sample.yml': line 1: invalid number '012'",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Unclosed array",
+		skipDoc:       true,
+		input:         "[1, 2",
+		expectedError: "bad file 'sample.yml': line 1: expected ',' or ']' but got end of file",
+		scenarioType:  "decode-error",
+	},
+}
+
+func json5TestPreferences(indent int) JsonPreferences {
+	prefs := ConfiguredJSONPreferences.Copy()
+	prefs.Indent = indent
+	prefs.ColorsEnabled = false
+	return prefs
+}
+
+func testJSON5Scenario(t *testing.T, s formatScenario) {
+	switch s.scenarioType {
+	case "decode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewJSON5Decoder(), NewYamlEncoder(ConfiguredYamlPreferences)), s.description)
+	case "encode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewJSON5Encoder(json5TestPreferences(2))), s.description)
+	case "encode-compact":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewJSON5Encoder(json5TestPreferences(0))), s.description)
+	case "roundtrip":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewJSON5Decoder(), NewJSON5Encoder(json5TestPreferences(2))), s.description)
+	case "decode-error":
+		result, err := processFormatScenario(s, NewJSON5Decoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		if err == nil {
+			t.Errorf("Expected error '%v' but it worked: %v", s.expectedError, result)
+		} else {
+			test.AssertResultComplexWithContext(t, s.expectedError, err.Error(), s.description)
+		}
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func documentJSON5Scenario(_ *testing.T, w *bufio.Writer, i interface{}) {
+	s := i.(formatScenario)
+	if s.skipDoc {
+		return
+	}
+	writeOrPanic(w, fmt.Sprintf("## %v\n", s.description))
+
+	if s.subdescription != "" {
+		writeOrPanic(w, s.subdescription)
+		writeOrPanic(w, "\n\n")
+	}
+
+	expression := s.expression
+	if expression == "" {
+		expression = "."
+	}
+
+	switch s.scenarioType {
+	case "decode":
+		writeOrPanic(w, "Given a sample.json5 file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```json5\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -p=json5 -o=yaml '%v' sample.json5\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n\n", mustProcessFormatScenario(s, NewJSON5Decoder(), NewYamlEncoder(ConfiguredYamlPreferences))))
+	case "encode":
+		writeOrPanic(w, "Given a sample.yml file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=jsonc '%v' sample.yml\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```jsonc\n%v```\n\n", mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewJSON5Encoder(json5TestPreferences(2)))))
+	case "roundtrip":
+		writeOrPanic(w, "Given a tsconfig.json file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```jsonc\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -p=jsonc -o=jsonc '%v' tsconfig.json\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```jsonc\n%v```\n\n", mustProcessFormatScenario(s, NewJSON5Decoder(), NewJSON5Encoder(json5TestPreferences(2)))))
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func TestJSON5Scenarios(t *testing.T) {
+	for _, tt := range json5Scenarios {
+		testJSON5Scenario(t, tt)
+	}
+	genericScenarios := make([]interface{}, len(json5Scenarios))
+	for i, s := range json5Scenarios {
+		genericScenarios[i] = s
+	}
+	documentScenarios(t, "usage", "json5", genericScenarios, documentJSON5Scenario)
+}
@@ -0,0 +1,19 @@
+package yqlib
+
+type JsonlPreferences struct {
+	SkipInvalidLines bool
+}
+
+func NewDefaultJsonlPreferences() JsonlPreferences {
+	return JsonlPreferences{
+		SkipInvalidLines: false,
+	}
+}
+
+func (p *JsonlPreferences) Copy() JsonlPreferences {
+	return JsonlPreferences{
+		SkipInvalidLines: p.SkipInvalidLines,
+	}
+}
+
+var ConfiguredJSONLPreferences = NewDefaultJsonlPreferences()
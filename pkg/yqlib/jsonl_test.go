@@ -0,0 +1,162 @@
+//go:build !yq_nojson
+
+package yqlib
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+const sampleJSONL = `{"level": "info", "msg": "started", "port": 8080}
+{"level": "error", "msg": "failed", "error": {"code": 500}}
+`
+
+var jsonlScenarios = []formatScenario{
+	{
+		description:  "Parse JSON Lines",
+		input:        sampleJSONL,
+		expected:     "level: info\nmsg: started\nport: 8080\n---\nlevel: error\nmsg: failed\nerror:\n  code: 500\n",
+		scenarioType: "decode",
+	},
+	{
+		description:    "Filter JSON Lines",
+		subdescription: "Each line is a separate document, and each result is written on its own line.",
+		input:          sampleJSONL,
+		expression:     `select(.level == "error") | .error`,
+		expected:       "{\"code\":500}\n",
+		scenarioType:   "roundtrip",
+	},
+	{
+		description:    "Encode JSON Lines",
+		subdescription: "Documents are written as compact JSON, one per line, without document separators.",
+		input:          "name: first\ntags: [a, b]\n---\nname: second\nnote: |\n  multi\n  line\n",
+		expected:       "{\"name\":\"first\",\"tags\":[\"a\",\"b\"]}\n{\"name\":\"second\",\"note\":\"multi\\nline\\n\"}\n",
+		scenarioType:   "encode",
+	},
+	{
+		description:    "Encode scalars as JSON Lines",
+		subdescription: "Scalars are quoted, so that each line is valid JSON.",
+		input:          "[cat, 3, true]",
+		expression:     ".[]",
+		expected:       "\"cat\"\n3\ntrue\n",
+		scenarioType:   "encode",
+	},
+	{
+		description:    "Skip invalid lines",
+		subdescription: "Use --jsonl-skip-invalid to skip lines that are not valid JSON, a warning is logged for each of them.",
+		input:          "{\"a\": 1}\n{\"a\": \n\n{\"a\": 3}\n",
+		expected:       "{\"a\":1}\n{\"a\":3}\n",
+		scenarioType:   "roundtrip-skip-invalid",
+	},
+	{
+		description:  "Blank lines and windows line endings",
+		skipDoc:      true,
+		input:        "\r\n{\"a\": 1}\r\n  \r\n[2]",
+		expected:     "{\"a\":1}\n[2]\n",
+		scenarioType: "roundtrip",
+	},
+	{
+		description:   "Invalid line",
+		skipDoc:       true,
+		input:         "{\"a\": 1}\n\n{\"a\": 2} {\"a\": 3}\n",
+		expectedError: "bad file 'sample.yml': line 3: ",
+		scenarioType:  "decode-error",
+	},
+}
+
+func jsonlTestPreferences() JsonPreferences {
+	prefs := ConfiguredJSONPreferences.Copy()
+	prefs.Indent = 2
+	prefs.ColorsEnabled = false
+	return prefs
+}
+
+func testJSONLScenario(t *testing.T, s formatScenario) {
+	switch s.scenarioType {
+	case "decode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewJSONLDecoder(NewDefaultJsonlPreferences()), NewYamlEncoder(ConfiguredYamlPreferences)), s.description)
+	case "encode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewJSONLEncoder(jsonlTestPreferences())), s.description)
+	case "roundtrip":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewJSONLDecoder(NewDefaultJsonlPreferences()), NewJSONLEncoder(jsonlTestPreferences())), s.description)
+	case "roundtrip-skip-invalid":
+		prefs := NewDefaultJsonlPreferences()
+		prefs.SkipInvalidLines = true
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewJSONLDecoder(prefs), NewJSONLEncoder(jsonlTestPreferences())), s.description)
+	case "decode-error":
+		result, err := processFormatScenario(s, NewJSONLDecoder(NewDefaultJsonlPreferences()), NewJSONLEncoder(jsonlTestPreferences()))
+		if err == nil {
+			t.Errorf("Expected error '%v' but it worked: %v", s.expectedError, result)
+		} else {
+			test.AssertResultWithContext(t, true, strings.HasPrefix(err.Error(), s.expectedError),
+				fmt.Sprintf("Expected [%v] to start with [%v]", err.Error(), s.expectedError))
+		}
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func documentJSONLScenario(_ *testing.T, w *bufio.Writer, i interface{}) {
+	s := i.(formatScenario)
+	if s.skipDoc {
+		return
+	}
+	writeOrPanic(w, fmt.Sprintf("## %v\n", s.description))
+
+	if s.subdescription != "" {
+		writeOrPanic(w, s.subdescription)
+		writeOrPanic(w, "\n\n")
+	}
+
+	expression := s.expression
+	if expression == "" {
+		expression = "."
+	}
+
+	switch s.scenarioType {
+	case "decode":
+		writeOrPanic(w, "Given a sample.jsonl file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```json\n%v```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=yaml '%v' sample.jsonl\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n\n", mustProcessFormatScenario(s, NewJSONLDecoder(NewDefaultJsonlPreferences()), NewYamlEncoder(ConfiguredYamlPreferences))))
+	case "encode":
+		writeOrPanic(w, "Given a sample.yml file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=jsonl '%v' sample.yml\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```json\n%v```\n\n", mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewJSONLEncoder(jsonlTestPreferences()))))
+	case "roundtrip", "roundtrip-skip-invalid":
+		prefs := NewDefaultJsonlPreferences()
+		flags := ""
+		if s.scenarioType == "roundtrip-skip-invalid" {
+			prefs.SkipInvalidLines = true
+			flags = " --jsonl-skip-invalid"
+		}
+		writeOrPanic(w, "Given a sample.jsonl file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```json\n%v```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq%v '%v' sample.jsonl\n```\n", flags, expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```json\n%v```\n\n", mustProcessFormatScenario(s, NewJSONLDecoder(prefs), NewJSONLEncoder(jsonlTestPreferences()))))
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func TestJSONLScenarios(t *testing.T) {
+	for _, tt := range jsonlScenarios {
+		testJSONLScenario(t, tt)
+	}
+	genericScenarios := make([]interface{}, len(jsonlScenarios))
+	for i, s := range jsonlScenarios {
+		genericScenarios[i] = s
+	}
+	documentScenarios(t, "usage", "jsonl", genericScenarios, documentJSONLScenario)
+}
@@ -3,6 +3,7 @@ package yqlib
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 type expressionTokeniser interface {
@@ -124,6 +125,30 @@ func handleToken(tokens []*token, index int, postProcessedTokens []*token) (toke
 
 	}
 
+	if tokenIsOpType(currentToken, recurseOpType) && (index == len(tokens)-1 || tokens[index+1].TokenType != openBracket) {
+		log.Debugf("recurse without arguments, calling the builtin function")
+		currentToken.Operation.OperationType = callFunctionOpType
+		currentToken.Operation.Value = callFunctionOpType.Type
+	}
+
+	if tokenIsOpType(currentToken, explodeOpType) && (index == len(tokens)-1 || tokens[index+1].TokenType != openBracket) {
+		log.Debugf("explode without arguments, exploding a string into codepoints")
+		currentToken.Operation.OperationType = explodeCodepointsOpType
+		currentToken.Operation.Value = explodeCodepointsOpType.Type
+	}
+
+	if tokenIsOpType(currentToken, combinationsOpType) && (index == len(tokens)-1 || tokens[index+1].TokenType != openBracket) {
+		log.Debugf("combinations without arguments, combining an array of arrays")
+		currentToken.Operation.OperationType = combinationsOfArraysOpType
+		currentToken.Operation.Value = combinationsOfArraysOpType.Type
+	}
+
+	if index != len(tokens)-1 && tokenIsOpType(currentToken, callFunctionOpType) && tokens[index+1].TokenType == openBracket {
+		log.Debugf("function call with arguments")
+		currentToken.Operation.OperationType = callFunctionWithArgsOpType
+		currentToken.Operation.Value = callFunctionWithArgsOpType.Type
+	}
+
 	if tokenIsOpType(currentToken, createMapOpType) {
 		log.Debugf("tokenIsOpType: createMapOpType")
 		// check the previous token is '[', means we are slice, but dont have a first number
@@ -186,3 +211,133 @@ func handleToken(tokens []*token, index int, postProcessedTokens []*token) (toke
 	}
 	return postProcessedTokens, skipNextToken
 }
+
+func tokenDepthChange(token *token) int {
+	switch token.TokenType {
+	case openBracket, openCollect, openCollectObject:
+		return 1
+	case closeBracket, closeCollect, closeCollectObject:
+		return -1
+	}
+	return 0
+}
+
+// findEndOfExpression returns the index of the first ';' or unmatched closing
+// bracket from start, or the length of tokens if there is none.
+func findEndOfExpression(tokens []*token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		depth = depth + tokenDepthChange(tokens[i])
+		if depth < 0 || (depth == 0 && tokenIsOpType(tokens[i], blockOpType)) {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// rewriteFunctionDefinitions converts `def f: body; rest` into `((body) DEF rest)`
+// so that the definition op has the body as its LHS and the rest of the expression as the RHS.
+// Import directives have no body, and become `(IMPORT rest)`.
+// Definitions are processed last to first, so that any nested definitions
+// have already been bracketed and no longer contain a terminating ';'.
+func rewriteFunctionDefinitions(tokens []*token) ([]*token, error) {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokenIsOpType(tokens[i], importOpType) {
+			restEnd := findEndOfExpression(tokens, i+1)
+			if restEnd == i+1 {
+				return nil, fmt.Errorf("bad expression, %v must be followed by an expression", strings.TrimSpace(tokens[i].Operation.StringValue))
+			}
+			tokens = wrapInBrackets(tokens, i, restEnd)
+			continue
+		}
+		if !tokenIsOpType(tokens[i], functionDefinitionOpType) {
+			continue
+		}
+		definition := tokens[i]
+		name := definition.Operation.Preferences.(functionDefinitionPreferences).Name
+
+		bodyEnd := findEndOfExpression(tokens, i+1)
+		if bodyEnd == len(tokens) || !tokenIsOpType(tokens[bodyEnd], blockOpType) {
+			return nil, fmt.Errorf("bad expression, definition of %v must end with ';'", name)
+		}
+		if bodyEnd == i+1 {
+			return nil, fmt.Errorf("bad expression, definition of %v has no body", name)
+		}
+		restEnd := findEndOfExpression(tokens, bodyEnd+1)
+		if restEnd == bodyEnd+1 {
+			return nil, fmt.Errorf("bad expression, definition of %v must be followed by an expression", name)
+		}
+
+		rewritten := make([]*token, 0, len(tokens)+3)
+		rewritten = append(rewritten, tokens[:i]...)
+		rewritten = append(rewritten, &token{TokenType: openBracket}, &token{TokenType: openBracket})
+		rewritten = append(rewritten, tokens[i+1:bodyEnd]...)
+		rewritten = append(rewritten, &token{TokenType: closeBracket}, definition)
+		rewritten = append(rewritten, tokens[bodyEnd+1:restEnd]...)
+		rewritten = append(rewritten, &token{TokenType: closeBracket})
+		rewritten = append(rewritten, tokens[restEnd:]...)
+		tokens = rewritten
+	}
+	return tokens, nil
+}
+
+// rewriteForeach converts jq's `foreach exp as $x (init; update; extract)` into
+// `(exp as $x FOREACH (init; update; extract))` so that, like ireduce, the foreach op
+// has the variable assignment as its LHS and the block as its RHS.
+func rewriteForeach(tokens []*token) ([]*token, error) {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if !tokenIsOpType(tokens[i], foreachOpType) {
+			continue
+		}
+		foreach := tokens[i]
+		badForeach := fmt.Errorf("bad expression, foreach must be of the form `foreach <exp> as $<name> (<init>; <update>; <extract>)`")
+
+		asIndex := -1
+		blockStart := -1
+		depth := 0
+		for j := i + 1; j < len(tokens) && depth >= 0 && blockStart == -1; j++ {
+			if depth == 0 && asIndex == -1 && tokenIsOpType(tokens[j], assignVariableOpType) {
+				asIndex = j
+			} else if depth == 0 && asIndex != -1 && j > asIndex+1 && tokens[j].TokenType == openBracket {
+				blockStart = j
+			}
+			depth = depth + tokenDepthChange(tokens[j])
+		}
+		if blockStart == -1 {
+			return nil, badForeach
+		}
+
+		blockEnd := -1
+		depth = 0
+		for j := blockStart; j < len(tokens) && blockEnd == -1; j++ {
+			depth = depth + tokenDepthChange(tokens[j])
+			if depth == 0 {
+				blockEnd = j
+			}
+		}
+		if blockEnd == -1 {
+			return nil, badForeach
+		}
+
+		rewritten := make([]*token, 0, len(tokens)+2)
+		rewritten = append(rewritten, tokens[:i]...)
+		rewritten = append(rewritten, &token{TokenType: openBracket})
+		rewritten = append(rewritten, tokens[i+1:blockStart]...)
+		rewritten = append(rewritten, foreach)
+		rewritten = append(rewritten, tokens[blockStart:blockEnd+1]...)
+		rewritten = append(rewritten, &token{TokenType: closeBracket})
+		rewritten = append(rewritten, tokens[blockEnd+1:]...)
+		tokens = rewritten
+	}
+	return tokens, nil
+}
+
+// wrapInBrackets surrounds tokens[start:end] with brackets
+func wrapInBrackets(tokens []*token, start int, end int) []*token {
+	rewritten := make([]*token, 0, len(tokens)+2)
+	rewritten = append(rewritten, tokens[:start]...)
+	rewritten = append(rewritten, &token{TokenType: openBracket})
+	rewritten = append(rewritten, tokens[start:end]...)
+	rewritten = append(rewritten, &token{TokenType: closeBracket})
+	return append(rewritten, tokens[end:]...)
+}
@@ -1,6 +1,9 @@
 package yqlib
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -8,6 +11,12 @@ import (
 )
 
 var participleYqRules = []*participleYqRule{
+	// functions from imported modules, these can not clash with builtin operators
+	{"ModuleFunctionCall", `[a-zA-Z_][a-zA-Z_0-9]*::[a-zA-Z_][a-zA-Z_0-9]*`, functionCallToken(""), 0},
+	{"Import", `import\s+"[^"]*"\s+as\s+\$?[a-zA-Z_][a-zA-Z_0-9]*\s*;`, importToken(false), 0},
+	{"Include", `include\s+"[^"]*"\s*;`, importToken(true), 0},
+	{"FunctionDefinition", `def\s+[a-zA-Z_][a-zA-Z_0-9]*\s*(\([^\)]*\))?\s*:`, functionDefinitionToken(), 0},
+
 	{"LINE_COMMENT", `line_?comment|lineComment`, opTokenWithPrefs(getCommentOpType, assignCommentOpType, commentOpPreferences{LineComment: true}), 0},
 	{"HEAD_COMMENT", `head_?comment|headComment`, opTokenWithPrefs(getCommentOpType, assignCommentOpType, commentOpPreferences{HeadComment: true}), 0},
 	{"FOOT_COMMENT", `foot_?comment|footComment`, opTokenWithPrefs(getCommentOpType, assignCommentOpType, commentOpPreferences{FootComment: true}), 0},
@@ -26,6 +35,11 @@ var participleYqRules = []*participleYqRule{
 	{"RecursiveDecent", `\.\.`, recursiveDecentOpToken(false), 0},
 
 	{"GetVariable", `\$[a-zA-Z_\-0-9]+`, getVariableOpToken(), 0},
+	// these need to come before 'as'
+	{"AsciiUppercase", `ascii_?upcase`, opTokenWithPrefs(changeCaseOpType, nil, changeCasePrefs{ToUpperCase: true, ASCIIOnly: true}), 0},
+	{"AsciiDowncase", `ascii_?downcase`, opTokenWithPrefs(changeCaseOpType, nil, changeCasePrefs{ToUpperCase: false, ASCIIOnly: true}), 0},
+	{"Ascii", `ascii`, opToken(asciiOpType), 0},
+
 	{"AssignAsVariable", `as`, opTokenWithPrefs(assignVariableOpType, nil, assignVarPreferences{}), 0},
 	{"AssignRefVariable", `ref`, opTokenWithPrefs(assignVariableOpType, nil, assignVarPreferences{IsReference: true}), 0},
 
@@ -46,18 +60,28 @@ var participleYqRules = []*participleYqRule{
 	{"Flatten", `flatten`, opTokenWithPrefs(flattenOpType, nil, flattenPreferences{depth: -1}), 0},
 
 	simpleOp("format_datetime", formatDateTimeOpType),
+	simpleOp("format", formatOpType),
+	simpleOp("template", templateOpType),
 	simpleOp("now", nowOpType),
 	simpleOp("tz", tzOpType),
 	simpleOp("from_?unix", fromUnixOpType),
 	simpleOp("to_?unix", toUnixOpType),
 	simpleOp("with_dtf", withDtFormatOpType),
+	simpleOp("date_diff", dateDiffOpType),
+	simpleOp("strftime", strftimeOpType),
+	simpleOp("strptime", strptimeOpType),
+	simpleOp("semver_parse", semverParseOpType),
+	simpleOp("semver_satisfies", semverSatisfiesOpType),
+	simpleOp("semver_bump", semverBumpOpType),
+	simpleOp("with_semver", withSemverOpType),
 	simpleOp("error", errorOpType),
+	{"Try", `try`, opToken(tryOpType), 0},
+	{"Catch", `catch`, opToken(catchOpType), 0},
 	simpleOp("shuffle", shuffleOpType),
 	simpleOp("sortKeys", sortKeysOpType),
 	simpleOp("sort_?keys", sortKeysOpType),
 
-	{"ArrayToMap", "array_?to_?map", expressionOpToken(`(.[] | select(. != null) ) as $i ireduce({}; .[$i | key] = $i)`), 0},
-	{"Root", "root", expressionOpToken(`parent(-1)`), 0},
+	{"ArrayToMap", "array_?to_?map", functionCallToken("array_to_map"), 0},
 	{"YamlEncodeWithIndent", `to_?yaml\([0-9]+\)`, encodeParseIndent(YamlFormat), 0},
 	{"XMLEncodeWithIndent", `to_?xml\([0-9]+\)`, encodeParseIndent(XMLFormat), 0},
 	{"JSONEncodeWithIndent", `to_?json\([0-9]+\)`, encodeParseIndent(JSONFormat), 0},
@@ -81,12 +105,22 @@ var participleYqRules = []*participleYqRule{
 	{"TSVDecode", `from_?tsv|@tsvd`, decodeOp(TSVFormat), 0},
 	{"TSVEncode", `to_?tsv|@tsv`, encodeWithIndent(TSVFormat, 0), 0},
 
+	{"Base64URLd", `@base64urld`, decodeOp(Base64URLFormat), 0},
+	{"Base64URL", `@base64url`, encodeWithIndent(Base64URLFormat, 0), 0},
 	{"Base64d", `@base64d`, decodeOp(Base64Format), 0},
 	{"Base64", `@base64`, encodeWithIndent(Base64Format, 0), 0},
+	{"Base32d", `@base32d`, decodeOp(Base32Format), 0},
+	{"Base32", `@base32`, encodeWithIndent(Base32Format, 0), 0},
 
 	{"Urid", `@urid`, decodeOp(UriFormat), 0},
 	{"Uri", `@uri`, encodeWithIndent(UriFormat, 0), 0},
+	{"Sha256", `@sha256`, encodeWithIndent(Sha256Format, 0), 0},
+	{"Sha1", `@sha1`, encodeWithIndent(Sha1Format, 0), 0},
+	{"Md5", `@md5`, encodeWithIndent(Md5Format, 0), 0},
 	{"SH", `@sh`, encodeWithIndent(ShFormat, 0), 0},
+	{"Hex", `@hex`, encodeWithIndent(HexFormat, 0), 0},
+	{"HTML", `@html`, encodeWithIndent(HTMLFormat, 0), 0},
+	{"Text", `@text`, encodeWithIndent(TextFormat, 0), 0},
 
 	{"LoadXML", `load_?xml|xml_?load`, loadOp(NewXMLDecoder(ConfiguredXMLPreferences)), 0},
 
@@ -104,6 +138,12 @@ var participleYqRules = []*participleYqRule{
 	simpleOp("has", hasOpType),
 	simpleOp("unique_?by", uniqueByOpType),
 	simpleOp("unique", uniqueOpType),
+	simpleOp("union_by", unionByOpType),
+	simpleOp("intersection", intersectionOpType),
+	simpleOp("difference", differenceOpType),
+	simpleOp("merge", mergeOpType),
+	simpleOp("INDEX", sqlIndexOpType),
+	simpleOp("IN", sqlInOpType),
 
 	simpleOp("group_?by", groupByOpType),
 	simpleOp("explode", explodeOpType),
@@ -111,8 +151,11 @@ var participleYqRules = []*participleYqRule{
 	simpleOp("and", andOpType),
 	simpleOp("not", notOpType),
 	simpleOp("ireduce", reduceOpType),
+	simpleOp("foreach", foreachOpType),
 
+	simpleOp("join_by", joinByOpType),
 	simpleOp("join", joinStringOpType),
+	simpleOp("gsub", globalSubStringOpType),
 	simpleOp("sub", subStringOpType),
 	simpleOp("match", matchOpType),
 	simpleOp("capture", captureOpType),
@@ -120,7 +163,14 @@ var participleYqRules = []*participleYqRule{
 
 	simpleOp("sort_?by", sortByOpType),
 	simpleOp("sort", sortOpType),
+	{"FirstOf", `first_of`, opToken(firstOfOpType), 0},
 	simpleOp("first", firstOpType),
+	simpleOp("range", rangeOpType),
+	simpleOp("limit", limitOpType),
+	simpleOp("until", untilOpType),
+	simpleOp("while", whileOpType),
+	simpleOp("recurse", recurseOpType),
+	simpleOp("repeat", repeatOpType),
 
 	simpleOp("reverse", reverseOpType),
 
@@ -131,6 +181,7 @@ var participleYqRules = []*participleYqRule{
 	simpleOp("all", allOpType),
 
 	simpleOp("contains", containsOpType),
+	{"Splits", `splits`, opToken(splitsOpType), 0},
 	simpleOp("split", splitStringOpType),
 
 	simpleOp("parents", getParentsOpType),
@@ -143,9 +194,14 @@ var participleYqRules = []*participleYqRule{
 
 	simpleOp("file_?name|fileName", getFilenameOpType),
 	simpleOp("file_?index|fileIndex|fi", getFileIndexOpType),
+	{"GetValueAtPath", `get_?path`, opToken(getValueAtPathOpType), 0},
 	simpleOp("path", getPathOpType),
 	simpleOp("set_?path", setPathOpType),
 	simpleOp("del_?paths", delPathsOpType),
+	simpleOp("from_paths", fromPathsOpType),
+
+	simpleOp("to_?stream|toStream", toStreamOpType),
+	simpleOp("from_?stream|fromStream", fromStreamOpType),
 
 	simpleOp("to_?entries|toEntries", toEntriesOpType),
 	simpleOp("from_?entries|fromEntries", fromEntriesOpType),
@@ -170,8 +226,16 @@ var participleYqRules = []*participleYqRule{
 
 	{"DocumentIndex", `documentIndex|document_?index|di`, opToken(getDocumentIndexOpType), 0},
 
-	{"Uppercase", `upcase|ascii_?upcase`, opTokenWithPrefs(changeCaseOpType, nil, changeCasePrefs{ToUpperCase: true}), 0},
-	{"Downcase", `downcase|ascii_?downcase`, opTokenWithPrefs(changeCaseOpType, nil, changeCasePrefs{ToUpperCase: false}), 0},
+	{"Uppercase", `upcase`, opTokenWithPrefs(changeCaseOpType, nil, changeCasePrefs{ToUpperCase: true}), 0},
+	{"Downcase", `downcase`, opTokenWithPrefs(changeCaseOpType, nil, changeCasePrefs{ToUpperCase: false}), 0},
+	{"LeftTrimString", `ltrimstr`, opToken(leftTrimStringOpType), 0},
+	{"RightTrimString", `rtrimstr`, opToken(rightTrimStringOpType), 0},
+	{"StartsWith", `startswith`, opToken(startsWithOpType), 0},
+	{"EndsWith", `endswith`, opToken(endsWithOpType), 0},
+	{"Implode", `implode`, opToken(implodeOpType), 0},
+	{"Indices", `indices`, opToken(indicesOpType), 0},
+	{"Index", `index`, opToken(indexOpType), 0},
+	{"Rindex", `rindex`, opToken(rindexOpType), 0},
 	simpleOp("trim", trimOpType),
 	simpleOp("to_?string", toStringOpType),
 
@@ -203,9 +267,34 @@ var participleYqRules = []*participleYqRule{
 	{"GreaterThan", `\s*>\s*`, opTokenWithPrefs(compareOpType, nil, compareTypePref{OrEqual: false, Greater: true}), 0},
 	{"LessThan", `\s*<\s*`, opTokenWithPrefs(compareOpType, nil, compareTypePref{OrEqual: false, Greater: false}), 0},
 
+	{"MinBy", `min_by`, opToken(minByOpType), 0},
+	{"MaxBy", `max_by`, opToken(maxByOpType), 0},
 	simpleOp("min", minOpType),
 	simpleOp("max", maxOpType),
 
+	{"Floor", `floor`, opToken(floorOpType), 0},
+	{"Ceil", `ceil`, opToken(ceilOpType), 0},
+	{"Round", `round`, opToken(roundOpType), 0},
+	{"Abs", `abs`, opToken(absOpType), 0},
+	{"Sqrt", `sqrt`, opToken(sqrtOpType), 0},
+	{"Pow", `pow`, opToken(powOpType), 0},
+	{"Log", `log`, opToken(logOpType), 0},
+	{"Exp", `exp`, opToken(expOpType), 0},
+
+	{"Frequencies", `frequencies`, opToken(frequenciesOpType), 0},
+	{"CountBy", `count_by`, opToken(countByOpType), 0},
+	{"Histogram", `histogram`, opToken(histogramOpType), 0},
+	{"Median", `median`, opToken(medianOpType), 0},
+	{"Percentile", `percentile`, opToken(percentileOpType), 0},
+	{"Stddev", `stddev`, opToken(stddevOpType), 0},
+
+	{"Chunk", `chunk`, opToken(chunkOpType), 0},
+	{"Window", `window`, opToken(windowOpType), 0},
+	{"Zip", `zip`, opToken(zipOpType), 0},
+	{"Transpose", `transpose`, opToken(transposeOpType), 0},
+	{"Combinations", `combinations`, opToken(combinationsOpType), 0},
+	{"Enumerate", `enumerate`, opToken(enumerateOpType), 0},
+
 	{"AssignRelative", `\|=[c]*`, assignOpToken(true), 0},
 	{"Assign", `=[c]*`, assignOpToken(false), 0},
 
@@ -232,9 +321,19 @@ var participleYqRules = []*participleYqRule{
 	{"Subtract", `\-`, opToken(subtractOpType), 0},
 	{"Comment", `#.*`, nil, 0},
 
+	{"DestructureAlternative", `\?//`, opToken(destructureAlternativeOpType), 0},
+	{"ErrorSuppress", `\?`, opToken(tryOpType), 0},
+
 	simpleOp("pivot", pivotOpType),
+
+	// needs to be last, so that it does not match any of the builtin operators
+	{"FunctionCall", `[a-zA-Z_][a-zA-Z_0-9]*`, functionCallToken(""), 0},
 }
 
+var functionParamRegex = regexp.MustCompile(`^\$?[a-zA-Z_][a-zA-Z_0-9]*$`)
+
+var wordRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z_0-9]*$`)
+
 type yqAction func(lexer.Token) (*token, error)
 
 type participleYqRule struct {
@@ -314,11 +413,49 @@ func opTokenWithPrefs(opType *operationType, assignOpType *operationType, prefer
 	}
 }
 
-func expressionOpToken(expression string) yqAction {
-	return func(_ lexer.Token) (*token, error) {
-		prefs := expressionOpPreferences{expression: expression}
-		expressionOp := &Operation{OperationType: expressionOpType, Preferences: prefs}
-		return &token{TokenType: operationToken, Operation: expressionOp}, nil
+func functionDefinitionToken() yqAction {
+	return func(rawToken lexer.Token) (*token, error) {
+		value := rawToken.Value
+		// strip the 'def' and trailing ':'
+		signature := strings.TrimSpace(value[3 : len(value)-1])
+		name := signature
+		params := []string{}
+		if bracketIndex := strings.Index(signature, "("); bracketIndex != -1 {
+			name = strings.TrimSpace(signature[:bracketIndex])
+			params = parseFunctionParams(signature[bracketIndex+1 : len(signature)-1])
+		}
+		for _, param := range params {
+			if !functionParamRegex.MatchString(param) {
+				return nil, fmt.Errorf("invalid parameter '%v' in definition of %v", param, name)
+			}
+		}
+		prefs := functionDefinitionPreferences{Name: name, Params: params}
+		op := &Operation{OperationType: functionDefinitionOpType, Value: functionDefinitionOpType.Type, StringValue: value, Preferences: prefs}
+		return &token{TokenType: operationToken, Operation: op}, nil
+	}
+}
+
+var importRegex = regexp.MustCompile(`^(?:import|include)\s+"([^"]*)"(?:\s+as\s+(\$?)([a-zA-Z_][a-zA-Z_0-9]*))?\s*;$`)
+
+func importToken(include bool) yqAction {
+	return func(rawToken lexer.Token) (*token, error) {
+		value := rawToken.Value
+		matches := importRegex.FindStringSubmatch(value)
+		prefs := importPreferences{Path: matches[1], Alias: matches[3], Include: include, Data: matches[2] == "$"}
+		op := &Operation{OperationType: importOpType, Value: importOpType.Type, StringValue: value, Preferences: prefs}
+		return &token{TokenType: operationToken, Operation: op}, nil
+	}
+}
+
+// functionCallToken creates a call to the given function, or to the matched name if blank.
+func functionCallToken(name string) yqAction {
+	return func(rawToken lexer.Token) (*token, error) {
+		functionName := name
+		if functionName == "" {
+			functionName = rawToken.Value
+		}
+		op := &Operation{OperationType: callFunctionOpType, Value: callFunctionOpType.Type, StringValue: functionName}
+		return &token{TokenType: operationToken, Operation: op, CheckForPostTraverse: true}, nil
 	}
 }
 
@@ -502,7 +639,10 @@ func numberValue() yqAction {
 	return func(rawToken lexer.Token) (*token, error) {
 		var numberString = rawToken.Value
 		var number, errParsingInt = strconv.ParseInt(numberString, 10, 64)
-		if errParsingInt != nil {
+		if errors.Is(errParsingInt, strconv.ErrRange) {
+			// like yaml, ints too big for an int64 are floats
+			return floatValue()(rawToken)
+		} else if errParsingInt != nil {
 			return nil, errParsingInt
 		}
 
@@ -585,16 +725,20 @@ func (p *participleLexer) Tokenise(expression string) ([]*token, error) {
 	if err != nil {
 		return nil, err
 	}
-	tokens := make([]*token, 0)
+	rawTokens := make([]lexer.Token, 0)
 
 	for {
 		rawToken, e := myLexer.Next()
 		if e != nil {
 			return nil, e
 		} else if rawToken.Type == lexer.EOF {
-			return postProcessTokens(tokens), nil
+			break
 		}
+		rawTokens = append(rawTokens, rawToken)
+	}
 
+	tokens := make([]*token, 0)
+	for _, rawToken := range p.mergeFunctionNames(rawTokens) {
 		definition := p.getYqDefinition(rawToken)
 		if definition.CreateYqToken != nil {
 			token, e := definition.CreateYqToken(rawToken)
@@ -603,7 +747,33 @@ func (p *participleLexer) Tokenise(expression string) ([]*token, error) {
 			}
 			tokens = append(tokens, token)
 		}
-
 	}
 
+	tokens, err = rewriteForeach(postProcessTokens(tokens))
+	if err != nil {
+		return nil, err
+	}
+	return rewriteFunctionDefinitions(tokens)
+}
+
+// mergeFunctionNames joins adjacent word tokens into a single function call,
+// so that function names starting with a builtin (e.g. 'mapper') are not split up.
+func (p *participleLexer) mergeFunctionNames(rawTokens []lexer.Token) []lexer.Token {
+	merged := make([]lexer.Token, 0, len(rawTokens))
+	functionCallType := participleYqRules[len(participleYqRules)-1].ParticipleTokenType
+
+	for _, rawToken := range rawTokens {
+		if len(merged) > 0 {
+			previous := merged[len(merged)-1]
+			adjacent := previous.Pos.Offset+len(previous.Value) == rawToken.Pos.Offset
+			if adjacent && wordRegex.MatchString(previous.Value) && wordRegex.MatchString("_"+rawToken.Value) {
+				previous.Value = previous.Value + rawToken.Value
+				previous.Type = functionCallType
+				merged[len(merged)-1] = previous
+				continue
+			}
+		}
+		merged = append(merged, rawToken)
+	}
+	return merged
 }
@@ -2,10 +2,12 @@
 package yqlib
 
 import (
+	"bytes"
 	"container/list"
 	"fmt"
 	"log/slog"
 	"math"
+	"math/big"
 	"strconv"
 	"strings"
 	"sync"
@@ -204,6 +206,52 @@ func parseInt(numberString string) (int, error) {
 	return int(parsed), err
 }
 
+func readBigEndianUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func writeBigEndianUint(buf *bytes.Buffer, value uint64, size int) {
+	for shift := (size - 1) * 8; shift >= 0; shift = shift - 8 {
+		buf.WriteByte(byte(value >> uint(shift)))
+	}
+}
+
+func isDigitInBase(r rune, base int) bool {
+	_, err := strconv.ParseUint(string(r), base, 8)
+	return err == nil
+}
+
+// parseBigInt parses integers that may not fit in an int64, e.g. from binary formats
+// that support unsigned 64 bit integers and bignums.
+func parseBigInt(numberString string) (*big.Int, error) {
+	_, parsed, err := parseInt64(numberString)
+	if err == nil {
+		return big.NewInt(parsed), nil
+	}
+	bigValue, ok := new(big.Int).SetString(numberString, 0)
+	if !ok {
+		return nil, err
+	}
+	return bigValue, nil
+}
+
+// createFloatScalarNode formats the float with the precision of its bit size, keeping
+// a decimal point so that it stays a float.
+func createFloatScalarNode(number float64, bitSize int) *CandidateNode {
+	value := formatFloatValue(number)
+	if !math.IsInf(number, 0) && !math.IsNaN(number) {
+		value = strconv.FormatFloat(number, 'g', -1, bitSize)
+		if !strings.ContainsAny(value, ".eE") {
+			value = value + ".0"
+		}
+	}
+	return &CandidateNode{Kind: ScalarNode, Tag: "!!float", Value: value}
+}
+
 func processEscapeCharacters(original string) string {
 	if original == "" {
 		return original
@@ -0,0 +1,208 @@
+//go:build !yq_nomsgpack
+
+package yqlib
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+// the binary inputs and outputs of the scenarios are in hex
+var msgpackScenarios = []formatScenario{
+	{
+		description:    "Encode MessagePack",
+		subdescription: "Each value uses its smallest encoding, e.g. 1.5 is a 32 bit float.",
+		input:          "name: cat\nage: 3\nweight: 1.5\nnicknames: [kitty]\n",
+		expected:       "84a46e616d65a3636174a361676503a6776569676874ca3fc00000a96e69636b6e616d657391a56b69747479",
+		scenarioType:   "encode",
+	},
+	{
+		description:    "Decode MessagePack",
+		subdescription: "Bin is decoded as base64 encoded binary, and the timestamp extension as timestamps.",
+		input:          "83a464617461c4050102030405a763726561746564d6ff65937d25a57072696365cbc0091eb851eb851f",
+		expected:       "data: !!binary AQIDBAU=\ncreated: 2024-01-02T03:04:05Z\nprice: -3.14\n",
+		scenarioType:   "decode",
+	},
+	{
+		description:    "Decode a stream of MessagePack objects",
+		subdescription: "Each object is a separate document.",
+		input:          "81a1610181a16102",
+		expected:       "a: 1\n---\na: 2\n",
+		scenarioType:   "decode",
+	},
+	{
+		description:  "Integers",
+		skipDoc:      true,
+		input:        "[0, 127, 128, 255, 256, 65536, 4294967296, 18446744073709551615, -1, -32, -33, -128, -129, -32769, -2147483649]",
+		expected:     "9f 00 7f cc80 ccff cd0100 ce00010000 cf0000000100000000 cfffffffffffffffff ff e0 d0df d080 d1ff7f d2ffff7fff d3ffffffff7fffffff",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Scalars",
+		skipDoc:      true,
+		input:        "[1.5, 1.1, .nan, .inf, null, true, false, \"\", a, !!binary aGVsbG8=]",
+		expected:     "9a ca3fc00000 cb3ff199999999999a ca7fc00000 ca7f800000 c0 c3 c2 a0 a161 c40568656c6c6f",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Long strings and collections",
+		skipDoc:      true,
+		input:        "[\"" + strings.Repeat("x", 32) + "\", [" + strings.Repeat("1, ", 15) + "1]]",
+		expected:     "92 d920" + strings.Repeat("78", 32) + " dc0010" + strings.Repeat("01", 16),
+		scenarioType: "encode",
+	},
+	{
+		description:  "Timestamps",
+		skipDoc:      true,
+		input:        "[1970-01-01T00:00:01Z, 1970-01-01T00:00:01.5Z, 1960-01-01T00:00:00Z, 2600-01-01T00:00:00Z]",
+		expected:     "94 d6ff00000001 d7ff7735940000000001 c70cff00000000ffffffffed300880 c70cff0000000000000004a0fe7280",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Decode values",
+		skipDoc:      true,
+		input:        "9a cc80 cfffffffffffffffff e0 d0df d1ff7f d3ffffffff7fffffff ca3dcccccd c0 c3 d9026869",
+		expected:     "- 128\n- 18446744073709551615\n- -32\n- -33\n- -129\n- -2147483649\n- 0.1\n- null\n- true\n- hi\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Decode timestamps",
+		skipDoc:      true,
+		input:        "93 d6ff00000001 d7ff7735940000000001 c70cff00000000ffffffffed300880",
+		expected:     "- 1970-01-01T00:00:01Z\n- 1970-01-01T00:00:01.5Z\n- 1960-01-01T00:00:00Z\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Roundtrip",
+		skipDoc:      true,
+		input:        "a: {b: [1, -2, 3.5, true, null, hello, !!binary aGVsbG8=, 2600-01-01T00:00:00.123456789Z]}\n1: x\n",
+		expected:     "a:\n  b:\n    - 1\n    - -2\n    - 3.5\n    - true\n    - null\n    - hello\n    - !!binary aGVsbG8=\n    - 2600-01-01T00:00:00.123456789Z\n1: x\n",
+		scenarioType: "roundtrip",
+	},
+	{
+		description:   "Integers that are too big",
+		skipDoc:       true,
+		input:         "!!int 18446744073709551616",
+		expectedError: "cannot encode 18446744073709551616 as a msgpack integer (): it does not fit in 64 bits",
+		scenarioType:  "encode-error",
+	},
+	{
+		description:   "Truncated",
+		skipDoc:       true,
+		input:         "9301",
+		expectedError: "bad file 'sample.yml': invalid msgpack: unexpected EOF",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Never used marker",
+		skipDoc:       true,
+		input:         "c1",
+		expectedError: "bad file 'sample.yml': invalid msgpack: unknown marker 0xC1",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Unsupported extension",
+		skipDoc:       true,
+		input:         "d40501",
+		expectedError: "bad file 'sample.yml': invalid msgpack: unsupported extension type 5",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Invalid timestamp",
+		skipDoc:       true,
+		input:         "d7ffffffffff00000000",
+		expectedError: "bad file 'sample.yml': invalid msgpack: invalid timestamp nanoseconds",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Nested too deeply",
+		skipDoc:       true,
+		input:         strings.Repeat("91", 10002),
+		expectedError: "bad file 'sample.yml': invalid msgpack: nested deeper than 10000",
+		scenarioType:  "decode-error",
+	},
+}
+
+func testMsgpackScenario(t *testing.T, s formatScenario) {
+	switch s.scenarioType {
+	case "encode":
+		encoded := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewMsgpackEncoder())
+		test.AssertResultWithContext(t, strings.ReplaceAll(s.expected, " ", ""), hex.EncodeToString([]byte(encoded)), s.description)
+	case "decode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(withHexInput(s), NewMsgpackDecoder(), NewYamlEncoder(ConfiguredYamlPreferences)), s.description)
+	case "roundtrip":
+		encoded := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewMsgpackEncoder())
+		decoded := mustProcessFormatScenario(formatScenario{input: encoded}, NewMsgpackDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		test.AssertResultWithContext(t, s.expected, decoded, s.description)
+	case "decode-error", "encode-error":
+		var result string
+		var err error
+		if s.scenarioType == "decode-error" {
+			result, err = processFormatScenario(withHexInput(s), NewMsgpackDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		} else {
+			result, err = processFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewMsgpackEncoder())
+		}
+		if err == nil {
+			t.Errorf("Expected error '%v' but it worked: %v", s.expectedError, result)
+		} else {
+			test.AssertResultWithContext(t, true, strings.HasPrefix(err.Error(), s.expectedError),
+				fmt.Sprintf("Expected [%v] to start with [%v]", err.Error(), s.expectedError))
+		}
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func documentMsgpackScenario(_ *testing.T, w *bufio.Writer, i interface{}) {
+	s := i.(formatScenario)
+	if s.skipDoc {
+		return
+	}
+	writeOrPanic(w, fmt.Sprintf("## %v\n", s.description))
+
+	if s.subdescription != "" {
+		writeOrPanic(w, s.subdescription)
+		writeOrPanic(w, "\n\n")
+	}
+
+	expression := s.expression
+	if expression == "" {
+		expression = "."
+	}
+
+	switch s.scenarioType {
+	case "encode":
+		writeOrPanic(w, "Given a sample.yml file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=msgpack '%v' sample.yml | xxd -p\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		encoded := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewMsgpackEncoder())
+		writeOrPanic(w, fmt.Sprintf("```\n%v\n```\n\n", hex.EncodeToString([]byte(encoded))))
+	case "decode":
+		writeOrPanic(w, "Given a sample.msgpack file of (in hex):\n")
+		writeOrPanic(w, fmt.Sprintf("```\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=yaml '%v' sample.msgpack\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n\n", mustProcessFormatScenario(withHexInput(s), NewMsgpackDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))))
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func TestMsgpackScenarios(t *testing.T) {
+	for _, tt := range msgpackScenarios {
+		testMsgpackScenario(t, tt)
+	}
+	genericScenarios := make([]interface{}, len(msgpackScenarios))
+	for i, s := range msgpackScenarios {
+		genericScenarios[i] = s
+	}
+	documentScenarios(t, "usage", "msgpack", genericScenarios, documentMsgpackScenario)
+}
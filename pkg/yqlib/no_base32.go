@@ -0,0 +1,11 @@
+//go:build yq_nobase32
+
+package yqlib
+
+func NewBase32Decoder() Decoder {
+	return nil
+}
+
+func NewBase32Encoder() Encoder {
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build yq_nocbor
+
+package yqlib
+
+func NewCBORDecoder() Decoder {
+	return nil
+}
+
+func NewCBOREncoder() Encoder {
+	return nil
+}
@@ -9,3 +9,23 @@ func NewJSONDecoder() Decoder {
 func NewJSONEncoder(prefs JsonPreferences) Encoder {
 	return nil
 }
+
+func NewJSONStreamDecoder() Decoder {
+	return nil
+}
+
+func NewJSON5Decoder() Decoder {
+	return nil
+}
+
+func NewJSON5Encoder(prefs JsonPreferences) Encoder {
+	return nil
+}
+
+func NewJSONLDecoder(prefs JsonlPreferences) Decoder {
+	return nil
+}
+
+func NewJSONLEncoder(prefs JsonPreferences) Encoder {
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build yq_nomsgpack
+
+package yqlib
+
+func NewMsgpackDecoder() Decoder {
+	return nil
+}
+
+func NewMsgpackEncoder() Encoder {
+	return nil
+}
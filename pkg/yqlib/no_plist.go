@@ -0,0 +1,11 @@
+//go:build yq_noplist
+
+package yqlib
+
+func NewPlistDecoder() Decoder {
+	return nil
+}
+
+func NewPlistEncoder(prefs PlistPreferences) Encoder {
+	return nil
+}
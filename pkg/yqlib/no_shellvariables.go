@@ -5,3 +5,7 @@ package yqlib
 func NewShellVariablesEncoder() Encoder {
 	return nil
 }
+
+func NewShellVariablesDecoder() Decoder {
+	return nil
+}
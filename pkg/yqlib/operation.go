@@ -39,9 +39,15 @@ func createValueOperation(value interface{}, stringValue string) *Operation {
 var orOpType = &operationType{Type: "OR", NumArgs: 2, Precedence: 20, Handler: orOperator}
 var andOpType = &operationType{Type: "AND", NumArgs: 2, Precedence: 20, Handler: andOperator}
 var reduceOpType = &operationType{Type: "REDUCE", NumArgs: 2, Precedence: 35, Handler: reduceOperator}
+var foreachOpType = &operationType{Type: "FOREACH", NumArgs: 2, Precedence: 35, Handler: foreachOperator}
 
 var blockOpType = &operationType{Type: "BLOCK", Precedence: 10, NumArgs: 2, Handler: emptyOperator}
 
+// function definitions need to be below block, as the rest of the expression after the definition
+// is the RHS
+var functionDefinitionOpType = &operationType{Type: "DEF", NumArgs: 2, Precedence: 5, Handler: functionDefinitionOperator}
+var importOpType = &operationType{Type: "IMPORT", NumArgs: 1, Precedence: 5, Handler: importOperator}
+
 var unionOpType = &operationType{Type: "UNION", NumArgs: 2, Precedence: 10, Handler: unionOperator}
 
 var pipeOpType = &operationType{Type: "PIPE", NumArgs: 2, Precedence: 30, Handler: pipeOperator}
@@ -53,6 +59,7 @@ var subtractAssignOpType = &operationType{Type: "SUBTRACT_ASSIGN", NumArgs: 2, P
 var assignAttributesOpType = &operationType{Type: "ASSIGN_ATTRIBUTES", NumArgs: 2, Precedence: 40, Handler: assignAttributesOperator}
 var assignStyleOpType = &operationType{Type: "ASSIGN_STYLE", NumArgs: 2, Precedence: 40, Handler: assignStyleOperator}
 var assignVariableOpType = &operationType{Type: "ASSIGN_VARIABLE", NumArgs: 2, Precedence: 40, Handler: useWithPipe}
+var destructureAlternativeOpType = &operationType{Type: "DESTRUCTURE_ALTERNATIVE", NumArgs: 2, Precedence: 41, Handler: useWithPipe}
 var assignTagOpType = &operationType{Type: "ASSIGN_TAG", NumArgs: 2, Precedence: 40, Handler: assignTagOperator}
 var assignCommentOpType = &operationType{Type: "ASSIGN_COMMENT", NumArgs: 2, Precedence: 40, Handler: assignCommentsOperator}
 var assignAnchorOpType = &operationType{Type: "ASSIGN_ANCHOR", NumArgs: 2, Precedence: 40, Handler: assignAnchorOperator}
@@ -73,20 +80,53 @@ var equalsOpType = &operationType{Type: "EQUALS", NumArgs: 2, Precedence: 40, Ha
 var notEqualsOpType = &operationType{Type: "NOT_EQUALS", NumArgs: 2, Precedence: 40, Handler: notEqualsOperator}
 
 var compareOpType = &operationType{Type: "COMPARE", NumArgs: 2, Precedence: 40, Handler: compareOperator}
+var floorOpType = &operationType{Type: "FLOOR", NumArgs: 0, Precedence: 50, Handler: floorOperator, CheckForPostTraverse: true}
+var ceilOpType = &operationType{Type: "CEIL", NumArgs: 0, Precedence: 50, Handler: ceilOperator, CheckForPostTraverse: true}
+var roundOpType = &operationType{Type: "ROUND", NumArgs: 0, Precedence: 50, Handler: roundOperator, CheckForPostTraverse: true}
+var absOpType = &operationType{Type: "ABS", NumArgs: 0, Precedence: 50, Handler: absOperator, CheckForPostTraverse: true}
+var sqrtOpType = &operationType{Type: "SQRT", NumArgs: 0, Precedence: 50, Handler: sqrtOperator, CheckForPostTraverse: true}
+var logOpType = &operationType{Type: "LOG", NumArgs: 0, Precedence: 50, Handler: logOperator, CheckForPostTraverse: true}
+var expOpType = &operationType{Type: "EXP", NumArgs: 0, Precedence: 50, Handler: expOperator, CheckForPostTraverse: true}
+var powOpType = &operationType{Type: "POW", NumArgs: 1, Precedence: 50, Handler: powOperator, CheckForPostTraverse: true}
+var minByOpType = &operationType{Type: "MIN_BY", NumArgs: 1, Precedence: 50, Handler: minByOperator, CheckForPostTraverse: true}
+var maxByOpType = &operationType{Type: "MAX_BY", NumArgs: 1, Precedence: 50, Handler: maxByOperator, CheckForPostTraverse: true}
+var frequenciesOpType = &operationType{Type: "FREQUENCIES", NumArgs: 0, Precedence: 50, Handler: frequenciesOperator, CheckForPostTraverse: true}
+var countByOpType = &operationType{Type: "COUNT_BY", NumArgs: 1, Precedence: 50, Handler: countByOperatorHandler, CheckForPostTraverse: true}
+var histogramOpType = &operationType{Type: "HISTOGRAM", NumArgs: 1, Precedence: 50, Handler: histogramOperator, CheckForPostTraverse: true}
+var medianOpType = &operationType{Type: "MEDIAN", NumArgs: 0, Precedence: 50, Handler: medianOperator, CheckForPostTraverse: true}
+var percentileOpType = &operationType{Type: "PERCENTILE", NumArgs: 1, Precedence: 50, Handler: percentileOperator, CheckForPostTraverse: true}
+var stddevOpType = &operationType{Type: "STDDEV", NumArgs: 0, Precedence: 50, Handler: stddevOperator, CheckForPostTraverse: true}
+var chunkOpType = &operationType{Type: "CHUNK", NumArgs: 1, Precedence: 50, Handler: chunkOperator, CheckForPostTraverse: true}
+var windowOpType = &operationType{Type: "WINDOW", NumArgs: 1, Precedence: 50, Handler: windowOperator, CheckForPostTraverse: true}
+var zipOpType = &operationType{Type: "ZIP", NumArgs: 0, Precedence: 50, Handler: zipOperator, CheckForPostTraverse: true}
+var transposeOpType = &operationType{Type: "TRANSPOSE", NumArgs: 0, Precedence: 50, Handler: transposeOperator, CheckForPostTraverse: true}
+var combinationsOpType = &operationType{Type: "COMBINATIONS", NumArgs: 1, Precedence: 50, Handler: combinationsOperator, CheckForPostTraverse: true}
+var combinationsOfArraysOpType = &operationType{Type: "COMBINATIONS_OF_ARRAYS", NumArgs: 0, Precedence: 50, Handler: combinationsOperator, CheckForPostTraverse: true}
+var enumerateOpType = &operationType{Type: "ENUMERATE", NumArgs: 0, Precedence: 50, Handler: enumerateOperator, CheckForPostTraverse: true}
+
 var minOpType = &operationType{Type: "MIN", NumArgs: 0, Precedence: 40, Handler: minOperator}
 var maxOpType = &operationType{Type: "MAX", NumArgs: 0, Precedence: 40, Handler: maxOperator}
 
 // createmap needs to be above union, as we use union to build the components of the objects
 var createMapOpType = &operationType{Type: "CREATE_MAP", NumArgs: 2, Precedence: 15, Handler: createMapOperator}
 
+// try binds tighter than arithmetic and pipes, but looser than traversals, so that
+// `try .a.b` and `.a.b?` cover the whole path.
+var tryOpType = &operationType{Type: "TRY", NumArgs: 1, Precedence: 44, Handler: tryOperator}
+var catchOpType = &operationType{Type: "CATCH", NumArgs: 2, Precedence: 43, Handler: catchOperator}
+
 var shortPipeOpType = &operationType{Type: "SHORT_PIPE", NumArgs: 2, Precedence: 45, Handler: pipeOperator}
 
 var lengthOpType = &operationType{Type: "LENGTH", NumArgs: 0, Precedence: 50, Handler: lengthOperator}
 var lineOpType = &operationType{Type: "LINE", NumArgs: 0, Precedence: 50, Handler: lineOperator}
 var columnOpType = &operationType{Type: "LINE", NumArgs: 0, Precedence: 50, Handler: columnOperator}
 
-// Use this expression to create alias/syntactic sugar expressions (in lexer_participle).
-var expressionOpType = &operationType{Type: "EXP", NumArgs: 0, Precedence: 50, Handler: expressionOperator}
+var callFunctionOpType = &operationType{Type: "CALL", NumArgs: 0, Precedence: 50, Handler: callFunctionOperator, CheckForPostTraverse: true, ToString: callFunctionToString}
+var callFunctionWithArgsOpType = &operationType{Type: "CALL_WITH_ARGS", NumArgs: 1, Precedence: 52, Handler: callFunctionOperator, CheckForPostTraverse: true, ToString: callFunctionToString}
+
+var callFunctionToString = func(p *Operation) string {
+	return fmt.Sprintf("%v (%v)", p.OperationType.Type, p.StringValue)
+}
 
 var collectOpType = &operationType{Type: "COLLECT", NumArgs: 1, Precedence: 50, Handler: collectOperator}
 var mapOpType = &operationType{Type: "MAP", NumArgs: 1, Precedence: 52, Handler: mapOperator, CheckForPostTraverse: true}
@@ -100,6 +140,13 @@ var mapValuesOpType = &operationType{Type: "MAP_VALUES", NumArgs: 1, Precedence:
 var formatDateTimeOpType = &operationType{Type: "FORMAT_DATE_TIME", NumArgs: 1, Precedence: 50, Handler: formatDateTime}
 var withDtFormatOpType = &operationType{Type: "WITH_DATE_TIME_FORMAT", NumArgs: 1, Precedence: 50, Handler: withDateTimeFormat}
 var nowOpType = &operationType{Type: "NOW", NumArgs: 0, Precedence: 50, Handler: nowOp}
+var dateDiffOpType = &operationType{Type: "DATE_DIFF", NumArgs: 1, Precedence: 50, Handler: dateDiffOp}
+var strftimeOpType = &operationType{Type: "STRFTIME", NumArgs: 1, Precedence: 50, Handler: strftimeOp}
+var strptimeOpType = &operationType{Type: "STRPTIME", NumArgs: 1, Precedence: 50, Handler: strptimeOp}
+var semverParseOpType = &operationType{Type: "SEMVER_PARSE", NumArgs: 0, Precedence: 50, Handler: semverParseOperator}
+var semverSatisfiesOpType = &operationType{Type: "SEMVER_SATISFIES", NumArgs: 1, Precedence: 50, Handler: semverSatisfiesOperator}
+var semverBumpOpType = &operationType{Type: "SEMVER_BUMP", NumArgs: 1, Precedence: 50, Handler: semverBumpOperator}
+var withSemverOpType = &operationType{Type: "WITH_SEMVER", NumArgs: 1, Precedence: 50, Handler: withSemverOperator}
 var tzOpType = &operationType{Type: "TIMEZONE", NumArgs: 1, Precedence: 50, Handler: tzOp}
 var fromUnixOpType = &operationType{Type: "FROM_UNIX", NumArgs: 0, Precedence: 50, Handler: fromUnixOp}
 var toUnixOpType = &operationType{Type: "TO_UNIX", NumArgs: 0, Precedence: 50, Handler: toUnixOp}
@@ -113,6 +160,8 @@ var containsOpType = &operationType{Type: "CONTAINS", NumArgs: 1, Precedence: 50
 var anyConditionOpType = &operationType{Type: "ANY_CONDITION", NumArgs: 1, Precedence: 50, Handler: anyOperator}
 var allConditionOpType = &operationType{Type: "ALL_CONDITION", NumArgs: 1, Precedence: 50, Handler: allOperator}
 
+var toStreamOpType = &operationType{Type: "TO_STREAM", NumArgs: 0, Precedence: 52, Handler: toStreamOperator, CheckForPostTraverse: true}
+var fromStreamOpType = &operationType{Type: "FROM_STREAM", NumArgs: 1, Precedence: 52, Handler: fromStreamOperator}
 var toEntriesOpType = &operationType{Type: "TO_ENTRIES", NumArgs: 0, Precedence: 52, Handler: toEntriesOperator, CheckForPostTraverse: true}
 var fromEntriesOpType = &operationType{Type: "FROM_ENTRIES", NumArgs: 0, Precedence: 50, Handler: fromEntriesOperator}
 var withEntriesOpType = &operationType{Type: "WITH_ENTRIES", NumArgs: 1, Precedence: 50, Handler: withEntriesOperator}
@@ -138,12 +187,21 @@ var getFilenameOpType = &operationType{Type: "GET_FILENAME", NumArgs: 0, Precede
 var getFileIndexOpType = &operationType{Type: "GET_FILE_INDEX", NumArgs: 0, Precedence: 50, Handler: getFileIndexOperator}
 
 var getPathOpType = &operationType{Type: "GET_PATH", NumArgs: 0, Precedence: 52, Handler: getPathOperator, CheckForPostTraverse: true}
+var getValueAtPathOpType = &operationType{Type: "GET_VALUE_AT_PATH", NumArgs: 1, Precedence: 52, Handler: getValueAtPathOperator, CheckForPostTraverse: true}
 var setPathOpType = &operationType{Type: "SET_PATH", NumArgs: 1, Precedence: 50, Handler: setPathOperator}
 var delPathsOpType = &operationType{Type: "DEL_PATHS", NumArgs: 1, Precedence: 52, Handler: delPathsOperator, CheckForPostTraverse: true}
+var fromPathsOpType = &operationType{Type: "FROM_PATHS", NumArgs: 0, Precedence: 50, Handler: fromPathsOperator}
 
 var explodeOpType = &operationType{Type: "EXPLODE", NumArgs: 1, Precedence: 52, Handler: explodeOperator, CheckForPostTraverse: true}
 var sortByOpType = &operationType{Type: "SORT_BY", NumArgs: 1, Precedence: 52, Handler: sortByOperator, CheckForPostTraverse: true}
 var firstOpType = &operationType{Type: "FIRST", NumArgs: 1, Precedence: 52, Handler: firstOperator, CheckForPostTraverse: true}
+var firstOfOpType = &operationType{Type: "FIRST_OF", NumArgs: 1, Precedence: 52, Handler: firstOfOperator, CheckForPostTraverse: true}
+var rangeOpType = &operationType{Type: "RANGE", NumArgs: 1, Precedence: 52, Handler: rangeOperator}
+var limitOpType = &operationType{Type: "LIMIT", NumArgs: 1, Precedence: 52, Handler: limitOperator, CheckForPostTraverse: true}
+var untilOpType = &operationType{Type: "UNTIL", NumArgs: 1, Precedence: 52, Handler: untilOperator, CheckForPostTraverse: true}
+var whileOpType = &operationType{Type: "WHILE", NumArgs: 1, Precedence: 52, Handler: whileOperator, CheckForPostTraverse: true}
+var recurseOpType = &operationType{Type: "RECURSE", NumArgs: 1, Precedence: 52, Handler: recurseOperator, CheckForPostTraverse: true}
+var repeatOpType = &operationType{Type: "REPEAT", NumArgs: 1, Precedence: 52, Handler: recurseOperator, CheckForPostTraverse: true}
 var reverseOpType = &operationType{Type: "REVERSE", NumArgs: 0, Precedence: 52, Handler: reverseOperator, CheckForPostTraverse: true}
 var sortOpType = &operationType{Type: "SORT", NumArgs: 0, Precedence: 52, Handler: sortOperator, CheckForPostTraverse: true}
 var shuffleOpType = &operationType{Type: "SHUFFLE", NumArgs: 0, Precedence: 52, Handler: shuffleOperator, CheckForPostTraverse: true}
@@ -152,10 +210,24 @@ var sortKeysOpType = &operationType{Type: "SORT_KEYS", NumArgs: 1, Precedence: 5
 
 var joinStringOpType = &operationType{Type: "JOIN", NumArgs: 1, Precedence: 50, Handler: joinStringOperator}
 var subStringOpType = &operationType{Type: "SUBSTR", NumArgs: 1, Precedence: 50, Handler: substituteStringOperator}
+var formatOpType = &operationType{Type: "FORMAT", NumArgs: 1, Precedence: 50, Handler: formatOperator}
+var templateOpType = &operationType{Type: "TEMPLATE", NumArgs: 1, Precedence: 50, Handler: templateOperator}
+var globalSubStringOpType = &operationType{Type: "GSUBSTR", NumArgs: 1, Precedence: 50, Handler: globalSubstituteStringOperator}
 var matchOpType = &operationType{Type: "MATCH", NumArgs: 1, Precedence: 50, Handler: matchOperator}
 var captureOpType = &operationType{Type: "CAPTURE", NumArgs: 1, Precedence: 50, Handler: captureOperator}
 var testOpType = &operationType{Type: "TEST", NumArgs: 1, Precedence: 50, Handler: testOperator}
 var splitStringOpType = &operationType{Type: "SPLIT", NumArgs: 1, Precedence: 52, Handler: splitStringOperator, CheckForPostTraverse: true}
+var leftTrimStringOpType = &operationType{Type: "LTRIMSTR", NumArgs: 1, Precedence: 50, Handler: leftTrimStringOperator}
+var rightTrimStringOpType = &operationType{Type: "RTRIMSTR", NumArgs: 1, Precedence: 50, Handler: rightTrimStringOperator}
+var startsWithOpType = &operationType{Type: "STARTS_WITH", NumArgs: 1, Precedence: 50, Handler: startsWithOperator}
+var endsWithOpType = &operationType{Type: "ENDS_WITH", NumArgs: 1, Precedence: 50, Handler: endsWithOperator}
+var splitsOpType = &operationType{Type: "SPLITS", NumArgs: 1, Precedence: 50, Handler: splitsOperator}
+var implodeOpType = &operationType{Type: "IMPLODE", NumArgs: 0, Precedence: 50, Handler: implodeOperator}
+var explodeCodepointsOpType = &operationType{Type: "EXPLODE_CODEPOINTS", NumArgs: 0, Precedence: 50, Handler: explodeCodepointsOperator}
+var asciiOpType = &operationType{Type: "ASCII", NumArgs: 0, Precedence: 50, Handler: asciiOperator}
+var indicesOpType = &operationType{Type: "INDICES", NumArgs: 1, Precedence: 50, Handler: indicesOperator}
+var indexOpType = &operationType{Type: "INDEX", NumArgs: 1, Precedence: 50, Handler: indexOperator}
+var rindexOpType = &operationType{Type: "RINDEX", NumArgs: 1, Precedence: 50, Handler: rindexOperator}
 var changeCaseOpType = &operationType{Type: "CHANGE_CASE", NumArgs: 0, Precedence: 50, Handler: changeCaseOperator}
 var trimOpType = &operationType{Type: "TRIM", NumArgs: 0, Precedence: 50, Handler: trimSpaceOperator}
 var toStringOpType = &operationType{Type: "TO_STRING", NumArgs: 0, Precedence: 50, Handler: toStringOperator}
@@ -193,6 +265,13 @@ var selectOpType = &operationType{Type: "SELECT", NumArgs: 1, Precedence: 52, Ha
 var hasOpType = &operationType{Type: "HAS", NumArgs: 1, Precedence: 50, Handler: hasOperator}
 var uniqueOpType = &operationType{Type: "UNIQUE", NumArgs: 0, Precedence: 52, Handler: unique, CheckForPostTraverse: true}
 var uniqueByOpType = &operationType{Type: "UNIQUE_BY", NumArgs: 1, Precedence: 52, Handler: uniqueBy, CheckForPostTraverse: true}
+var intersectionOpType = &operationType{Type: "INTERSECTION", NumArgs: 1, Precedence: 50, Handler: intersectionOperator}
+var differenceOpType = &operationType{Type: "DIFFERENCE", NumArgs: 1, Precedence: 50, Handler: differenceOperator}
+var unionByOpType = &operationType{Type: "UNION_BY", NumArgs: 1, Precedence: 50, Handler: unionByOperator}
+var joinByOpType = &operationType{Type: "JOIN_BY", NumArgs: 1, Precedence: 50, Handler: joinByOperator}
+var mergeOpType = &operationType{Type: "MERGE", NumArgs: 1, Precedence: 50, Handler: mergeOperator}
+var sqlInOpType = &operationType{Type: "SQL_IN", NumArgs: 1, Precedence: 50, Handler: inOperator}
+var sqlIndexOpType = &operationType{Type: "SQL_INDEX", NumArgs: 1, Precedence: 50, Handler: sqlIndexOperator}
 var groupByOpType = &operationType{Type: "GROUP_BY", NumArgs: 1, Precedence: 52, Handler: groupBy, CheckForPostTraverse: true}
 var flattenOpType = &operationType{Type: "FLATTEN_BY", NumArgs: 0, Precedence: 52, Handler: flattenOp, CheckForPostTraverse: true}
 var deleteChildOpType = &operationType{Type: "DELETE", NumArgs: 1, Precedence: 40, Handler: deleteChildOperator}
@@ -100,7 +100,7 @@ func addScalars(context Context, target *CandidateNode, lhs *CandidateNode, rhs
 	}
 
 	if isDateTime {
-		return addDateTimes(context.GetDateTimeLayout(), target, lhs, rhs)
+		return addDateTimes(context, target, lhs, rhs)
 
 	} else if lhsTag == "!!str" {
 		target.Tag = lhs.Tag
@@ -147,9 +147,10 @@ func addScalars(context Context, target *CandidateNode, lhs *CandidateNode, rhs
 	return nil
 }
 
-func addDateTimes(layout string, target *CandidateNode, lhs *CandidateNode, rhs *CandidateNode) error {
+func addDateTimes(context Context, target *CandidateNode, lhs *CandidateNode, rhs *CandidateNode) error {
+	layout := context.GetDateTimeLayout()
 
-	duration, err := time.ParseDuration(rhs.Value)
+	duration, err := parseCalendarDuration(rhs.Value)
 	if err != nil {
 		return fmt.Errorf("unable to parse duration [%v]: %w", rhs.Value, err)
 	}
@@ -159,7 +160,7 @@ func addDateTimes(layout string, target *CandidateNode, lhs *CandidateNode, rhs
 		return err
 	}
 
-	newTime := currentTime.Add(duration)
+	newTime := duration.addTo(inDateTimeLocation(currentTime, context.GetDateTimeLocation()))
 	target.Value = newTime.Format(layout)
 	return nil
 
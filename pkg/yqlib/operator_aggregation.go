@@ -0,0 +1,235 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/elliotchance/orderedmap"
+)
+
+type groupCount struct {
+	key   *CandidateNode
+	count int
+}
+
+// countIntoGroups counts the entries of the array by their key, in the order the keys are first seen.
+func countIntoGroups(funcName string, d *dataTreeNavigator, context Context, keyExp *ExpressionNode, node *CandidateNode) (*orderedmap.OrderedMap, error) {
+	var groups = orderedmap.NewOrderedMap()
+	for _, child := range node.Content {
+		key, err := getKey(d, context, child, keyExp)
+		if err != nil {
+			return nil, err
+		}
+		if key.Kind != ScalarNode {
+			return nil, fmt.Errorf("%v keys must be scalars, but got %v (%v)", funcName, key.Tag, child.GetNicePath())
+		}
+
+		group, exists := groups.Get(key.Value)
+		if !exists {
+			group = &groupCount{key: key}
+			groups.Set(key.Value, group)
+		}
+		group.(*groupCount).count++
+	}
+	return groups, nil
+}
+
+func countByOperator(funcName string) operatorHandler {
+	return func(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		keyExp := expressionNode.RHS
+		if keyExp == nil {
+			keyExp = &ExpressionNode{Operation: &Operation{OperationType: selfReferenceOpType}}
+		}
+
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			candidate := el.Value.(*CandidateNode)
+			if candidate.Kind != SequenceNode {
+				return Context{}, fmt.Errorf("%v only works on arrays, but got %v", funcName, candidate.Tag)
+			}
+			groups, err := countIntoGroups(funcName, d, context, keyExp, candidate)
+			if err != nil {
+				return Context{}, err
+			}
+
+			resultNode := candidate.CreateReplacement(MappingNode, "!!map", "")
+			for groupEl := groups.Front(); groupEl != nil; groupEl = groupEl.Next() {
+				group := groupEl.Value.(*groupCount)
+				key := group.key.CopyWithoutContent()
+				key.HeadComment, key.LineComment, key.FootComment = "", "", ""
+				resultNode.AddKeyValueChild(key, createScalarNode(group.count, fmt.Sprintf("%v", group.count)))
+			}
+			results.PushBack(resultNode)
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+// frequencies counts each distinct value, count_by(f) counts the values of f.
+var frequenciesOperator = countByOperator("frequencies")
+var countByOperatorHandler = countByOperator("count_by")
+
+type statNumber struct {
+	node   *CandidateNode
+	number mathNumber
+}
+
+// getSortedNumbers returns the numbers of the array, sorted ascending.
+func getSortedNumbers(funcName string, candidate *CandidateNode) ([]statNumber, error) {
+	if candidate.Kind != SequenceNode {
+		return nil, fmt.Errorf("%v only works on arrays, but got %v", funcName, candidate.Tag)
+	}
+	numbers := make([]statNumber, 0, len(candidate.Content))
+	for _, child := range candidate.Content {
+		number, err := parseMathNumber(funcName, child)
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, statNumber{node: child, number: number})
+	}
+	sort.SliceStable(numbers, func(i, j int) bool {
+		if numbers[i].number.isInt && numbers[j].number.isInt {
+			return numbers[i].number.intValue < numbers[j].number.intValue
+		}
+		return numbers[i].number.floatValue < numbers[j].number.floatValue
+	})
+	return numbers, nil
+}
+
+// interpolatePercentile returns the value at the (fractional) position of the sorted numbers,
+// keeping ints as ints when the position lands on an entry, or between two ints with a whole result.
+func interpolatePercentile(owner *CandidateNode, numbers []statNumber, position float64) *CandidateNode {
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	if lower == upper {
+		return numbers[lower].node.Copy()
+	}
+	low, high := numbers[lower], numbers[upper]
+	fraction := position - float64(lower)
+	if low.number.isInt && high.number.isInt {
+		difference := high.number.intValue - low.number.intValue
+		if scaled := float64(difference) * fraction; scaled == math.Trunc(scaled) && difference >= 0 {
+			return owner.CreateReplacement(ScalarNode, "!!int", fmt.Sprintf(low.number.intFormat, low.number.intValue+int64(scaled)))
+		}
+	}
+	return owner.CreateReplacement(ScalarNode, "!!float", formatFloatValue(low.number.floatValue+(high.number.floatValue-low.number.floatValue)*fraction))
+}
+
+func percentileOf(funcName string, candidate *CandidateNode, percent float64) (*CandidateNode, error) {
+	numbers, err := getSortedNumbers(funcName, candidate)
+	if err != nil {
+		return nil, err
+	} else if len(numbers) == 0 {
+		return candidate.CreateReplacement(ScalarNode, "!!null", "null"), nil
+	}
+	return interpolatePercentile(candidate, numbers, percent/100*float64(len(numbers)-1)), nil
+}
+
+func medianOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("median")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		result, err := percentileOf("median", el.Value.(*CandidateNode), 50)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
+
+// percentile(p) linearly interpolates between the closest entries, with p from 0 to 100.
+func percentileOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("percentile")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		_, percent, err := getMathParameter("percentile", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		} else if percent.floatValue < 0 || percent.floatValue > 100 {
+			return Context{}, fmt.Errorf("percentile must be between 0 and 100, but got %v", formatFloatValue(percent.floatValue))
+		}
+		result, err := percentileOf("percentile", candidate, percent.floatValue)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
+
+// stddev is the population standard deviation, which is always a float.
+func stddevOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("stddev")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		numbers, err := getSortedNumbers("stddev", candidate)
+		if err != nil {
+			return Context{}, err
+		} else if len(numbers) == 0 {
+			results.PushBack(candidate.CreateReplacement(ScalarNode, "!!null", "null"))
+			continue
+		}
+
+		mean := 0.0
+		for _, n := range numbers {
+			mean += n.number.floatValue
+		}
+		mean = mean / float64(len(numbers))
+		variance := 0.0
+		for _, n := range numbers {
+			variance += (n.number.floatValue - mean) * (n.number.floatValue - mean)
+		}
+		stddev := math.Sqrt(variance / float64(len(numbers)))
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!float", formatFloatValue(stddev)))
+	}
+	return context.ChildContext(results), nil
+}
+
+// histogram(width) counts the numbers into buckets of the given width, keyed by the
+// start of each bucket in ascending order.
+func histogramOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("histogram")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		widthNode, width, err := getMathParameter("histogram", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		} else if width.floatValue <= 0 || math.IsInf(width.floatValue, 0) || math.IsNaN(width.floatValue) {
+			return Context{}, fmt.Errorf("histogram bucket width must be a positive number, but got %v", widthNode.Value)
+		}
+		numbers, err := getSortedNumbers("histogram", candidate)
+		if err != nil {
+			return Context{}, err
+		}
+
+		resultNode := candidate.CreateReplacement(MappingNode, "!!map", "")
+		var bucketCount *CandidateNode
+		var bucketStart float64
+		count := 0
+		for _, n := range numbers {
+			start := math.Floor(n.number.floatValue/width.floatValue) * width.floatValue
+			if bucketCount == nil || start != bucketStart {
+				var key *CandidateNode
+				if width.isInt {
+					key = createScalarNode(int64(start), fmt.Sprintf("%v", int64(start)))
+				} else {
+					key = createScalarNode(start, formatFloatValue(start))
+				}
+				count = 0
+				bucketStart = start
+				_, bucketCount = resultNode.AddKeyValueChild(key, createScalarNode(0, "0"))
+			}
+			count++
+			bucketCount.Value = fmt.Sprintf("%v", count)
+		}
+		results.PushBack(resultNode)
+	}
+	return context.ChildContext(results), nil
+}
@@ -0,0 +1,167 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var aggregationOperatorScenarios = []expressionScenario{
+	{
+		description:    "Frequencies",
+		subdescription: "Counts each distinct value of the array.",
+		document:       `[cat, dog, cat, fish, cat]`,
+		expression:     `frequencies`,
+		expected: []string{
+			"D0, P[], (!!map)::cat: 3\ndog: 1\nfish: 1\n",
+		},
+	},
+	{
+		description:    "Count by",
+		subdescription: "Counts the entries of the array by the result of the expression.",
+		document:       `[{name: sam, team: red}, {name: bob, team: blue}, {name: pat, team: red}]`,
+		expression:     `count_by(.team)`,
+		expected: []string{
+			"D0, P[], (!!map)::red: 2\nblue: 1\n",
+		},
+	},
+	{
+		description:    "Summarise CSV rows",
+		subdescription: "Given a CSV file with `name`, `team` and `cost` columns, `yq -p=csv 'count_by(.team)' costs.csv` counts the rows for each team. Combine with `group_by` for other summaries:",
+		document:       `[{name: sam, team: red, cost: 10}, {name: bob, team: blue, cost: 5}, {name: pat, team: red, cost: 7}]`,
+		expression:     `group_by(.team) | map({"team": .[0].team, "count": length, "cost": (map(.cost) | sum)})`,
+		expected: []string{
+			"D0, P[], (!!seq)::- team: red\n  count: 2\n  cost: 17\n- team: blue\n  count: 1\n  cost: 5\n",
+		},
+	},
+	{
+		description: "Count by missing key",
+		skipDoc:     true,
+		document:    `[{a: 1}, {b: 2}, {a: 1}]`,
+		expression:  `count_by(.a)`,
+		expected: []string{
+			"D0, P[], (!!map)::1: 2\nnull: 1\n",
+		},
+	},
+	{
+		description:   "Count by requires scalar keys",
+		skipDoc:       true,
+		document:      `[{a: [1]}]`,
+		expression:    `count_by(.a)`,
+		expectedError: "count_by keys must be scalars, but got !!seq ([0])",
+	},
+	{
+		description:   "Frequencies of a map",
+		skipDoc:       true,
+		document:      `{a: 1}`,
+		expression:    `frequencies`,
+		expectedError: "frequencies only works on arrays, but got !!map",
+	},
+	{
+		description:    "Histogram",
+		subdescription: "Counts the numbers into buckets of the given width, keyed by the start of each bucket.",
+		document:       `[1, 12, 3, 25, 18, 11]`,
+		expression:     `histogram(10)`,
+		expected: []string{
+			"D0, P[], (!!map)::0: 2\n10: 3\n20: 1\n",
+		},
+	},
+	{
+		description: "Histogram with float buckets",
+		skipDoc:     true,
+		document:    `[0.1, 0.7, 0.4, -0.2]`,
+		expression:  `histogram(0.5)`,
+		expected: []string{
+			"D0, P[], (!!map)::-0.5: 1\n0: 2\n0.5: 1\n",
+		},
+	},
+	{
+		description:   "Histogram requires a positive width",
+		skipDoc:       true,
+		document:      `[1]`,
+		expression:    `histogram(0)`,
+		expectedError: "histogram bucket width must be a positive number, but got 0",
+	},
+	{
+		description:    "Median",
+		subdescription: "The middle number, or the mean of the two middle numbers. Returns null for an empty array.",
+		document:       `[5, 1, 3, 8]`,
+		expression:     `median`,
+		expected: []string{
+			"D0, P[], (!!int)::4\n",
+		},
+	},
+	{
+		description: "Median of odd length",
+		skipDoc:     true,
+		document:    `[5, 1.5, 3]`,
+		expression:  `median`,
+		expected: []string{
+			"D0, P[2], (!!int)::3\n",
+		},
+	},
+	{
+		description: "Median that is not a whole number",
+		skipDoc:     true,
+		document:    `[1, 2]`,
+		expression:  `median`,
+		expected: []string{
+			"D0, P[], (!!float)::1.5\n",
+		},
+	},
+	{
+		description: "Median of an empty array",
+		skipDoc:     true,
+		document:    `[]`,
+		expression:  `median`,
+		expected: []string{
+			"D0, P[], (!!null)::null\n",
+		},
+	},
+	{
+		description:   "Median of strings",
+		skipDoc:       true,
+		document:      `[a]`,
+		expression:    `median`,
+		expectedError: "median: expected a number but got !!str ([0])",
+	},
+	{
+		description:    "Percentile",
+		subdescription: "Interpolates linearly between the closest numbers, for a percentile from 0 to 100.",
+		document:       `[10, 20, 30, 40, 50]`,
+		expression:     `[percentile(90), percentile(25), percentile(0)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 46\n- 20\n- 10\n",
+		},
+	},
+	{
+		description:   "Percentile out of range",
+		skipDoc:       true,
+		document:      `[1]`,
+		expression:    `percentile(101)`,
+		expectedError: "percentile must be between 0 and 100, but got 101",
+	},
+	{
+		description:    "Standard deviation",
+		subdescription: "The population standard deviation, which is always a float.",
+		document:       `[2, 4, 4, 4, 5, 5, 7, 9]`,
+		expression:     `stddev`,
+		expected: []string{
+			"D0, P[], (!!float)::2\n",
+		},
+	},
+	{
+		description: "Standard deviation of an empty array",
+		skipDoc:     true,
+		document:    `[]`,
+		expression:  `stddev`,
+		expected: []string{
+			"D0, P[], (!!null)::null\n",
+		},
+	},
+}
+
+func TestAggregationOperatorScenarios(t *testing.T) {
+	for _, tt := range aggregationOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "aggregation", aggregationOperatorScenarios)
+}
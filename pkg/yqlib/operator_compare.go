@@ -73,6 +73,12 @@ func compareScalars(context Context, prefs compareTypePref, lhs *CandidateNode,
 	lhsTag := lhs.guessTagFromCustomType()
 	rhsTag := rhs.guessTagFromCustomType()
 
+	if context.IsSemverCompare() {
+		if result, ok := compareSemverNodes(lhs, rhs); ok {
+			return (prefs.OrEqual && result == 0) || (prefs.Greater && result > 0) || (!prefs.Greater && result < 0), nil
+		}
+	}
+
 	isDateTime := lhs.Tag == "!!timestamp"
 	// if the lhs is a string, it might be a timestamp in a custom format.
 	if lhsTag == "!!str" {
@@ -4,7 +4,9 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,6 +28,12 @@ func withDateTimeFormat(d *dataTreeNavigator, context Context, expressionNode *E
 		if err != nil {
 			return Context{}, fmt.Errorf("could not get date time format: %w", err)
 		}
+		if strings.Contains(layout, "%") {
+			layout, err = strftimeToLayout(layout)
+			if err != nil {
+				return Context{}, fmt.Errorf("could not get date time format: %w", err)
+			}
+		}
 		context.SetDateTimeLayout(layout)
 		return d.GetMatchingNodes(context, expressionNode.RHS.RHS)
 
@@ -76,7 +84,12 @@ func formatDateTime(d *dataTreeNavigator, context Context, expressionNode *Expre
 		if err != nil {
 			return Context{}, fmt.Errorf("could not parse datetime of [%v]: %w", candidate.GetNicePath(), err)
 		}
-		formattedTimeStr := parsedTime.Format(format)
+		var formattedTimeStr string
+		if strings.Contains(format, "%") {
+			formattedTimeStr = strftime(parsedTime, format)
+		} else {
+			formattedTimeStr = parsedTime.Format(format)
+		}
 
 		node, errorReading := parseSnippet(formattedTimeStr)
 		if errorReading != nil {
@@ -95,20 +108,36 @@ func formatDateTime(d *dataTreeNavigator, context Context, expressionNode *Expre
 	return context.ChildContext(results), nil
 }
 
+func getTimezone(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (*time.Location, error) {
+	timezoneStr, err := getStringParameter("timezone", d, context, expressionNode)
+	if err != nil {
+		return nil, err
+	}
+	timezone, err := time.LoadLocation(timezoneStr)
+	if err != nil {
+		return nil, fmt.Errorf("could not load tz [%v]: %w", timezoneStr, err)
+	}
+	return timezone, nil
+}
+
 func tzOp(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
-	timezoneStr, err := getStringParameter("timezone", d, context, expressionNode.RHS)
-	layout := context.GetDateTimeLayout()
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		// tz(timezone; exp) does calendar math in exp in the timezone, respecting daylight savings
+		timezone, err := getTimezone(d, context, expressionNode.RHS.LHS)
+		if err != nil {
+			return Context{}, err
+		}
+		context.SetDateTimeLocation(timezone)
+		return d.GetMatchingNodes(context, expressionNode.RHS.RHS)
+	}
 
+	layout := context.GetDateTimeLayout()
+	timezone, err := getTimezone(d, context, expressionNode.RHS)
 	if err != nil {
 		return Context{}, err
 	}
 	var results = list.New()
 
-	timezone, err := time.LoadLocation(timezoneStr)
-	if err != nil {
-		return Context{}, fmt.Errorf("could not load tz [%v]: %w", timezoneStr, err)
-	}
-
 	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
 		candidate := el.Value.(*CandidateNode)
 
@@ -179,3 +208,475 @@ func toUnixOp(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context
 
 	return context.ChildContext(results), nil
 }
+
+// calendarDuration is a duration that may include calendar units (years, months, weeks and days).
+// These are added to the date, rather than as a fixed number of hours, so they respect
+// month lengths and daylight savings.
+type calendarDuration struct {
+	years    int
+	months   int
+	days     int
+	duration time.Duration
+}
+
+var calendarDurationRegex = regexp.MustCompile(`^([0-9]*\.?[0-9]+)(y|mo|w|d|h|ms|m|s|us|µs|ns)`)
+
+// parseCalendarDuration parses durations like "1y2mo", "-3d12h" or "1h30m".
+func parseCalendarDuration(value string) (calendarDuration, error) {
+	result := calendarDuration{}
+	remaining := value
+	negative := strings.HasPrefix(remaining, "-")
+	remaining = strings.TrimLeft(remaining, "+-")
+	if remaining == "0" {
+		return result, nil
+	} else if remaining == "" {
+		return result, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var goDuration strings.Builder
+	for remaining != "" {
+		match := calendarDurationRegex.FindStringSubmatch(remaining)
+		if match == nil {
+			return result, fmt.Errorf("invalid duration %q", value)
+		}
+		amount, unit := match[1], match[2]
+		switch unit {
+		case "y", "mo", "w", "d":
+			number, err := strconv.Atoi(amount)
+			if err != nil {
+				return result, fmt.Errorf("invalid duration %q, %v must be a whole number", value, amount+unit)
+			}
+			switch unit {
+			case "y":
+				result.years += number
+			case "mo":
+				result.months += number
+			case "w":
+				result.days += number * 7
+			case "d":
+				result.days += number
+			}
+		default:
+			goDuration.WriteString(match[0])
+		}
+		remaining = remaining[len(match[0]):]
+	}
+
+	if goDuration.Len() > 0 {
+		duration, err := time.ParseDuration(goDuration.String())
+		if err != nil {
+			return result, err
+		}
+		result.duration = duration
+	}
+
+	if negative {
+		result.years, result.months, result.days, result.duration = -result.years, -result.months, -result.days, -result.duration
+	}
+	return result, nil
+}
+
+func daysInMonth(year int, month time.Month, location *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, location).Day()
+}
+
+// addTo adds the duration to the time. Adding months clamps to the end of the month,
+// so 31st of January plus a month is the 28th (or 29th) of February.
+func (c calendarDuration) addTo(t time.Time) time.Time {
+	if c.years != 0 || c.months != 0 {
+		year, month, day := t.Date()
+		firstOfMonth := time.Date(year+c.years, month+time.Month(c.months), 1, 0, 0, 0, 0, t.Location())
+		day = min(day, daysInMonth(firstOfMonth.Year(), firstOfMonth.Month(), t.Location()))
+		hour, minute, second := t.Clock()
+		t = time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, hour, minute, second, t.Nanosecond(), t.Location())
+	}
+	return t.AddDate(0, 0, c.days).Add(c.duration)
+}
+
+func (c calendarDuration) negate() calendarDuration {
+	return calendarDuration{years: -c.years, months: -c.months, days: -c.days, duration: -c.duration}
+}
+
+// inDateTimeLocation moves the time into the timezone given by tz(timezone; exp), if any.
+func inDateTimeLocation(t time.Time, location *time.Location) time.Time {
+	if location == nil {
+		return t
+	}
+	return t.In(location)
+}
+
+// wholeCalendarUnits counts the whole number of units between start and end, truncated towards zero,
+// starting from an estimate.
+func wholeCalendarUnits(start time.Time, end time.Time, estimate int, add func(time.Time, int) time.Time) int {
+	count := estimate
+	if !end.Before(start) {
+		for !add(start, count+1).After(end) {
+			count++
+		}
+		for count > 0 && add(start, count).After(end) {
+			count--
+		}
+	} else {
+		for !add(start, count-1).Before(end) {
+			count--
+		}
+		for count < 0 && add(start, count).Before(end) {
+			count++
+		}
+	}
+	return count
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	return calendarDuration{months: months}.addTo(t)
+}
+
+func addDays(t time.Time, days int) time.Time {
+	return t.AddDate(0, 0, days)
+}
+
+func dateDiff(start time.Time, end time.Time, unit string) (int64, error) {
+	switch unit {
+	case "ns", "nanosecond", "nanoseconds":
+		return end.Sub(start).Nanoseconds(), nil
+	case "ms", "millisecond", "milliseconds":
+		return end.Sub(start).Milliseconds(), nil
+	case "s", "second", "seconds":
+		return int64(end.Sub(start) / time.Second), nil
+	case "m", "minute", "minutes":
+		return int64(end.Sub(start) / time.Minute), nil
+	case "h", "hour", "hours":
+		return int64(end.Sub(start) / time.Hour), nil
+	}
+
+	end = end.In(start.Location())
+	switch unit {
+	case "d", "day", "days":
+		return int64(wholeCalendarUnits(start, end, int(end.Sub(start)/(24*time.Hour)), addDays)), nil
+	case "w", "week", "weeks":
+		return int64(wholeCalendarUnits(start, end, int(end.Sub(start)/(24*time.Hour)), addDays) / 7), nil
+	}
+
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	switch unit {
+	case "mo", "month", "months":
+		return int64(wholeCalendarUnits(start, end, months, addMonths)), nil
+	case "y", "year", "years":
+		return int64(wholeCalendarUnits(start, end, months, addMonths) / 12), nil
+	}
+	return 0, fmt.Errorf("unknown date_diff unit '%v', expected one of years, months, weeks, days, hours, minutes, seconds, milliseconds or nanoseconds", unit)
+}
+
+func getDateTimeParameter(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (time.Time, error) {
+	result, err := d.GetMatchingNodes(context.ReadOnlyClone(), expressionNode)
+	if err != nil {
+		return time.Time{}, err
+	} else if result.MatchingNodes.Len() == 0 {
+		return time.Time{}, errors.New("date_diff expected a date time but got nothing")
+	}
+	node := result.MatchingNodes.Front().Value.(*CandidateNode)
+	parsedTime, err := parseDateTime(context.GetDateTimeLayout(), node.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse datetime of [%v] using layout [%v]: %w", node.GetNicePath(), context.GetDateTimeLayout(), err)
+	}
+	return inDateTimeLocation(parsedTime, context.GetDateTimeLocation()), nil
+}
+
+// date_diff(start; end; unit)
+func dateDiffOp(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	args := getFunctionArguments(expressionNode.RHS)
+	if len(args) != 3 {
+		return Context{}, fmt.Errorf("date_diff expects (start; end; unit), but got %v arguments", len(args))
+	}
+
+	var results = list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		candidateContext := context.SingleReadonlyChildContext(candidate)
+
+		start, err := getDateTimeParameter(d, candidateContext, args[0])
+		if err != nil {
+			return Context{}, err
+		}
+		end, err := getDateTimeParameter(d, candidateContext, args[1])
+		if err != nil {
+			return Context{}, err
+		}
+		unit, err := getStringArgument("date_diff", d, candidateContext, args[2])
+		if err != nil {
+			return Context{}, err
+		}
+		diff, err := dateDiff(start, end, unit)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!int", fmt.Sprintf("%v", diff)))
+	}
+	return context.ChildContext(results), nil
+}
+
+var strftimeLayouts = map[rune]string{
+	'a': "Mon",
+	'A': "Monday",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'c': "Mon Jan _2 15:04:05 2006",
+	'd': "02",
+	'D': "01/02/06",
+	'e': "_2",
+	'f': "000000",
+	'F': "2006-01-02",
+	'H': "15",
+	'I': "03",
+	'j': "002",
+	'm': "01",
+	'M': "04",
+	'p': "PM",
+	'R': "15:04",
+	'S': "05",
+	'T': "15:04:05",
+	'y': "06",
+	'Y': "2006",
+	'z': "-0700",
+	'Z': "MST",
+}
+
+// layoutTokenProbe is a time where every field differs from the Golang reference time, so
+// formatting text with it only leaves the text unchanged if it has no layout tokens.
+var layoutTokenProbe = time.Date(1999, 11, 28, 9, 33, 44, 123456789, time.FixedZone("XYZ", 3600))
+
+// strftimeToLayout converts a strftime pattern, like "%Y-%m-%d", to a Golang layout. As Golang layouts
+// cannot escape literal text, literals that would be read as date fields (e.g. "5" or "Jan") are rejected.
+func strftimeToLayout(pattern string) (string, error) {
+	var layout strings.Builder
+	for _, part := range splitStrftimePattern(pattern) {
+		if !part.directive {
+			if layoutTokenProbe.Format(part.text) != part.text {
+				return "", fmt.Errorf("literal text [%v] in [%v] would be read as a date field", part.text, pattern)
+			}
+			layout.WriteString(part.text)
+			continue
+		}
+		goLayout, ok := strftimeLayouts[part.verb]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c in [%v]", part.verb, pattern)
+		}
+		layout.WriteString(goLayout)
+	}
+	if strings.HasSuffix(pattern, "%") && !strings.HasSuffix(pattern, "%%") {
+		return "", fmt.Errorf("strftime pattern [%v] ends with a lone %%", pattern)
+	}
+	return layout.String(), nil
+}
+
+type strftimePart struct {
+	text      string
+	verb      rune
+	directive bool
+}
+
+// splitStrftimePattern splits the pattern into literal text and directives, %% is literal text.
+func splitStrftimePattern(pattern string) []strftimePart {
+	var parts []strftimePart
+	var literal strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case runes[i] != '%' || i == len(runes)-1:
+			literal.WriteRune(runes[i])
+		case runes[i+1] == '%':
+			literal.WriteRune('%')
+			i++
+		default:
+			if literal.Len() > 0 {
+				parts = append(parts, strftimePart{text: literal.String()})
+				literal.Reset()
+			}
+			i++
+			parts = append(parts, strftimePart{verb: runes[i], directive: true})
+		}
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, strftimePart{text: literal.String()})
+	}
+	return parts
+}
+
+// the composite directives, expanded when parsing so that each field is matched on its own
+var strptimeComposites = map[rune]string{
+	'c': "%a %b %e %H:%M:%S %Y",
+	'D': "%m/%d/%y",
+	'F': "%Y-%m-%d",
+	'R': "%H:%M",
+	'T': "%H:%M:%S",
+}
+
+// the text matched by each directive when parsing
+var strptimeRegexes = map[rune]string{
+	'a': `[A-Za-z]{3}`,
+	'A': `[A-Za-z]+`,
+	'b': `[A-Za-z]{3}`,
+	'h': `[A-Za-z]{3}`,
+	'B': `[A-Za-z]+`,
+	'd': `\d{2}`,
+	'e': ` ?\d{1,2}`,
+	'f': `\d{6}`,
+	'H': `\d{2}`,
+	'I': `\d{2}`,
+	'j': `\d{3}`,
+	'm': `\d{2}`,
+	'M': `\d{2}`,
+	'p': `[AaPp][Mm]`,
+	'S': `\d{2}`,
+	'y': `\d{2}`,
+	'Y': `\d{4}`,
+	'z': `[+-]\d{4}`,
+	'Z': `[A-Za-z]+`,
+}
+
+// strptimePattern matches the literal text of the pattern itself, and only gives the text of the
+// directives to Golang to parse, so that literals are never read as date fields.
+type strptimePattern struct {
+	regex   *regexp.Regexp
+	layouts []string
+}
+
+func newStrptimePattern(pattern string) (*strptimePattern, error) {
+	if strings.HasSuffix(pattern, "%") && !strings.HasSuffix(pattern, "%%") {
+		return nil, fmt.Errorf("strftime pattern [%v] ends with a lone %%", pattern)
+	}
+	var parts []strftimePart
+	for _, part := range splitStrftimePattern(pattern) {
+		if composite, ok := strptimeComposites[part.verb]; ok && part.directive {
+			parts = append(parts, splitStrftimePattern(composite)...)
+		} else {
+			parts = append(parts, part)
+		}
+	}
+
+	var regex strings.Builder
+	var layouts []string
+	regex.WriteString("^")
+	for _, part := range parts {
+		if !part.directive {
+			regex.WriteString(regexp.QuoteMeta(part.text))
+			continue
+		}
+		partRegex, ok := strptimeRegexes[part.verb]
+		if !ok {
+			return nil, fmt.Errorf("unsupported directive %%%c in [%v]", part.verb, pattern)
+		}
+		regex.WriteString("(" + partRegex + ")")
+		layouts = append(layouts, strftimeLayouts[part.verb])
+	}
+	regex.WriteString("$")
+	return &strptimePattern{regex: regexp.MustCompile(regex.String()), layouts: layouts}, nil
+}
+
+func (p *strptimePattern) parse(value string) (time.Time, error) {
+	matches := p.regex.FindStringSubmatch(value)
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("[%v] does not match the pattern", value)
+	}
+	fields := matches[1:]
+	for index, layout := range p.layouts {
+		if layout == "PM" {
+			// Golang only parses upper case AM and PM
+			fields[index] = strings.ToUpper(fields[index])
+		}
+	}
+	// the fields are separated by a character that is not part of any layout
+	return time.Parse(strings.Join(p.layouts, "\x00"), strings.Join(fields, "\x00"))
+}
+
+// strftime formats each directive separately, so literal text is never mistaken for a Golang layout.
+func strftime(t time.Time, pattern string) string {
+	var sb strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' || i == len(runes)-1 {
+			sb.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case '%':
+			sb.WriteRune('%')
+		case 'f':
+			sb.WriteString(t.Format(".000000")[1:])
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'u':
+			sb.WriteString(strconv.Itoa((int(t.Weekday())+6)%7 + 1))
+		case 'w':
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		default:
+			if goLayout, ok := strftimeLayouts[runes[i]]; ok {
+				sb.WriteString(t.Format(goLayout))
+			} else {
+				sb.WriteRune('%')
+				sb.WriteRune(runes[i])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// strftime(pattern) formats date times, or unix times, as strings.
+func strftimeOp(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	layout := context.GetDateTimeLayout()
+
+	var results = list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+
+		pattern, err := getStringArgument("strftime", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+
+		var parsedTime time.Time
+		actualTag := candidate.guessTagFromCustomType()
+		if actualTag == "!!int" || actualTag == "!!float" {
+			parsedTime, err = parseUnixTime(candidate.Value)
+			parsedTime = parsedTime.UTC()
+		} else {
+			parsedTime, err = parseDateTime(layout, candidate.Value)
+		}
+		if err != nil {
+			return Context{}, fmt.Errorf("could not parse datetime of [%v] using layout [%v]: %w", candidate.GetNicePath(), layout, err)
+		}
+
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!str", strftime(parsedTime, pattern)))
+	}
+	return context.ChildContext(results), nil
+}
+
+// strptime(pattern) parses strings into timestamps.
+func strptimeOp(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	var results = list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+
+		pattern, err := getStringArgument("strptime", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+		parser, err := newStrptimePattern(pattern)
+		if err != nil {
+			return Context{}, err
+		}
+		parsedTime, err := parser.parse(candidate.Value)
+		if err != nil {
+			return Context{}, fmt.Errorf("could not parse datetime of [%v] using pattern [%v]: %w", candidate.GetNicePath(), pattern, err)
+		}
+
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!timestamp", parsedTime.Format(time.RFC3339Nano)))
+	}
+	return context.ChildContext(results), nil
+}
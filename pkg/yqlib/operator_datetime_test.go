@@ -126,6 +126,173 @@ var dateTimeOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!map)::a: Saturday, 15-Dec-01 at 1:59PM AEDT\n",
 		},
 	},
+	{
+		description:    "Date addition - calendar units",
+		subdescription: "Durations can include years (y), months (mo), weeks (w) and days (d). These respect month lengths, adding a month to the 31st of January gives the last day of February.",
+		document:       `a: 2024-01-31T10:00:00Z`,
+		expression:     `.a += "1mo2d"`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 2024-03-02T10:00:00Z\n",
+		},
+	},
+	{
+		description: "Date subtraction - calendar units",
+		skipDoc:     true,
+		document:    `a: 2024-03-31T10:00:00Z`,
+		expression:  `.a -= "1y1mo"`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 2023-02-28T10:00:00Z\n",
+		},
+	},
+	{
+		description: "Date addition - weeks and hours",
+		skipDoc:     true,
+		document:    `a: 2024-01-01T10:00:00Z`,
+		expression:  `.a += "-2w12h"`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 2023-12-17T22:00:00Z\n",
+		},
+	},
+	{
+		description:   "Date addition - fractional days",
+		skipDoc:       true,
+		document:      `a: 2024-01-01T10:00:00Z`,
+		expression:    `.a += "1.5d"`,
+		expectedError: `unable to parse duration [1.5d]: invalid duration "1.5d", 1.5d must be a whole number`,
+	},
+	{
+		description:    "Subtract two dates",
+		subdescription: "Subtracting one date from another gives the duration between them.",
+		document:       "a: 2024-03-01T12:00:00Z\nb: 2024-02-28T00:00:00Z",
+		expression:     `.a - .b`,
+		expected: []string{
+			"D0, P[a], (!!str)::60h0m0s\n",
+		},
+	},
+	{
+		description:    "Calendar math in a timezone",
+		subdescription: "`tz(timezone; exp)` does the date math in exp in the given timezone, respecting daylight savings. Here the clocks go forward, so a day is only 23 hours.",
+		document:       `a: 2024-03-30T12:00:00Z`,
+		expression:     `.a |= tz("Europe/London"; [. + "1d", . + "24h"])`,
+		expected: []string{
+			"D0, P[], (!!map)::a:\n    - 2024-03-31T12:00:00+01:00\n    - 2024-03-31T13:00:00+01:00\n",
+		},
+	},
+	{
+		description:    "Date difference",
+		subdescription: "`date_diff(start; end; unit)` gives the whole number of units from start to end. Units are years, months, weeks, days, hours, minutes, seconds, milliseconds or nanoseconds.",
+		document:       "issued: 2024-01-31T10:00:00Z\nexpires: 2024-03-31T09:00:00Z",
+		expression:     `[date_diff(.issued; .expires; "months"), date_diff(.issued; .expires; "days"), date_diff(.expires; .issued; "weeks")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 59\n- -8\n",
+		},
+	},
+	{
+		description: "Date difference in years",
+		skipDoc:     true,
+		expression:  `[date_diff("2020-02-29T00:00:00Z"; "2024-02-28T00:00:00Z"; "y"), date_diff("2020-02-29T00:00:00Z"; "2024-02-29T00:00:00Z"; "years")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 3\n- 4\n",
+		},
+	},
+	{
+		description: "Date difference in hours",
+		skipDoc:     true,
+		expression:  `date_diff("2024-01-01T00:00:00Z"; "2024-01-02T01:30:00+01:00"; "hours")`,
+		expected: []string{
+			"D0, P[], (!!int)::24\n",
+		},
+	},
+	{
+		description:   "Date difference with a bad unit",
+		skipDoc:       true,
+		expression:    `date_diff("2024-01-01T00:00:00Z"; "2024-01-02T00:00:00Z"; "fortnights")`,
+		expectedError: "unknown date_diff unit 'fortnights', expected one of years, months, weeks, days, hours, minutes, seconds, milliseconds or nanoseconds",
+	},
+	{
+		description:    "Format with strftime",
+		subdescription: "Like jq, strftime also formats unix times.",
+		document:       "a: 2024-03-05T23:51:47Z\nb: 1700000000",
+		expression:     `.[] |= strftime("%A, %d %B %Y at %H:%M")`,
+		expected: []string{
+			"D0, P[], (!!map)::a: Tuesday, 05 March 2024 at 23:51\nb: Tuesday, 14 November 2023 at 22:13\n",
+		},
+	},
+	{
+		description: "Format with strftime - other directives",
+		skipDoc:     true,
+		document:    `2024-03-05T23:51:47.123Z`,
+		expression:  `strftime("%j %u %w %s %f %% %Q 1")`,
+		expected: []string{
+			"D0, P[], (!!str)::065 2 2 1709682707 123000 % %Q 1\n",
+		},
+	},
+	{
+		description:    "Parse with strptime",
+		subdescription: "Parses the string into a timestamp.",
+		document:       `a: 05/03/2024 10:30`,
+		expression:     `.a |= strptime("%d/%m/%Y %H:%M")`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 2024-03-05T10:30:00Z\n",
+		},
+	},
+	{
+		description:    "Parse with strptime - literal text",
+		subdescription: "Literal text in the pattern is matched as it is, even when it looks like a date.",
+		document:       `a: Day 5 of Jan 2006 was 03/01/2024 Mon`,
+		expression:     `.a |= strptime("Day 5 of Jan 2006 was %d/%m/%Y Mon")`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 2024-01-03T00:00:00Z\n",
+		},
+	},
+	{
+		description: "Parse with strptime - composite directives and am/pm",
+		skipDoc:     true,
+		document:    `[Tue Mar  5 23:51:47 2024, "2024-03-05T10:30:00+0100", "%c 5 pm"]`,
+		expression:  `[.[0] | strptime("%c"), .[1] | strptime("%FT%T%z"), .[2] | strptime("%%c %e %p")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 2024-03-05T23:51:47Z\n- 2024-03-05T10:30:00+01:00\n- 0000-01-05T12:00:00Z\n",
+		},
+	},
+	{
+		description:   "Parse with strptime - text that does not match",
+		skipDoc:       true,
+		document:      `a: Day 6 2024`,
+		expression:    `.a |= strptime("Day 5 %Y")`,
+		expectedError: "could not parse datetime of [a] using pattern [Day 5 %Y]: [Day 6 2024] does not match the pattern",
+	},
+	{
+		description: "Format with strftime - literal text",
+		skipDoc:     true,
+		document:    `2024-03-05T23:51:47Z`,
+		expression:  `strftime("Day 5 of Jan 2006 Mon: %d/%m/%Y")`,
+		expected: []string{
+			"D0, P[], (!!str)::Day 5 of Jan 2006 Mon: 05/03/2024\n",
+		},
+	},
+	{
+		description:   "strftime patterns in with_dtf cannot have literal date fields",
+		skipDoc:       true,
+		document:      `a: Day 5 2024`,
+		expression:    `.a |= with_dtf("Day 5 %Y"; . + "1mo")`,
+		expectedError: "could not get date time format: literal text [Day 5 ] in [Day 5 %Y] would be read as a date field",
+	},
+	{
+		description:   "Parse with an unsupported directive",
+		skipDoc:       true,
+		document:      `a: 05/03/2024`,
+		expression:    `.a |= strptime("%d/%Q")`,
+		expectedError: "unsupported directive %Q in [%d/%Q]",
+	},
+	{
+		description:    "strftime patterns in with_dtf and format_datetime",
+		subdescription: "Layouts containing % are treated as strftime patterns.",
+		document:       `a: 05/03/2024`,
+		expression:     `.a |= with_dtf("%d/%m/%Y"; . + "1mo" | format_datetime("%Y-%m-%d"))`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 2024-04-05\n",
+		},
+	},
 }
 
 func TestDatetimeOperatorScenarios(t *testing.T) {
@@ -326,6 +326,99 @@ var encoderDecoderOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!str)::cats\n",
 		},
 	},
+	{
+		description:    "Encode a string to base64url",
+		subdescription: "Uses the url and filename safe alphabet.",
+		document:       "coolData: \"ÿ?>\"",
+		expression:     ".coolData | [@base64url, @base64]",
+		expected: []string{
+			"D0, P[coolData], (!!seq)::- w78_Pg==\n- w78/Pg==\n",
+		},
+	},
+	{
+		description: "base64url round trip",
+		skipDoc:     true,
+		expression:  `"ÿ?>" | @base64url | @base64urld`,
+		expected: []string{
+			"D0, P[], (!!str)::ÿ?>\n",
+		},
+	},
+	{
+		description: "Encode a string to base32",
+		document:    "coolData: hello",
+		expression:  ".coolData |= @base32",
+		expected: []string{
+			"D0, P[], (!!map)::coolData: NBSWY3DP\n",
+		},
+	},
+	{
+		description: "Decode a base32 encoded string",
+		skipDoc:     true,
+		expression:  `"NBSWY3DP" | @base32d`,
+		expected: []string{
+			"D0, P[], (!!str)::hello\n",
+		},
+	},
+	{
+		description:   "base32 encode a map",
+		skipDoc:       true,
+		expression:    `{} | @base32`,
+		expectedError: "cannot encode !!map as base32, can only operate on strings",
+	},
+	{
+		description:    "Hash a string",
+		subdescription: "@sha256, @sha1 and @md5 hash the value of scalars, returning a hex string.",
+		document:       "password: hello",
+		expression:     ".password |= @sha256",
+		expected: []string{
+			"D0, P[], (!!map)::password: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824\n",
+		},
+	},
+	{
+		description: "sha1 and md5",
+		skipDoc:     true,
+		expression:  `"hello" | [@sha1, @md5]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d\n- 5d41402abc4b2a76b9719d911017c592\n",
+		},
+	},
+	{
+		requiresFormat: "json",
+		description:    "Hash a subtree",
+		subdescription: "Maps and arrays are hashed by their canonical encoding - compact json with sorted keys - so the order of keys doesn't change the hash. This is useful for config hash annotations.",
+		document:       "config: {b: 1, a: [1, 2]}\nsame: {a: [1, 2], b: 1}",
+		expression:     ".config |= @sha256 | .same |= @sha256",
+		expected: []string{
+			"D0, P[], (!!map)::config: 94a786c3662bc7beeb598efa7d8cb58d7bea25d6c275ea9785a0230ff1f8c2ba\nsame: 94a786c3662bc7beeb598efa7d8cb58d7bea25d6c275ea9785a0230ff1f8c2ba\n",
+		},
+	},
+	{
+		description: "Encode a string to hex",
+		document:    "a: hello",
+		expression:  ".a |= @hex",
+		expected: []string{
+			"D0, P[], (!!map)::a: 68656c6c6f\n",
+		},
+	},
+	{
+		description:    "Escape html",
+		subdescription: "Escapes <, >, &, ' and \" like jq.",
+		document:       `a: "<b>Tom & Jerry's</b>"`,
+		expression:     ".a |= @html",
+		expected: []string{
+			"D0, P[], (!!map)::a: \"&lt;b&gt;Tom &amp; Jerry&#39;s&lt;/b&gt;\"\n",
+		},
+	},
+	{
+		requiresFormat: "json",
+		description:    "Encode as text",
+		subdescription: "Scalars are returned as strings, maps and arrays are encoded as json.",
+		document:       "a: 3\nb: {c: cat}",
+		expression:     ".[] |= @text",
+		expected: []string{
+			"D0, P[], (!!map)::a: \"3\"\nb: '{\"c\":\"cat\"}'\n",
+		},
+	},
 	{
 		requiresFormat: "xml",
 		description:    "empty xml decode",
@@ -0,0 +1,76 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+)
+
+func foreachOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("foreachOp")
+	// foreach .[] as $x (init; update; extract)
+	// is rewritten by the lexer to
+	// .[] as $x FOREACH (init; update; extract)
+	// lhs is the assignment operator, rhs is the block.
+	// Like reduce, '.' in update refers to the current state, unlike reduce
+	// each state (or the result of extract) is returned.
+
+	if expressionNode.LHS.Operation.OperationType != assignVariableOpType {
+		return Context{}, fmt.Errorf("foreach must be given a variables assignment, got %v instead", expressionNode.LHS.Operation.OperationType.Type)
+	} else if expressionNode.RHS.Operation.OperationType != blockOpType {
+		return Context{}, fmt.Errorf("foreach must be given a block, got %v instead", expressionNode.RHS.Operation.OperationType.Type)
+	}
+
+	args := getFunctionArguments(expressionNode.RHS)
+	if len(args) > 3 {
+		return Context{}, fmt.Errorf("foreach expects (init; update) or (init; update; extract), but got %v arguments", len(args))
+	}
+	initExp, updateExp := args[0], args[1]
+	var extractExp *ExpressionNode
+	if len(args) == 3 {
+		extractExp = args[2]
+	}
+
+	items, err := d.GetMatchingNodes(context, expressionNode.LHS.LHS)
+	if err != nil {
+		return Context{}, err
+	}
+
+	state, err := d.GetMatchingNodes(context, initExp)
+	if err != nil {
+		return Context{}, err
+	}
+
+	results := list.New()
+	for el := items.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		log.Debugf("FOREACH WITH %v", NodeToString(candidate))
+
+		// copy the state, as it has already been returned and update may change it in place
+		stateCopy := list.New()
+		for stateEl := state.MatchingNodes.Front(); stateEl != nil; stateEl = stateEl.Next() {
+			stateCopy.PushBack(stateEl.Value.(*CandidateNode).Copy())
+		}
+		iterationContext := context.ChildContext(stateCopy)
+		if err := bindVariable(d, &iterationContext, expressionNode.LHS.RHS, candidate); err != nil {
+			return Context{}, err
+		}
+
+		updated, err := d.GetMatchingNodes(iterationContext, updateExp)
+		if err != nil {
+			return Context{}, err
+		}
+		state = iterationContext.ChildContext(updated.MatchingNodes)
+
+		if extractExp == nil {
+			results.PushBackList(state.MatchingNodes)
+			continue
+		}
+		extracted, err := d.GetMatchingNodes(state, extractExp)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBackList(extracted.MatchingNodes)
+	}
+
+	return context.ChildContext(results), nil
+}
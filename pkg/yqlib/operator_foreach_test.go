@@ -0,0 +1,63 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var foreachOperatorScenarios = []expressionScenario{
+	{
+		description: "Running total",
+		document:    `[10, 2, 5, 3]`,
+		expression:  `[foreach .[] as $item (0; . + $item)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 10\n- 12\n- 17\n- 20\n",
+		},
+	},
+	{
+		description:    "Extract from each state",
+		subdescription: "The extract expression has access to the current state as `.` and the current element as the variable.",
+		document:       `[a, b, c]`,
+		expression:     `[foreach .[] as $item (0; . + 1; {"index": ., "value": $item})]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- index: 1\n  value: a\n- index: 2\n  value: b\n- index: 3\n  value: c\n",
+		},
+	},
+	{
+		description:    "Layer config",
+		subdescription: "Each state is a copy, so updating the state does not change previous results.",
+		document:       `[{a: 1}, {b: 2}, {a: 3}]`,
+		expression:     `[foreach .[] as $item ({}; . * $item)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a: 1\n- {a: 1, b: 2}\n- {a: 3, b: 2}\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[1, 2, 3]`,
+		expression: `[foreach .[] as $item ({}; .[$item | to_string] = $item)] | length`,
+		expected: []string{
+			"D0, P[], (!!int)::3\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[]`,
+		expression: `[foreach .[] as $item (0; . + $item)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::[]\n",
+		},
+	},
+	{
+		skipDoc:       true,
+		document:      `[1]`,
+		expression:    `foreach .[] as $item (0; . + $item; .; .)`,
+		expectedError: "foreach expects (init; update) or (init; update; extract), but got 4 arguments",
+	},
+}
+
+func TestForeachOperatorScenarios(t *testing.T) {
+	for _, tt := range foreachOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "foreach", foreachOperatorScenarios)
+}
@@ -0,0 +1,156 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+)
+
+// toGoValue converts the node into plain go maps, slices and scalars. Scalars
+// that do not fit a go value, like ints too big for an int64, are left as strings.
+func toGoValue(node *CandidateNode) interface{} {
+	if node.Kind == AliasNode && node.Alias != nil {
+		return toGoValue(node.Alias)
+	}
+	switch node.Kind {
+	case MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		for index := 0; index < len(node.Content); index = index + 2 {
+			result[node.Content[index].Value] = toGoValue(node.Content[index+1])
+		}
+		return result
+	case SequenceNode:
+		result := make([]interface{}, len(node.Content))
+		for index, child := range node.Content {
+			result[index] = toGoValue(child)
+		}
+		return result
+	}
+	var value interface{}
+	var err error
+	if node.guessTagFromCustomType() == "!!float" {
+		value, err = parseFloatValue(node.Value)
+	} else {
+		value, err = node.GetValueRep()
+	}
+	if err != nil {
+		log.Debugf("toGoValue: using the string value of %v: %v", node.Value, err)
+		return node.Value
+	}
+	return value
+}
+
+// formatVerbs returns the verb used by each argument of the format string,
+// or nil if the arguments are explicitly indexed.
+func formatVerbs(format string) []rune {
+	verbs := make([]rune, 0)
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			continue
+		}
+		for i = i + 1; i < len(runes) && strings.ContainsRune("+-# 0123456789.*[]", runes[i]); i++ {
+			if runes[i] == '[' {
+				return nil
+			} else if runes[i] == '*' {
+				verbs = append(verbs, 'd')
+			}
+		}
+		if i < len(runes) && runes[i] != '%' {
+			verbs = append(verbs, runes[i])
+		}
+	}
+	return verbs
+}
+
+// formatArgument coerces the value to suit the verb, so that yaml ints work with %f,
+// whole floats work with %d, and anything works with %s.
+func formatArgument(verb rune, node *CandidateNode, value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		if strings.ContainsRune("eEfFgG", verb) {
+			return float64(v)
+		}
+	case float64:
+		if verb == 'd' && v == math.Trunc(v) && !math.IsInf(v, 0) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+	}
+	if (verb == 's' || verb == 'q') && node.Kind == ScalarNode {
+		return node.Value
+	}
+	return value
+}
+
+// format(fmt; args) and format(fmt), which formats the current node.
+func formatOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("Format")
+	formatExp := expressionNode.RHS
+	var argsExp *ExpressionNode
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		formatExp = expressionNode.RHS.LHS
+		argsExp = expressionNode.RHS.RHS
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		candidateContext := context.SingleReadonlyChildContext(candidate)
+
+		format, err := getStringArgument("format", d, candidateContext, formatExp)
+		if err != nil {
+			return Context{}, err
+		}
+
+		argNodes := list.New()
+		argNodes.PushBack(candidate)
+		if argsExp != nil {
+			args, err := d.GetMatchingNodes(candidateContext, argsExp)
+			if err != nil {
+				return Context{}, err
+			}
+			argNodes = args.MatchingNodes
+		}
+
+		verbs := formatVerbs(format)
+		values := make([]interface{}, 0, argNodes.Len())
+		for argEl := argNodes.Front(); argEl != nil; argEl = argEl.Next() {
+			argNode := argEl.Value.(*CandidateNode)
+			value := toGoValue(argNode)
+			if len(values) < len(verbs) {
+				value = formatArgument(verbs[len(values)], argNode, value)
+			}
+			values = append(values, value)
+		}
+
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!str", fmt.Sprintf(format, values...)))
+	}
+	return context.ChildContext(results), nil
+}
+
+// template(text) renders the go text/template against the current node.
+func templateOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("Template")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+
+		text, err := getStringArgument("template", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+		tmpl, err := template.New("template").Parse(text)
+		if err != nil {
+			return Context{}, err
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, toGoValue(candidate)); err != nil {
+			return Context{}, err
+		}
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!str", sb.String()))
+	}
+	return context.ChildContext(results), nil
+}
@@ -0,0 +1,127 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var formatOperatorScenarios = []expressionScenario{
+	{
+		description:    "Format with printf style verbs",
+		subdescription: "This uses Golang's [fmt](https://pkg.go.dev/fmt) package, so widths, padding and precision all work. Each result of the second expression is an argument.",
+		document:       "name: cpu0\ncpu: 3.14159",
+		expression:     `format("%-6s|%6.2f|"; .name, .cpu)`,
+		expected: []string{
+			"D0, P[], (!!str)::cpu0  |  3.14|\n",
+		},
+	},
+	{
+		description:    "Format the current node",
+		subdescription: "Without a second parameter, the current node is formatted. Ints can be used with float verbs and whole floats with `%d`.",
+		document:       `[1, 2.0, 3.5]`,
+		expression:     `.[] |= format("%05.1f")`,
+		expected: []string{
+			"D0, P[], (!!seq)::[\"001.0\", \"002.0\", \"003.5\"]\n",
+		},
+	},
+	{
+		description: "Format whole floats as ints",
+		skipDoc:     true,
+		document:    `2.0`,
+		expression:  `format("%03d")`,
+		expected: []string{
+			"D0, P[], (!!str)::002\n",
+		},
+	},
+	{
+		description: "Format anything as a string",
+		skipDoc:     true,
+		document:    `[3, true, null]`,
+		expression:  `format("%s %s %s"; .[])`,
+		expected: []string{
+			"D0, P[], (!!str)::3 true null\n",
+		},
+	},
+	{
+		description: "Format with star width",
+		skipDoc:     true,
+		document:    `a`,
+		expression:  `format("[%*s]"; 3, .)`,
+		expected: []string{
+			"D0, P[], (!!str)::[  a]\n",
+		},
+	},
+	{
+		description:   "Format with a non string",
+		skipDoc:       true,
+		document:      `a`,
+		expression:    `format(3)`,
+		expectedError: "format must be given a string, but got !!int",
+	},
+	{
+		description:    "Render a template",
+		subdescription: "This uses Golang's [text/template](https://pkg.go.dev/text/template), with the current node as `.`.",
+		document:       "name: yq\nversion: 4.1.0\nauthors: [mike, bob]",
+		expression:     `template("# {{ .name }} {{ .version }}\n{{ range .authors }}- {{ . }}\n{{ end }}")`,
+		expected: []string{
+			"D0, P[], (!!str)::# yq 4.1.0\n- mike\n- bob\n\n",
+		},
+	},
+	{
+		description:    "Template with conditionals",
+		subdescription: "Numbers and booleans keep their types, so they can be compared.",
+		document:       "debug: true\nreplicas: 3",
+		expression:     `template("{{ if .debug }}DEBUG=1\n{{ end }}{{ if gt .replicas 1 }}REPLICAS={{ .replicas }}{{ end }}")`,
+		expected: []string{
+			"D0, P[], (!!str)::DEBUG=1\nREPLICAS=3\n",
+		},
+	},
+	{
+		description: "Template with aliases",
+		skipDoc:     true,
+		document:    "a: &x {b: cat}\nc: *x",
+		expression:  `template("{{ .c.b }}")`,
+		expected: []string{
+			"D0, P[], (!!str)::cat\n",
+		},
+	},
+	{
+		description: "Template with a number too big for an int64",
+		skipDoc:     true,
+		expression:  `{"a": 99999999999999999999, "b": "x"} | template("{{.b}}")`,
+		expected: []string{
+			"D0, P[], (!!str)::x\n",
+		},
+	},
+	{
+		description: "Template with an int too big for an int64",
+		skipDoc:     true,
+		document:    `{a: !!int 99999999999999999999, b: x}`,
+		expression:  `template("{{.b}} {{.a}}")`,
+		expected: []string{
+			"D0, P[], (!!str)::x 99999999999999999999\n",
+		},
+	},
+	{
+		description: "Format an int too big for an int64",
+		skipDoc:     true,
+		document:    `a: !!int 99999999999999999999`,
+		expression:  `format("%s"; .a)`,
+		expected: []string{
+			"D0, P[], (!!str)::99999999999999999999\n",
+		},
+	},
+	{
+		description:   "Template with bad syntax",
+		skipDoc:       true,
+		document:      `a: cat`,
+		expression:    `template("{{ .a ")`,
+		expectedError: "template: template:1: unclosed action",
+	},
+}
+
+func TestFormatOperatorScenarios(t *testing.T) {
+	for _, tt := range formatOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "format-and-template", formatOperatorScenarios)
+}
@@ -0,0 +1,205 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// builtinFunctionsSource holds builtins that are defined in terms of other
+// operators, written with the same `def` syntax users have available.
+const builtinFunctionsSource = `
+def array_to_map: (.[] | select(. != null) ) as $i ireduce({}; .[$i | key] = $i);
+def root: parent(-1);
+def recurse: recurse(.[]);
+def paths: (path | length) as $n | .. | path | .[$n:] | select(length > 0);
+def paths(f): . as $dot | paths | select(. as $p | $dot | getpath($p) | f);
+def leaf_paths: paths(kind == "scalar");
+def to_paths: [(path | length) as $n | .. | select(kind == "scalar" or length == 0) | [(path | .[$n:]), .]];
+def truncate_stream(stream): . as $n | null | stream | select((.[0] | length) > $n) | .[0] |= .[$n:];
+def add: .[] as $x ireduce (null; . + $x);
+def sum: .[] as $x ireduce (0; . + $x);
+def avg: (select(length > 0) | sum / length) // null;
+def JOIN($idx; idx_expr): [.[] | [., $idx[idx_expr]]];
+def JOIN($idx; stream; idx_expr): stream | [., $idx[idx_expr]];
+def JOIN($idx; stream; idx_expr; join_expr): stream | [., $idx[idx_expr]] as $pair | $pair | join_expr;
+.`
+
+var builtinFunctions map[string]*functionDefinition
+var builtinFunctionsOnce sync.Once
+
+type functionDefinition struct {
+	name   string
+	params []string
+	body   *ExpressionNode
+	// the context the function was defined in, this is
+	// what gives functions lexical scoping.
+	closure Context
+}
+
+type functionDefinitionPreferences struct {
+	Name   string
+	Params []string
+}
+
+func functionKey(name string, arity int) string {
+	return fmt.Sprintf("%v/%v", name, arity)
+}
+
+func getBuiltinFunctions() map[string]*functionDefinition {
+	builtinFunctionsOnce.Do(func() {
+		builtinFunctions = make(map[string]*functionDefinition)
+		node, err := ExpressionParser.ParseExpression(builtinFunctionsSource)
+		if err != nil {
+			panic(fmt.Errorf("could not parse builtin functions: %w", err))
+		}
+		for node != nil && node.Operation.OperationType == functionDefinitionOpType {
+			function := newFunctionDefinition(node, Context{})
+			builtinFunctions[functionKey(function.name, len(function.params))] = function
+			node = node.RHS
+		}
+	})
+	return builtinFunctions
+}
+
+func newFunctionDefinition(expressionNode *ExpressionNode, closure Context) *functionDefinition {
+	prefs := expressionNode.Operation.Preferences.(functionDefinitionPreferences)
+	return &functionDefinition{
+		name:    prefs.Name,
+		params:  prefs.Params,
+		body:    expressionNode.LHS,
+		closure: closure,
+	}
+}
+
+func parseFunctionParams(paramString string) []string {
+	params := make([]string, 0)
+	if strings.TrimSpace(paramString) == "" {
+		return params
+	}
+	for _, param := range strings.Split(paramString, ";") {
+		params = append(params, strings.TrimSpace(param))
+	}
+	return params
+}
+
+// def name(params): body; rest
+// lhs is the function body, rhs is the expression the function is visible in.
+func functionDefinitionOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	scope := context.ChildContext(context.MatchingNodes)
+	function := newFunctionDefinition(expressionNode, Context{})
+	scope.SetFunction(function)
+	log.Debugf("defined function %v", functionKey(function.name, len(function.params)))
+
+	// the closure includes the function itself, so that it can recurse.
+	function.closure = scope.ChildContext(nil)
+
+	result, err := d.GetMatchingNodes(scope, expressionNode.RHS)
+	if err != nil {
+		return Context{}, err
+	}
+	return context.ChildContext(result.MatchingNodes), nil
+}
+
+func getFunctionArguments(expressionNode *ExpressionNode) []*ExpressionNode {
+	args := make([]*ExpressionNode, 0)
+	for expressionNode != nil {
+		if expressionNode.Operation.OperationType != blockOpType {
+			return append(args, expressionNode)
+		}
+		args = append(args, expressionNode.LHS)
+		expressionNode = expressionNode.RHS
+	}
+	return args
+}
+
+func callFunctionOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	name := expressionNode.Operation.StringValue
+	args := getFunctionArguments(expressionNode.RHS)
+
+	function := context.GetFunction(name, len(args))
+	if function == nil {
+		return Context{}, fmt.Errorf("%v is not defined", functionKey(name, len(args)))
+	}
+	log.Debugf("calling function %v", functionKey(name, len(args)))
+
+	hasValueParams := false
+	for _, param := range function.params {
+		hasValueParams = hasValueParams || strings.HasPrefix(param, "$")
+	}
+
+	// functions are called for each input, so that bodies like reduce see a
+	// single input. Value parameters are evaluated against each input, and the
+	// function is called for every combination of their results.
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidateContext := context.SingleChildContext(el.Value.(*CandidateNode))
+		if !hasValueParams {
+			result, err := callFunction(d, candidateContext, function, args, nil)
+			if err != nil {
+				return Context{}, err
+			}
+			results.PushBackList(result.MatchingNodes)
+			continue
+		}
+		err := callFunctionWithValues(d, candidateContext, function, args, make([]*CandidateNode, 0, len(args)), results)
+		if err != nil {
+			return Context{}, err
+		}
+	}
+	return context.ChildContext(results), nil
+}
+
+func callFunctionWithValues(d *dataTreeNavigator, context Context, function *functionDefinition, args []*ExpressionNode, values []*CandidateNode, results *list.List) error {
+	if len(values) == len(args) {
+		result, err := callFunction(d, context, function, args, values)
+		if err != nil {
+			return err
+		}
+		results.PushBackList(result.MatchingNodes)
+		return nil
+	}
+
+	index := len(values)
+	if !strings.HasPrefix(function.params[index], "$") {
+		return callFunctionWithValues(d, context, function, args, append(values, nil), results)
+	}
+
+	argResults, err := d.GetMatchingNodes(context.ReadOnlyClone(), args[index])
+	if err != nil {
+		return err
+	}
+	for el := argResults.MatchingNodes.Front(); el != nil; el = el.Next() {
+		err = callFunctionWithValues(d, context, function, args, append(values, el.Value.(*CandidateNode)), results)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callFunction(d *dataTreeNavigator, context Context, function *functionDefinition, args []*ExpressionNode, values []*CandidateNode) (Context, error) {
+	scope := function.closure.ChildContext(context.MatchingNodes)
+	scope.DontAutoCreate = context.DontAutoCreate
+	scope.datetimeLayout = context.datetimeLayout
+
+	// filter parameters are closures over the scope of the caller
+	callerScope := context.ChildContext(nil)
+
+	for i, param := range function.params {
+		paramName := strings.TrimPrefix(param, "$")
+		scope.SetFunction(&functionDefinition{name: paramName, params: []string{}, body: args[i], closure: callerScope})
+		if values != nil && values[i] != nil {
+			variableValue := list.New()
+			variableValue.PushBack(values[i].Copy())
+			scope.SetVariable(paramName, variableValue)
+		}
+	}
+
+	result, err := d.GetMatchingNodes(scope, function.body)
+	if err != nil {
+		return Context{}, err
+	}
+	return context.ChildContext(result.MatchingNodes), nil
+}
@@ -0,0 +1,137 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var functionOperatorScenarios = []expressionScenario{
+	{
+		description:    "Define a function",
+		subdescription: "Functions are defined with `def name: body;` and can be used anywhere in the rest of the expression.",
+		document:       `{a: [1, 2, 3]}`,
+		expression:     `def increment: . + 1; .a[] |= increment`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: [2, 3, 4]}\n",
+		},
+	},
+	{
+		description:    "Functions with filter parameters",
+		subdescription: "Filter parameters are evaluated each time they are used, against the current value (`.`) inside the function.",
+		document:       `[{name: cat, age: 3}, {name: dog, age: 5}]`,
+		expression:     `def names(f): map(f | .name); names(select(.age > 4))`,
+		expected: []string{
+			"D0, P[], (!!seq)::[dog]\n",
+		},
+	},
+	{
+		description:    "Functions with value parameters",
+		subdescription: "Parameters starting with `$` are evaluated against the input of the function call, and bound as variables.",
+		document:       `{multiplier: 3, values: [1, 2]}`,
+		expression:     `def scale($by): map(. * $by); .values |= scale(parent | .multiplier)`,
+		expected: []string{
+			"D0, P[], (!!map)::{multiplier: 3, values: [3, 6]}\n",
+		},
+	},
+	{
+		description: "Multiple parameters",
+		expression:  `def between($low; $high): select(. >= $low and . <= $high); [1, 5, 10] | map(between(2; 8))`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 5\n",
+		},
+	},
+	{
+		description: "Recursive functions",
+		document:    `{a: {b: {c: {}}}}`,
+		expression:  `def depth: [0, (.[] | depth + 1)] | max; depth`,
+		expected: []string{
+			"D0, P[1], (!!int)::3\n",
+		},
+	},
+	{
+		description:    "Functions are lexically scoped",
+		subdescription: "Functions see the variables and functions that were defined where they were declared, not where they are called.",
+		expression:     `1 as $x | def f: $x; 2 as $x | [f, $x]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n",
+		},
+	},
+	{
+		description: "Nested functions",
+		expression:  `def f: def g: 3; g * 2; f`,
+		expected: []string{
+			"D0, P[], (!!int)::6\n",
+		},
+	},
+	{
+		description: "Functions can be overloaded by number of parameters",
+		expression:  `def f: "none"; def f(a): "one"; [f, f(1)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- none\n- one\n",
+		},
+	},
+	{
+		description: "Function names starting with a builtin",
+		skipDoc:     true,
+		expression:  `def mapper: "mine"; mapper`,
+		expected: []string{
+			"D0, P[], (!!str)::mine\n",
+		},
+	},
+	{
+		description: "Filter parameters are evaluated in the scope of the caller",
+		skipDoc:     true,
+		expression:  `def f(g): def h: "inner"; g; def h: "outer"; f(h)`,
+		expected: []string{
+			"D0, P[], (!!str)::outer\n",
+		},
+	},
+	{
+		description:    "Functions are called once for each input",
+		subdescription: "Like jq, a function is called separately for each of its inputs. Note this differs from writing the body inline, where `ireduce` sees all of the inputs together - `.[] | (.[] as $x ireduce (0; . + $x))` returns `6`.",
+		document:       `[[1, 2], [3]]`,
+		expression:     `def total: .[] as $x ireduce (0; . + $x); .[] | total`,
+		expected: []string{
+			"D0, P[], (!!int)::3\n",
+			"D0, P[], (!!int)::3\n",
+		},
+	},
+	{
+		description: "Value parameters generate a result for each value",
+		skipDoc:     true,
+		expression:  `def f($a): $a * 10; f(1, 2)`,
+		expected: []string{
+			"D0, P[], (!!int)::10\n",
+			"D0, P[], (!!int)::20\n",
+		},
+	},
+	{
+		description: "Definitions inside brackets",
+		skipDoc:     true,
+		expression:  `[def f: 1; f, 2]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n",
+		},
+	},
+	{
+		description: "Traverse function results",
+		skipDoc:     true,
+		document:    `{a: {b: cat}}`,
+		expression:  `def f: .a; f.b`,
+		expected: []string{
+			"D0, P[a b], (!!str)::cat\n",
+		},
+	},
+	{
+		description:   "Undefined function",
+		skipDoc:       true,
+		expression:    `def f: 1; f(2)`,
+		expectedError: "f/1 is not defined",
+	},
+}
+
+func TestFunctionOperatorScenarios(t *testing.T) {
+	for _, tt := range functionOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "user-defined-functions", functionOperatorScenarios)
+}
@@ -0,0 +1,292 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// the maximum number of values range can generate, to stop a bad step from running forever
+const maxRangeLength = 10000000
+
+func getNumberParameter(parameterName string, d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (*CandidateNode, float64, error) {
+	result, err := d.GetMatchingNodes(context.ReadOnlyClone(), expressionNode)
+	if err != nil {
+		return nil, 0, err
+	} else if result.MatchingNodes.Len() == 0 {
+		return nil, 0, fmt.Errorf("%v must be a number, but got nothing", parameterName)
+	}
+	node := result.MatchingNodes.Front().Value.(*CandidateNode)
+	if node.Kind != ScalarNode || (node.guessTagFromCustomType() != "!!int" && node.guessTagFromCustomType() != "!!float") {
+		return nil, 0, fmt.Errorf("%v must be a number, got %v", parameterName, node.Tag)
+	}
+	if node.guessTagFromCustomType() == "!!int" {
+		_, value, err := parseInt64(node.Value)
+		return node, float64(value), err
+	}
+	value, err := strconv.ParseFloat(node.Value, 64)
+	return node, value, err
+}
+
+func createRangeNode(value float64, isFloat bool) *CandidateNode {
+	if isFloat {
+		return createScalarNode(value, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return createScalarNode(int64(value), fmt.Sprintf("%v", int64(value)))
+}
+
+// range(upto), range(from; upto) and range(from; upto; by)
+func rangeOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	args := getFunctionArguments(expressionNode.RHS)
+	if len(args) > 3 {
+		return Context{}, fmt.Errorf("range expects 1 to 3 arguments, but got %v", len(args))
+	}
+	names := [][]string{{"upto"}, {"from", "upto"}, {"from", "upto", "by"}}[len(args)-1]
+
+	results := list.New()
+
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidateContext := context.SingleChildContext(el.Value.(*CandidateNode))
+
+		from, upto, by := 0.0, 0.0, 1.0
+		values := []*float64{&upto}
+		if len(args) > 1 {
+			values = []*float64{&from, &upto, &by}[:len(args)]
+		}
+		isFloat := false
+		for i, arg := range args {
+			node, value, err := getNumberParameter(names[i], d, candidateContext, arg)
+			if err != nil {
+				return Context{}, err
+			}
+			isFloat = isFloat || node.guessTagFromCustomType() == "!!float"
+			*values[i] = value
+		}
+
+		if by == 0 {
+			return Context{}, fmt.Errorf("range step must not be 0")
+		}
+		if math.Abs((upto-from)/by) > maxRangeLength {
+			return Context{}, fmt.Errorf("range would generate more than %v values", maxRangeLength)
+		}
+		for current := from; (by > 0 && current < upto) || (by < 0 && current > upto); current = current + by {
+			results.PushBack(createRangeNode(current, isFloat))
+		}
+	}
+	return context.ChildContext(results), nil
+}
+
+// getFirstMatchingNodes evaluates the expression against each candidate in turn, stopping once
+// it has found the given number of results. Unions and pipes are evaluated step by step, so that
+// e.g. the right hand side of a union is only evaluated if more results are needed.
+func getFirstMatchingNodes(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode, count int) (*list.List, error) {
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil && results.Len() < count; el = el.Next() {
+		candidateContext := context.SingleChildContext(el.Value.(*CandidateNode))
+		err := appendFirstMatchingNodes(d, candidateContext, expressionNode, count, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func appendFirstMatchingNodes(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode, count int, results *list.List) error {
+	switch expressionNode.Operation.OperationType {
+	case unionOpType:
+		err := appendFirstMatchingNodes(d, context, expressionNode.LHS, count, results)
+		if err != nil || results.Len() >= count {
+			return err
+		}
+		return appendFirstMatchingNodes(d, context, expressionNode.RHS, count, results)
+	case pipeOpType, shortPipeOpType:
+		if expressionNode.LHS.Operation.OperationType == assignVariableOpType {
+			break
+		}
+		lhs, err := d.GetMatchingNodes(context, expressionNode.LHS)
+		if err != nil {
+			return err
+		}
+		for el := lhs.MatchingNodes.Front(); el != nil && results.Len() < count; el = el.Next() {
+			err = appendFirstMatchingNodes(d, context.SingleChildContext(el.Value.(*CandidateNode)), expressionNode.RHS, count, results)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case recurseOpType, repeatOpType:
+		recurseExp, conditionExp := getRecurseArguments(expressionNode)
+		return recurse(d, context, recurseExp, conditionExp, count, results)
+	}
+
+	matches, err := d.GetMatchingNodes(context, expressionNode)
+	if err != nil {
+		return err
+	}
+	for el := matches.MatchingNodes.Front(); el != nil && results.Len() < count; el = el.Next() {
+		results.PushBack(el.Value)
+	}
+	return nil
+}
+
+// limit(n; exp)
+func limitOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	if expressionNode.RHS.Operation.OperationType != blockOpType {
+		return Context{}, fmt.Errorf("limit must be given a block (;), got %v instead", expressionNode.RHS.Operation.OperationType.Type)
+	}
+
+	results := list.New()
+
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidateContext := context.SingleChildContext(el.Value.(*CandidateNode))
+
+		node, count, err := getNumberParameter("limit", d, candidateContext, expressionNode.RHS.LHS)
+		if err != nil {
+			return Context{}, err
+		} else if node.guessTagFromCustomType() != "!!int" {
+			return Context{}, fmt.Errorf("limit must be an integer, got %v", node.Value)
+		} else if count <= 0 {
+			continue
+		}
+
+		limited, err := getFirstMatchingNodes(d, candidateContext, expressionNode.RHS.RHS, int(count))
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBackList(limited)
+	}
+	return context.ChildContext(results), nil
+}
+
+// first_of(exp)
+func firstOfOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		first, err := getFirstMatchingNodes(d, context.SingleChildContext(el.Value.(*CandidateNode)), expressionNode.RHS, 1)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBackList(first)
+	}
+	return context.ChildContext(results), nil
+}
+
+func conditionIsTruthy(d *dataTreeNavigator, context Context, conditionExp *ExpressionNode) (bool, error) {
+	condition, err := d.GetMatchingNodes(context.ReadOnlyClone(), conditionExp)
+	if err != nil {
+		return false, err
+	}
+	for el := condition.MatchingNodes.Front(); el != nil; el = el.Next() {
+		if isTruthyNode(el.Value.(*CandidateNode)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// generateWhile applies the update to each candidate, over and over. Each value is given to
+// emit, along with whether the condition holds for it. Iteration stops when emit returns false.
+func generateWhile(d *dataTreeNavigator, context Context, conditionExp *ExpressionNode, updateExp *ExpressionNode, emit func(*CandidateNode, bool) bool) error {
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidateContext := context.SingleChildContext(el.Value.(*CandidateNode))
+		conditionResult, err := conditionIsTruthy(d, candidateContext, conditionExp)
+		if err != nil {
+			return err
+		}
+		if !emit(el.Value.(*CandidateNode), conditionResult) {
+			continue
+		}
+		updated, err := d.GetMatchingNodes(candidateContext, updateExp)
+		if err != nil {
+			return err
+		}
+		err = generateWhile(d, updated, conditionExp, updateExp, emit)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// until(cond; update)
+func untilOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	if expressionNode.RHS.Operation.OperationType != blockOpType {
+		return Context{}, fmt.Errorf("until must be given a block (;), got %v instead", expressionNode.RHS.Operation.OperationType.Type)
+	}
+	results := list.New()
+	err := generateWhile(d, context, expressionNode.RHS.LHS, expressionNode.RHS.RHS, func(node *CandidateNode, conditionResult bool) bool {
+		if conditionResult {
+			results.PushBack(node)
+		}
+		return !conditionResult
+	})
+	if err != nil {
+		return Context{}, err
+	}
+	return context.ChildContext(results), nil
+}
+
+// while(cond; update)
+func whileOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	if expressionNode.RHS.Operation.OperationType != blockOpType {
+		return Context{}, fmt.Errorf("while must be given a block (;), got %v instead", expressionNode.RHS.Operation.OperationType.Type)
+	}
+	results := list.New()
+	err := generateWhile(d, context, expressionNode.RHS.LHS, expressionNode.RHS.RHS, func(node *CandidateNode, conditionResult bool) bool {
+		if conditionResult {
+			results.PushBack(node)
+		}
+		return conditionResult
+	})
+	if err != nil {
+		return Context{}, err
+	}
+	return context.ChildContext(results), nil
+}
+
+func getRecurseArguments(expressionNode *ExpressionNode) (*ExpressionNode, *ExpressionNode) {
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		return expressionNode.RHS.LHS, expressionNode.RHS.RHS
+	}
+	return expressionNode.RHS, nil
+}
+
+// recurse(exp), recurse(exp; cond) and repeat(exp), recurse on its own is defined in the builtin functions.
+func recurseOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	recurseExp, conditionExp := getRecurseArguments(expressionNode)
+	results := list.New()
+	err := recurse(d, context, recurseExp, conditionExp, math.MaxInt, results)
+	if err != nil {
+		return Context{}, err
+	}
+	return context.ChildContext(results), nil
+}
+
+// recurse adds the candidates, and the results of applying recurseExp to them, until results has count items.
+func recurse(d *dataTreeNavigator, context Context, recurseExp *ExpressionNode, conditionExp *ExpressionNode, count int, results *list.List) error {
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		results.PushBack(candidate)
+		if results.Len() >= count {
+			return nil
+		}
+
+		candidateContext := context.SingleChildContext(candidate)
+		children, err := d.GetMatchingNodes(candidateContext.ReadOnlyClone(), recurseExp)
+		if err != nil {
+			return err
+		}
+		if conditionExp != nil {
+			children, err = selectOperator(d, children, &ExpressionNode{RHS: conditionExp})
+			if err != nil {
+				return err
+			}
+		}
+		err = recurse(d, children, recurseExp, conditionExp, count, results)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var generatorOperatorScenarios = []expressionScenario{
+	{
+		description: "Range",
+		expression:  `[range(5)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- 1\n- 2\n- 3\n- 4\n",
+		},
+	},
+	{
+		description: "Range from and to",
+		expression:  `[range(2; 5)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 2\n- 3\n- 4\n",
+		},
+	},
+	{
+		description: "Range with a step",
+		expression:  `[range(0; 10; 3)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- 3\n- 6\n- 9\n",
+		},
+	},
+	{
+		description: "Range with a negative step",
+		expression:  `[range(5; 0; -2)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 5\n- 3\n- 1\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		expression: `[range(0; 1; 0.25)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- 0.25\n- 0.5\n- 0.75\n",
+		},
+	},
+	{
+		description:    "Range using values from the document",
+		subdescription: "Useful for generating things like port ranges",
+		document:       `{start: 8080, count: 3}`,
+		expression:     `[range(.start; .start + .count)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 8080\n- 8081\n- 8082\n",
+		},
+	},
+	{
+		skipDoc:       true,
+		expression:    `range(0; 1; 0)`,
+		expectedError: "range step must not be 0",
+	},
+	{
+		skipDoc:       true,
+		expression:    `range("cat")`,
+		expectedError: "upto must be a number, got !!str",
+	},
+	{
+		skipDoc:       true,
+		expression:    `range(1; 2; 3; 4)`,
+		expectedError: "range expects 1 to 3 arguments, but got 4",
+	},
+	{
+		description: "Limit",
+		document:    `[a, b, c, d]`,
+		expression:  `[limit(2; .[])]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a\n- b\n",
+		},
+	},
+	{
+		description:    "Limit stops evaluating once it has enough results",
+		subdescription: "The error is never reached",
+		expression:     `[limit(3; 1, 2, 3, error("never evaluated"))]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n- 3\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[[1, 2], [3, 4]]`,
+		expression: `[limit(3; .[] | .[])]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n- 3\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[a, b]`,
+		expression: `[limit(0; .[])]`,
+		expected: []string{
+			"D0, P[], (!!seq)::[]\n",
+		},
+	},
+	{
+		skipDoc:       true,
+		expression:    `limit(1.5; 1)`,
+		expectedError: "limit must be an integer, got 1.5",
+	},
+	{
+		description: "First of a generator",
+		document:    `[{name: a, ok: false}, {name: b, ok: true}, {name: c, ok: true}]`,
+		expression:  `first_of(.[] | select(.ok)) | .name`,
+		expected: []string{
+			"D0, P[1 name], (!!str)::b\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		expression: `[first_of(.a[])]`,
+		expected: []string{
+			"D0, P[], (!!seq)::[]\n",
+		},
+	},
+	{
+		description: "Until",
+		expression:  `1 | until(. > 100; . * 2)`,
+		expected: []string{
+			"D0, P[], (!!int)::128\n",
+		},
+	},
+	{
+		description: "While",
+		expression:  `[1 | while(. < 100; . * 2)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n- 4\n- 8\n- 16\n- 32\n- 64\n",
+		},
+	},
+	{
+		description: "Recurse",
+		document:    `{name: a, children: [{name: b}, {name: c, children: [{name: d}]}]}`,
+		expression:  `[recurse(.children[]) | .name]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a\n- b\n- c\n- d\n",
+		},
+	},
+	{
+		description: "Recurse with a condition",
+		expression:  `[2 | recurse(. * .; . < 100)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 2\n- 4\n- 16\n",
+		},
+	},
+	{
+		description:    "Repeat",
+		subdescription: "`repeat` never stops unless its expression returns nothing, so is usually used with `limit` or `first_of`.",
+		expression:     `[limit(5; 1 | repeat(. * 2))]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n- 4\n- 8\n- 16\n",
+		},
+	},
+	{
+		description: "Repeat until the expression returns nothing",
+		skipDoc:     true,
+		expression:  `[0 | repeat(select(. < 3) | . + 1)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- 1\n- 2\n- 3\n",
+		},
+	},
+	{
+		description: "Limit stops recursing once it has enough results",
+		skipDoc:     true,
+		expression:  `[limit(3; 2 | recurse(. * .)), first_of(3 | repeat(.))]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 2\n- 4\n- 16\n- 3\n",
+		},
+	},
+	{
+		description:    "Recurse without arguments",
+		subdescription: "The same as `recurse(.[])`",
+		document:       `[1, [2]]`,
+		expression:     `[recurse]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [1, [2]]\n- 1\n- [2]\n- 2\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[1, [2]]`,
+		expression: `[recurse | select(tag == "!!int")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n",
+		},
+	},
+}
+
+func TestGeneratorOperatorScenarios(t *testing.T) {
+	for _, tt := range generatorOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "generators", generatorOperatorScenarios)
+}
@@ -0,0 +1,193 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfiguredLibraryPaths are the directories searched by import and include.
+// If empty, $HOME/.yq is used.
+var ConfiguredLibraryPaths = []string{}
+
+const moduleExtension = ".yq"
+
+type importPreferences struct {
+	Path    string
+	Alias   string
+	Include bool
+	Data    bool
+}
+
+func getLibraryPaths() []string {
+	if len(ConfiguredLibraryPaths) > 0 {
+		return ConfiguredLibraryPaths
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Debugf("could not determine home directory: %v", err)
+		return []string{}
+	}
+	return []string{filepath.Join(home, ".yq")}
+}
+
+func fileExists(filename string) bool {
+	info, err := os.Stat(filename)
+	return err == nil && !info.IsDir()
+}
+
+// resolveImportPath finds the file for an import. Paths starting with ./ or ../ are relative to
+// the importing module (or the current directory), otherwise the library paths are searched.
+// Modules may either be <path>.yq or <path>/<name>.yq.
+func resolveImportPath(path string, originDir string, data bool) (string, error) {
+	searchDirs := getLibraryPaths()
+	if filepath.IsAbs(path) {
+		searchDirs = []string{""}
+	} else if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		searchDirs = []string{originDir}
+	}
+
+	candidates := []string{path}
+	if !data {
+		candidates = []string{path + moduleExtension, filepath.Join(path, filepath.Base(path)+moduleExtension)}
+	}
+
+	for _, dir := range searchDirs {
+		for _, candidate := range candidates {
+			filename := filepath.Join(dir, candidate)
+			if fileExists(filename) {
+				log.Debugf("resolved import '%v' to %v", path, filename)
+				return filename, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("could not find '%v' in library paths %v", path, searchDirs)
+}
+
+func importData(filename string) (*list.List, error) {
+	format, err := FormatFromString(FormatStringFromFilename(filename))
+	if err != nil {
+		return nil, err
+	}
+	node, err := loadWithDecoder(filename, format.DecoderFactory())
+	if err != nil {
+		return nil, err
+	}
+	return node.AsList(), nil
+}
+
+func importFunction(function *functionDefinition, alias string) *functionDefinition {
+	if alias == "" {
+		return function
+	}
+	return &functionDefinition{
+		name:    alias + "::" + function.name,
+		params:  function.params,
+		body:    function.body,
+		closure: function.closure,
+	}
+}
+
+// processImport adds the functions (or data variable) from an import directive to the scope,
+// returning any functions that should be re-exported by the module (i.e. from an include).
+func processImport(scope *Context, prefs importPreferences, originDir string, loading map[string]bool) ([]*functionDefinition, error) {
+	if ConfiguredSecurityPreferences.DisableFileOps {
+		return nil, fmt.Errorf("file operations have been disabled")
+	}
+	filename, err := resolveImportPath(prefs.Path, originDir, prefs.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefs.Data {
+		data, err := importData(filename)
+		if err != nil {
+			return nil, err
+		}
+		scope.SetVariable(prefs.Alias, data)
+		return nil, nil
+	}
+
+	functions, err := loadModule(filename, loading)
+	if err != nil {
+		return nil, err
+	}
+	for _, function := range functions {
+		scope.SetFunction(importFunction(function, prefs.Alias))
+	}
+	if prefs.Include {
+		return functions, nil
+	}
+	return nil, nil
+}
+
+// loadModule reads a module, which can only contain import directives and definitions,
+// and returns the functions it defines.
+func loadModule(filename string, loading map[string]bool) ([]*functionDefinition, error) {
+	absolutePath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if loading[absolutePath] {
+		return nil, fmt.Errorf("circular import of %v", filename)
+	}
+	loading[absolutePath] = true
+	defer delete(loading, absolutePath)
+
+	source, err := os.ReadFile(filename) // #nosec
+	if err != nil {
+		return nil, err
+	}
+
+	// a module has no expression after its definitions, so we add one
+	node, err := ExpressionParser.ParseExpression(string(source) + "\n.")
+	if err != nil {
+		return nil, fmt.Errorf("could not parse module %v: %w", filename, err)
+	}
+
+	scope := Context{}
+	exported := make([]*functionDefinition, 0)
+	for node.Operation.OperationType != selfReferenceOpType {
+		// compare the type name, as referring to importOpType here would be an initialisation cycle
+		switch node.Operation.OperationType.Type {
+		case functionDefinitionOpType.Type:
+			function := newFunctionDefinition(node, Context{})
+			scope.SetFunction(function)
+			function.closure = scope.ChildContext(nil)
+			exported = append(exported, function)
+		case "IMPORT":
+			prefs := node.Operation.Preferences.(importPreferences)
+			included, err := processImport(&scope, prefs, filepath.Dir(filename), loading)
+			if err != nil {
+				return nil, err
+			}
+			exported = append(exported, included...)
+		default:
+			return nil, fmt.Errorf("module %v can only contain imports and definitions", filename)
+		}
+		node = node.RHS
+	}
+	return exported, nil
+}
+
+// import "path" as name; rest
+// include "path"; rest
+// the RHS is the expression the imported functions are visible in.
+func importOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	prefs := expressionNode.Operation.Preferences.(importPreferences)
+	log.Debugf("importOperator %v", prefs.Path)
+
+	scope := context.ChildContext(context.MatchingNodes)
+	_, err := processImport(&scope, prefs, ".", make(map[string]bool))
+	if err != nil {
+		return Context{}, err
+	}
+
+	result, err := d.GetMatchingNodes(scope, expressionNode.RHS)
+	if err != nil {
+		return Context{}, err
+	}
+	return context.ChildContext(result.MatchingNodes), nil
+}
@@ -0,0 +1,85 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var importOperatorScenarios = []expressionScenario{
+	{
+		description: "Import a module",
+		subdescription: "Given a `lib/shell.yq` module of:\n```\n# helpers for exporting yaml as shell variables\ndef quote: \"'\" + . + \"'\";\n\n" +
+			"def to_shell_vars: .[] | (\n    ( select(kind == \"scalar\") | key + \"=\" + quote),\n    ( select(kind == \"seq\") | key + \"=(\" + (map(quote) | join(\",\")) + \")\")\n);\n```\n" +
+			"Functions from an imported module are prefixed with the given name.",
+		yqFlags:    "-L lib",
+		document:   "name: cat\nfriends: [dog, mouse]",
+		expression: `import "shell" as sh; sh::to_shell_vars`,
+		expected: []string{
+			"D0, P[name='cat'], (!!str)::name='cat'\n",
+			"D0, P[friends=('dog','mouse')], (!!str)::friends=('dog','mouse')\n",
+		},
+	},
+	{
+		description:    "Include a module",
+		subdescription: "Included functions are not prefixed.",
+		yqFlags:        "-L lib",
+		document:       "name: cat",
+		expression:     `include "shell"; .name | quote`,
+		expected: []string{
+			"D0, P[], (!!str)::'cat'\n",
+		},
+	},
+	{
+		description:    "Import data",
+		subdescription: "Given a `lib/defaults.yaml` file of:\n```yaml\nreplicas: 1\nimage: nginx\n```\nData files are decoded using the format matching their file extension, and are bound to the variable.",
+		yqFlags:        "-L lib",
+		document:       "replicas: 3",
+		expression:     `import "defaults.yaml" as $defaults; $defaults * .`,
+		expected: []string{
+			"D0, P[], (!!map)::replicas: 3\nimage: nginx\n",
+		},
+	},
+	{
+		description:   "Relative imports are not searched for in the library path",
+		expression:    `import "./lib/shell" as sh; "cat" | sh::quote`,
+		skipDoc:       true,
+		expectedError: "could not find './lib/shell' in library paths [.]",
+	},
+	{
+		description: "Import relative path",
+		skipDoc:     true,
+		expression:  `import "../../examples/lib/shell" as sh; "cat" | sh::quote`,
+		expected: []string{
+			"D0, P[], (!!str)::'cat'\n",
+		},
+	},
+	{
+		description:   "Missing module",
+		skipDoc:       true,
+		expression:    `include "missing"; .`,
+		expectedError: "could not find 'missing' in library paths [../../examples/lib]",
+	},
+}
+
+func TestImportOperatorScenarios(t *testing.T) {
+	ConfiguredLibraryPaths = []string{"../../examples/lib"}
+	defer func() { ConfiguredLibraryPaths = []string{} }()
+
+	for _, tt := range importOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "import-include", importOperatorScenarios)
+}
+
+func TestImportDisabledFileOps(t *testing.T) {
+	ConfiguredLibraryPaths = []string{"../../examples/lib"}
+	ConfiguredSecurityPreferences.DisableFileOps = true
+	defer func() {
+		ConfiguredLibraryPaths = []string{}
+		ConfiguredSecurityPreferences.DisableFileOps = false
+	}()
+
+	testScenario(t, &expressionScenario{
+		expression:    `include "shell"; .`,
+		expectedError: "file operations have been disabled",
+	})
+}
@@ -0,0 +1,114 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+)
+
+// stringIndices finds the (codepoint) offsets of every occurrence of target in value,
+// including those that overlap.
+func stringIndices(value string, target string) []int {
+	indices := make([]int, 0)
+	if target == "" {
+		return indices
+	}
+	runes := []rune(value)
+	for offset := range runes {
+		if strings.HasPrefix(string(runes[offset:]), target) {
+			indices = append(indices, offset)
+		}
+	}
+	return indices
+}
+
+// arrayIndices finds the offsets where the target array appears in the array. If
+// the target is not an array, the offsets of entries equal to it are returned.
+func arrayIndices(array *CandidateNode, target *CandidateNode) []int {
+	targetContent := []*CandidateNode{target}
+	if target.Kind == SequenceNode {
+		targetContent = target.Content
+	}
+	indices := make([]int, 0)
+	if len(targetContent) == 0 {
+		return indices
+	}
+	for offset := 0; offset+len(targetContent) <= len(array.Content); offset++ {
+		matches := true
+		for i, targetChild := range targetContent {
+			if !recursiveNodeEqual(array.Content[offset+i], targetChild) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			indices = append(indices, offset)
+		}
+	}
+	return indices
+}
+
+func findIndices(node *CandidateNode, target *CandidateNode) ([]int, error) {
+	switch {
+	case node.Kind == SequenceNode:
+		return arrayIndices(node, target), nil
+	case node.Kind == ScalarNode && node.guessTagFromCustomType() == "!!str":
+		if target.Kind != ScalarNode || target.guessTagFromCustomType() != "!!str" {
+			return nil, fmt.Errorf("cannot find the indices of %v in a string, expected a string", target.Tag)
+		}
+		return stringIndices(node.Value, target.Value), nil
+	}
+	return nil, fmt.Errorf("cannot find indices in %v, can only search strings and arrays", node.Tag)
+}
+
+func createIndexNode(index int) *CandidateNode {
+	return createScalarNode(int64(index), fmt.Sprintf("%v", index))
+}
+
+func indicesFunction(funcName string, pick func(indices []int) *CandidateNode) operatorHandler {
+	return func(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			node := el.Value.(*CandidateNode)
+			if node.Tag == "!!null" {
+				results.PushBack(createScalarNode(nil, "null"))
+				continue
+			}
+			target, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(node), expressionNode.RHS)
+			if err != nil {
+				return Context{}, err
+			} else if target.MatchingNodes.Len() == 0 {
+				return Context{}, fmt.Errorf("%v must be given a value to find", funcName)
+			}
+			indices, err := findIndices(node, target.MatchingNodes.Front().Value.(*CandidateNode))
+			if err != nil {
+				return Context{}, err
+			}
+			results.PushBack(pick(indices))
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+var indicesOperator = indicesFunction("indices", func(indices []int) *CandidateNode {
+	node := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	for _, index := range indices {
+		node.AddChild(createIndexNode(index))
+	}
+	return node
+})
+
+var indexOperator = indicesFunction("index", func(indices []int) *CandidateNode {
+	if len(indices) == 0 {
+		return createScalarNode(nil, "null")
+	}
+	return createIndexNode(indices[0])
+})
+
+var rindexOperator = indicesFunction("rindex", func(indices []int) *CandidateNode {
+	if len(indices) == 0 {
+		return createScalarNode(nil, "null")
+	}
+	return createIndexNode(indices[len(indices)-1])
+})
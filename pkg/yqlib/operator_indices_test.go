@@ -0,0 +1,82 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var indicesOperatorScenarios = []expressionScenario{
+	{
+		description:    "Indices of a substring",
+		subdescription: "Offsets are in codepoints, and overlapping matches are included.",
+		document:       `"a,b, cd, efg"`,
+		expression:     `indices(", ")`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 3\n- 7\n",
+		},
+	},
+	{
+		description: "Overlapping indices",
+		skipDoc:     true,
+		document:    `aaa`,
+		expression:  `indices("aa")`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- 1\n",
+		},
+	},
+	{
+		description:    "Index and rindex",
+		subdescription: "Returns the first and last index, or null if there are none.",
+		document:       `"a,b, cd, efg"`,
+		expression:     `[index(", "), rindex(", "), index("x")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 3\n- 7\n- null\n",
+		},
+	},
+	{
+		description: "Indices of an entry in an array",
+		document:    `[0, 1, 2, 1, 3]`,
+		expression:  `indices(1)`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 3\n",
+		},
+	},
+	{
+		description: "Indices of a sub-array",
+		document:    `[0, 1, 2, 1, 3, 1, 2]`,
+		expression:  `indices([1, 2])`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 5\n",
+		},
+	},
+	{
+		description: "Index of a map in an array",
+		skipDoc:     true,
+		document:    `[{a: 1}, {a: 2}]`,
+		expression:  `index({"a": 2})`,
+		expected: []string{
+			"D0, P[], (!!int)::1\n",
+		},
+	},
+	{
+		description: "Indices of null",
+		skipDoc:     true,
+		expression:  `indices("a")`,
+		expected: []string{
+			"D0, P[], (!!null)::null\n",
+		},
+	},
+	{
+		description:   "Indices in a number",
+		skipDoc:       true,
+		document:      `3`,
+		expression:    `indices(3)`,
+		expectedError: "cannot find indices in !!int, can only search strings and arrays",
+	},
+}
+
+func TestIndicesOperatorScenarios(t *testing.T) {
+	for _, tt := range indicesOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "indices", indicesOperatorScenarios)
+}
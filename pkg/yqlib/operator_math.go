@@ -0,0 +1,267 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type mathNumber struct {
+	isInt      bool
+	intFormat  string
+	intValue   int64
+	floatValue float64
+}
+
+func parseFloatValue(value string) (float64, error) {
+	switch strings.ToLower(value) {
+	case ".inf", "+.inf":
+		return math.Inf(1), nil
+	case "-.inf":
+		return math.Inf(-1), nil
+	case ".nan":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func formatFloatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return ".inf"
+	case math.IsInf(value, -1):
+		return "-.inf"
+	case math.IsNaN(value):
+		return ".nan"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func parseMathNumber(funcName string, node *CandidateNode) (mathNumber, error) {
+	if node.Kind == ScalarNode {
+		switch node.guessTagFromCustomType() {
+		case "!!int":
+			format, value, err := parseInt64(node.Value)
+			if err != nil {
+				return mathNumber{}, err
+			}
+			return mathNumber{isInt: true, intFormat: format, intValue: value, floatValue: float64(value)}, nil
+		case "!!float":
+			value, err := parseFloatValue(node.Value)
+			if err != nil {
+				return mathNumber{}, err
+			}
+			return mathNumber{floatValue: value}, nil
+		}
+	}
+	return mathNumber{}, fmt.Errorf("%v: expected a number but got %v (%v)", funcName, node.Tag, node.GetNicePath())
+}
+
+// createMathResult creates the result node in place of the original, keeping
+// its (custom) tag if the result is still the same type of number.
+func createMathResult(original *CandidateNode, tag string, value string) *CandidateNode {
+	target := original.CopyWithoutContent()
+	target.Kind = ScalarNode
+	if original.guessTagFromCustomType() != tag {
+		target.Tag = tag
+	}
+	target.Value = value
+	return target
+}
+
+func createMathIntResult(original *CandidateNode, format string, value int64) *CandidateNode {
+	return createMathResult(original, "!!int", fmt.Sprintf(format, value))
+}
+
+func createMathFloatResult(original *CandidateNode, value float64) *CandidateNode {
+	return createMathResult(original, "!!float", formatFloatValue(value))
+}
+
+func mathOperator(funcName string, fn func(original *CandidateNode, number mathNumber) (*CandidateNode, error)) operatorHandler {
+	return func(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			candidate := el.Value.(*CandidateNode)
+			number, err := parseMathNumber(funcName, candidate)
+			if err != nil {
+				return Context{}, err
+			}
+			result, err := fn(candidate, number)
+			if err != nil {
+				return Context{}, err
+			}
+			results.PushBack(result)
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+// roundingOperator rounds floats to ints (when they fit), ints are left as they are.
+func roundingOperator(funcName string, round func(float64) float64) operatorHandler {
+	return mathOperator(funcName, func(original *CandidateNode, number mathNumber) (*CandidateNode, error) {
+		if number.isInt {
+			return createMathIntResult(original, number.intFormat, number.intValue), nil
+		}
+		rounded := round(number.floatValue)
+		if math.IsNaN(rounded) || rounded >= math.MaxInt64 || rounded < math.MinInt64 {
+			return createMathFloatResult(original, rounded), nil
+		}
+		return createMathIntResult(original, "%v", int64(rounded)), nil
+	})
+}
+
+// floatOperator applies a function that always results in a float, e.g. sqrt.
+func floatOperator(funcName string, fn func(float64) float64) operatorHandler {
+	return mathOperator(funcName, func(original *CandidateNode, number mathNumber) (*CandidateNode, error) {
+		result := fn(number.floatValue)
+		if math.IsNaN(result) && !math.IsNaN(number.floatValue) {
+			return nil, fmt.Errorf("%v: %v is out of range", funcName, original.Value)
+		}
+		return createMathFloatResult(original, result), nil
+	})
+}
+
+var floorOperator = roundingOperator("floor", math.Floor)
+var ceilOperator = roundingOperator("ceil", math.Ceil)
+var roundOperator = roundingOperator("round", math.Round)
+var sqrtOperator = floatOperator("sqrt", math.Sqrt)
+var logOperator = floatOperator("log", math.Log)
+var expOperator = floatOperator("exp", math.Exp)
+
+var absOperator = mathOperator("abs", func(original *CandidateNode, number mathNumber) (*CandidateNode, error) {
+	if !number.isInt {
+		return createMathFloatResult(original, math.Abs(number.floatValue)), nil
+	} else if number.intValue == math.MinInt64 {
+		return createMathFloatResult(original, math.Abs(number.floatValue)), nil
+	} else if number.intValue < 0 {
+		return createMathIntResult(original, number.intFormat, -number.intValue), nil
+	}
+	return createMathIntResult(original, number.intFormat, number.intValue), nil
+})
+
+// powIntegers raises an int to a (non negative) int power, returning false if the result overflows.
+func powIntegers(base int64, exponent int64) (int64, bool) {
+	switch {
+	case base == 1 || exponent == 0:
+		return 1, true
+	case base == 0:
+		return 0, true
+	case base == -1 && exponent%2 == 0:
+		return 1, true
+	case base == -1:
+		return -1, true
+	}
+	result := int64(1)
+	for i := int64(0); i < exponent; i++ {
+		if base != 0 && (result > math.MaxInt64/absInt64(base) || result < math.MinInt64/absInt64(base)) {
+			return 0, false
+		}
+		result = result * base
+	}
+	return result, true
+}
+
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func getMathParameter(funcName string, d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (*CandidateNode, mathNumber, error) {
+	result, err := d.GetMatchingNodes(context.ReadOnlyClone(), expressionNode)
+	if err != nil {
+		return nil, mathNumber{}, err
+	} else if result.MatchingNodes.Len() == 0 {
+		return nil, mathNumber{}, fmt.Errorf("%v: expected a number but got nothing", funcName)
+	}
+	node := result.MatchingNodes.Front().Value.(*CandidateNode)
+	number, err := parseMathNumber(funcName, node)
+	return node, number, err
+}
+
+// pow(base; exponent)
+func powOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("pow")
+	if expressionNode.RHS.Operation.OperationType != blockOpType {
+		return Context{}, fmt.Errorf("pow must be given a block (;), got %v instead", expressionNode.RHS.Operation.OperationType.Type)
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidateContext := context.SingleChildContext(el.Value.(*CandidateNode))
+
+		baseNode, base, err := getMathParameter("pow", d, candidateContext, expressionNode.RHS.LHS)
+		if err != nil {
+			return Context{}, err
+		}
+		_, exponent, err := getMathParameter("pow", d, candidateContext, expressionNode.RHS.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+
+		if base.isInt && exponent.isInt && exponent.intValue >= 0 && base.intValue != math.MinInt64 {
+			if result, ok := powIntegers(base.intValue, exponent.intValue); ok {
+				results.PushBack(createMathIntResult(baseNode, base.intFormat, result))
+				continue
+			}
+		}
+		results.PushBack(createMathFloatResult(baseNode, math.Pow(base.floatValue, exponent.floatValue)))
+	}
+	return context.ChildContext(results), nil
+}
+
+func superlativeBy(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode, prefs compareTypePref) (Context, error) {
+	fn := compare(prefs)
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		if candidate.Kind != SequenceNode {
+			return Context{}, fmt.Errorf("%v (%v) cannot be compared by an expression, expected an array", candidate.Tag, candidate.GetNicePath())
+		}
+
+		var best *CandidateNode
+		var bestValue *CandidateNode
+		for _, child := range candidate.Content {
+			value, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(child), expressionNode.RHS)
+			if err != nil {
+				return Context{}, err
+			}
+			var childValue *CandidateNode
+			if value.MatchingNodes.Len() > 0 {
+				childValue = value.MatchingNodes.Front().Value.(*CandidateNode)
+			}
+			if best == nil {
+				best, bestValue = child, childValue
+				continue
+			}
+			cmp, err := fn(d, context, childValue, bestValue)
+			if err != nil {
+				return Context{}, err
+			}
+			if isTruthyNode(cmp) {
+				best, bestValue = child, childValue
+			}
+		}
+		if best != nil {
+			results.PushBack(best)
+		}
+	}
+	return context.ChildContext(results), nil
+}
+
+// min_by(exp)
+func minByOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("MinBy")
+	return superlativeBy(d, context, expressionNode, compareTypePref{Greater: false})
+}
+
+// max_by(exp)
+func maxByOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("MaxBy")
+	return superlativeBy(d, context, expressionNode, compareTypePref{Greater: true})
+}
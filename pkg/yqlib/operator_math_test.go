@@ -0,0 +1,201 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var mathOperatorScenarios = []expressionScenario{
+	{
+		description:    "Floor, ceil and round",
+		subdescription: "Floats are rounded to ints, ints are left as they are.",
+		document:       `[3.7, -3.5, 2]`,
+		expression:     `[.[] | [floor, ceil, round]]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - 3\n  - 4\n  - 4\n- - -4\n  - -3\n  - -4\n- - 2\n  - 2\n  - 2\n",
+		},
+	},
+	{
+		description: "Round a float to an int",
+		skipDoc:     true,
+		document:    `1.5`,
+		expression:  `round | tag`,
+		expected: []string{
+			"D0, P[], (!!str)::!!int\n",
+		},
+	},
+	{
+		description: "Round scientific notation",
+		skipDoc:     true,
+		document:    `1.5e3`,
+		expression:  `floor`,
+		expected: []string{
+			"D0, P[], (!!int)::1500\n",
+		},
+	},
+	{
+		description: "Round keeps hex",
+		skipDoc:     true,
+		document:    `0x1F`,
+		expression:  `round`,
+		expected: []string{
+			"D0, P[], (!!int)::0x1F\n",
+		},
+	},
+	{
+		description: "Absolute value",
+		document:    `[-3, 2.5, -0x10]`,
+		expression:  `[.[] | abs]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 3\n- 2.5\n- 0x10\n",
+		},
+	},
+	{
+		description:    "Square root",
+		subdescription: "sqrt, log (natural log) and exp always return floats.",
+		document:       `{a: 16}`,
+		expression:     `.a |= sqrt`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: 4}\n",
+		},
+	},
+	{
+		description: "Square root tag",
+		skipDoc:     true,
+		document:    `16`,
+		expression:  `sqrt | tag`,
+		expected: []string{
+			"D0, P[], (!!str)::!!float\n",
+		},
+	},
+	{
+		description: "Log and exp",
+		document:    `1`,
+		expression:  `[log, exp]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- 2.718281828459045\n",
+		},
+	},
+	{
+		description: "Log of 0",
+		skipDoc:     true,
+		document:    `0`,
+		expression:  `log`,
+		expected: []string{
+			"D0, P[], (!!float)::-.inf\n",
+		},
+	},
+	{
+		description:   "Square root of a negative number",
+		skipDoc:       true,
+		document:      `-1`,
+		expression:    `sqrt`,
+		expectedError: "sqrt: -1 is out of range",
+	},
+	{
+		description:   "Math on a string",
+		skipDoc:       true,
+		document:      `{a: cat}`,
+		expression:    `.a | floor`,
+		expectedError: "floor: expected a number but got !!str (a)",
+	},
+	{
+		description:    "Power",
+		subdescription: "Ints raised to (non negative) int powers stay as ints, unless they are too large.",
+		document:       `{cpu: 2}`,
+		expression:     `[pow(.cpu; 10), pow(.cpu; -1), pow(2.5; 2), pow(2; 100)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1024\n- 0.5\n- 6.25\n- 1.2676506002282294e+30\n",
+		},
+	},
+	{
+		description: "Power of hex",
+		skipDoc:     true,
+		expression:  `pow(0x10; 2)`,
+		expected: []string{
+			"D0, P[], (!!int)::0x100\n",
+		},
+	},
+	{
+		description: "Power of one",
+		skipDoc:     true,
+		expression:  `[pow(1; 1000000000000), pow(-1; 3), pow(0; 0)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- -1\n- 1\n",
+		},
+	},
+	{
+		description: "Minimum by an expression",
+		document:    `[{name: a, cpu: 3}, {name: b, cpu: 1}, {name: c, cpu: 2}]`,
+		expression:  `min_by(.cpu)`,
+		expected: []string{
+			"D0, P[1], (!!map)::{name: b, cpu: 1}\n",
+		},
+	},
+	{
+		description: "Maximum by an expression",
+		document:    `[{name: a, cpu: 3}, {name: b, cpu: 1}, {name: c, cpu: 2}]`,
+		expression:  `max_by(.cpu)`,
+		expected: []string{
+			"D0, P[0], (!!map)::{name: a, cpu: 3}\n",
+		},
+	},
+	{
+		description: "Minimum by of empty array",
+		skipDoc:     true,
+		document:    `[]`,
+		expression:  `min_by(.cpu)`,
+		expected:    []string{},
+	},
+	{
+		description:    "Add",
+		subdescription: "Adds all the entries of an array (or map) together with `+`, so it works with strings, arrays and maps too. Returns null for an empty array.",
+		document:       `[[a, b], [c]]`,
+		expression:     `add`,
+		expected: []string{
+			"D0, P[], (!!seq)::[a, b, c]\n",
+		},
+	},
+	{
+		description: "Add empty array",
+		skipDoc:     true,
+		document:    `[]`,
+		expression:  `add`,
+		expected: []string{
+			"D0, P[], (!!null)::null\n",
+		},
+	},
+	{
+		description: "Add each array",
+		skipDoc:     true,
+		document:    `[[{a: 1}, {b: 2}], [{a: 3}, {b: 4}]]`,
+		expression:  `map(add)`,
+		expected: []string{
+			"D0, P[], (!!seq)::[{a: 1, b: 2}, {a: 3, b: 4}]\n",
+		},
+	},
+	{
+		description:    "Sum and average",
+		subdescription: "sum returns 0 and avg returns null for an empty array.",
+		document:       `[1, 2.5, 3]`,
+		expression:     `[sum, avg]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 6.5\n- 2.1666666666666665\n",
+		},
+	},
+	{
+		description: "Sum and average of an empty array",
+		skipDoc:     true,
+		document:    `[]`,
+		expression:  `[sum, avg]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 0\n- null\n",
+		},
+	},
+}
+
+func TestMathOperatorScenarios(t *testing.T) {
+	for _, tt := range mathOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "math", mathOperatorScenarios)
+}
@@ -0,0 +1,272 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+)
+
+// array merge strategies, as well as "key:<name>" to merge the entries by the value of their <name> key.
+const (
+	mergeStrategyReplace   = "replace"
+	mergeStrategyAppend    = "append"
+	mergeStrategyPrepend   = "prepend"
+	mergeStrategyUnique    = "unique-append"
+	mergeStrategyIndex     = "index"
+	mergeStrategyKeyPrefix = "key:"
+)
+
+type mergeStrategies struct {
+	patterns   [][]string
+	strategies []string
+}
+
+func parseMergeStrategies(node *CandidateNode) (mergeStrategies, error) {
+	strategies := mergeStrategies{}
+	if node == nil || node.Tag == "!!null" {
+		return strategies, nil
+	} else if node.Kind != MappingNode {
+		return strategies, fmt.Errorf("merge strategies must be a map of paths to strategies, but got %v", node.Tag)
+	}
+	for index := 0; index < len(node.Content); index = index + 2 {
+		path, strategy := node.Content[index], node.Content[index+1]
+		if strategy.Kind != ScalarNode || strategy.Tag == "!!null" || strategy.Value == "" {
+			return strategies, fmt.Errorf("merge strategy for '%v' must be a string, but got %v", path.Value, strategy.Tag)
+		}
+		strategyValue := strategy.Value
+		switch strategyValue {
+		case mergeStrategyReplace, mergeStrategyAppend, mergeStrategyPrepend, mergeStrategyUnique, mergeStrategyIndex:
+		case mergeStrategyKeyPrefix:
+			return strategies, fmt.Errorf("merge strategy for '%v' is missing the key name after '%v'", path.Value, mergeStrategyKeyPrefix)
+		default:
+			if !strings.HasPrefix(strategyValue, mergeStrategyKeyPrefix) {
+				// any other name is shorthand for key:<name>
+				strategyValue = mergeStrategyKeyPrefix + strategyValue
+			}
+		}
+		pattern := make([]string, 0)
+		if path.Value != "" {
+			pattern = strings.Split(path.Value, ".")
+		}
+		strategies.patterns = append(strategies.patterns, pattern)
+		strategies.strategies = append(strategies.strategies, strategyValue)
+	}
+	return strategies, nil
+}
+
+// strategyFor returns the strategy of the first pattern that matches the path, where '*' matches any key.
+// Array indices are not part of the path, so "spec.containers.env" matches the env of each container.
+func (s mergeStrategies) strategyFor(path []string) string {
+	for index, pattern := range s.patterns {
+		if len(pattern) != len(path) {
+			continue
+		}
+		matches := true
+		for i, key := range pattern {
+			matches = matches && (key == "*" || key == path[i])
+		}
+		if matches {
+			return s.strategies[index]
+		}
+	}
+	return mergeStrategyReplace
+}
+
+// mergeComments keeps the comments of the lhs, falling back to those of the rhs.
+func mergeComments(target *CandidateNode, lhs *CandidateNode, rhs *CandidateNode) {
+	target.LeadingContent, target.HeadComment, target.FootComment = getComments(lhs, rhs)
+	target.LineComment = rhs.LineComment
+	if lhs.LineComment != "" {
+		target.LineComment = lhs.LineComment
+	}
+}
+
+func replaceChild(parent *CandidateNode, index int, value *CandidateNode) {
+	value.SetParent(parent)
+	if parent.Kind == MappingNode {
+		value.Key = parent.Content[index-1]
+	} else {
+		value.Key = createScalarNode(index, fmt.Sprintf("%v", index))
+	}
+	parent.Content[index] = value
+}
+
+func mergeNodes(lhs *CandidateNode, rhs *CandidateNode, path []string, strategies mergeStrategies) (*CandidateNode, error) {
+	var result *CandidateNode
+	var err error
+	switch {
+	case lhs.Kind == MappingNode && rhs.Kind == MappingNode:
+		result, err = mergeMaps(lhs, rhs, path, strategies)
+	case lhs.Kind == SequenceNode && rhs.Kind == SequenceNode:
+		result, err = mergeArrays(lhs, rhs, path, strategies)
+	default:
+		result = lhs.CopyAsReplacement(rhs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mergeComments(result, lhs, rhs)
+	return result, nil
+}
+
+func mergeMaps(lhs *CandidateNode, rhs *CandidateNode, path []string, strategies mergeStrategies) (*CandidateNode, error) {
+	result := lhs.Copy()
+	for index := 0; index < len(rhs.Content); index = index + 2 {
+		key, value := rhs.Content[index], rhs.Content[index+1]
+		indexInLHS := findKeyInMap(result, key)
+		if indexInLHS < 0 {
+			result.AddKeyValueChild(key, value)
+			continue
+		}
+		resultKey := result.Content[indexInLHS]
+		mergeComments(resultKey, resultKey.Copy(), key)
+
+		merged, err := mergeNodes(result.Content[indexInLHS+1], value, append(path[:len(path):len(path)], key.Value), strategies)
+		if err != nil {
+			return nil, err
+		}
+		replaceChild(result, indexInLHS+1, merged)
+	}
+	return result, nil
+}
+
+func getMergeKey(node *CandidateNode, keyName string) *CandidateNode {
+	if node.Kind != MappingNode {
+		return nil
+	}
+	for index := 0; index < len(node.Content); index = index + 2 {
+		if node.Content[index].Value == keyName {
+			return node.Content[index+1]
+		}
+	}
+	return nil
+}
+
+func mergeArrays(lhs *CandidateNode, rhs *CandidateNode, path []string, strategies mergeStrategies) (*CandidateNode, error) {
+	strategy := strategies.strategyFor(path)
+	log.Debugf("merging arrays at %v with strategy %v", strings.Join(path, "."), strategy)
+
+	switch strategy {
+	case mergeStrategyReplace:
+		return lhs.CopyAsReplacement(rhs), nil
+	case mergeStrategyAppend:
+		result := lhs.Copy()
+		result.AddChildren(rhs.Content)
+		return result, nil
+	case mergeStrategyPrepend:
+		result := lhs.CopyWithoutContent()
+		result.AddChildren(rhs.Content)
+		result.AddChildren(lhs.Content)
+		return result, nil
+	case mergeStrategyUnique:
+		result := lhs.Copy()
+		existing := newNodeLookup()
+		for _, child := range lhs.Content {
+			existing.add(child, child)
+		}
+		for _, child := range rhs.Content {
+			if !existing.contains(child) {
+				existing.add(child, child)
+				result.AddChild(child)
+			}
+		}
+		return result, nil
+	case mergeStrategyIndex:
+		result := lhs.Copy()
+		for index, child := range rhs.Content {
+			if index >= len(result.Content) {
+				result.AddChild(child)
+				continue
+			}
+			merged, err := mergeNodes(result.Content[index], child, path, strategies)
+			if err != nil {
+				return nil, err
+			}
+			replaceChild(result, index, merged)
+		}
+		return result, nil
+	}
+
+	// merge the entries with the same key, and append the rest
+	keyName := strings.TrimPrefix(strategy, mergeStrategyKeyPrefix)
+	result := lhs.Copy()
+	entries := newNodeLookup()
+	positions := make(map[*CandidateNode]int)
+	for index, child := range result.Content {
+		if key := getMergeKey(child, keyName); key != nil {
+			entries.add(key, child)
+			positions[child] = index
+		}
+	}
+	for _, child := range rhs.Content {
+		var matches []*CandidateNode
+		if key := getMergeKey(child, keyName); key != nil {
+			matches = entries.get(key)
+		}
+		if len(matches) == 0 {
+			result.AddChild(child)
+			continue
+		}
+		for _, match := range matches {
+			index := positions[match]
+			merged, err := mergeNodes(result.Content[index], child, path, strategies)
+			if err != nil {
+				return nil, err
+			}
+			replaceChild(result, index, merged)
+		}
+	}
+	return result, nil
+}
+
+// merge(overlay) and merge(overlay; strategies) deep merge the overlay into the current node.
+// Strategies is a map of paths to how the arrays at those paths are merged.
+func mergeOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("merge")
+	overlayExp := expressionNode.RHS
+	var strategiesExp *ExpressionNode
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		overlayExp = expressionNode.RHS.LHS
+		strategiesExp = expressionNode.RHS.RHS
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		candidateContext := context.SingleReadonlyChildContext(candidate)
+
+		var strategiesNode *CandidateNode
+		if strategiesExp != nil {
+			strategiesResult, err := d.GetMatchingNodes(candidateContext, strategiesExp)
+			if err != nil {
+				return Context{}, err
+			}
+			if strategiesResult.MatchingNodes.Len() > 0 {
+				strategiesNode = strategiesResult.MatchingNodes.Front().Value.(*CandidateNode)
+			}
+		}
+		strategies, err := parseMergeStrategies(strategiesNode)
+		if err != nil {
+			return Context{}, err
+		}
+
+		overlays, err := d.GetMatchingNodes(candidateContext, overlayExp)
+		if err != nil {
+			return Context{}, err
+		}
+		for overlayEl := overlays.MatchingNodes.Front(); overlayEl != nil; overlayEl = overlayEl.Next() {
+			overlay := overlayEl.Value.(*CandidateNode)
+			// like *, merging null leaves the node as it is, while null values within the overlay replace
+			if overlay.Tag == "!!null" {
+				results.PushBack(candidate.Copy())
+				continue
+			}
+			merged, err := mergeNodes(candidate, overlay, make([]string, 0), strategies)
+			if err != nil {
+				return Context{}, err
+			}
+			results.PushBack(merged)
+		}
+	}
+	return context.ChildContext(results), nil
+}
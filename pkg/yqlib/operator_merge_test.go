@@ -0,0 +1,154 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var mergeStrategyDocument = `spec:
+  containers:
+    - name: app
+      image: app:1.0
+      env:
+        - {name: A, value: "1"}
+    - name: sidecar # keep me
+      image: sidecar:1.0
+overlay:
+  spec:
+    containers:
+      - name: logger
+        image: logger:1.0
+      - name: app
+        image: app:2.0
+        env:
+          - {name: B, value: "2"}
+`
+
+var mergeOperatorScenarios = []expressionScenario{
+	{
+		description:    "Merge",
+		subdescription: "Without strategies, this is a deep merge like `*`, where arrays are replaced.",
+		document:       `{a: {b: 1, c: [1, 2]}, overlay: {a: {c: [3], d: 4}}}`,
+		expression:     `.overlay as $o | del(.overlay) | merge($o)`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: {b: 1, c: [3], d: 4}}\n",
+		},
+	},
+	{
+		description:    "Merge arrays by a key",
+		subdescription: "Containers and their env variables are merged by name, other entries are appended.",
+		document:       mergeStrategyDocument,
+		expression:     `.overlay as $o | del(.overlay) | merge($o; {"spec.containers": "key:name", "spec.containers.env": "key:name"})`,
+		expected: []string{
+			"D0, P[], (!!map)::spec:\n    containers:\n        - name: app\n          image: app:2.0\n          env:\n            - {name: A, value: \"1\"}\n            - {name: B, value: \"2\"}\n        - name: sidecar # keep me\n          image: sidecar:1.0\n        - name: logger\n          image: logger:1.0\n",
+		},
+	},
+	{
+		description:    "Merge arrays by a key name",
+		subdescription: "Any name that is not a strategy is short for `key:<name>`.",
+		document:       `{spec: {containers: [{name: app, image: "app:1.0"}]}, overlay: {spec: {containers: [{name: app, image: "app:2.0"}, {name: logger}]}}}`,
+		expression:     `.overlay as $overlay | del(.overlay) | merge($overlay; {"spec.containers": "name"})`,
+		expected: []string{
+			"D0, P[], (!!map)::{spec: {containers: [{name: app, image: \"app:2.0\"}, {name: logger}]}}\n",
+		},
+	},
+	{
+		description:    "Merge with wildcard paths",
+		subdescription: "`*` matches any key.",
+		document:       `{a: {list: [{id: 1, v: x}]}, b: {list: [{id: 2, v: y}]}, overlay: {a: {list: [{id: 1, v: z}]}, b: {list: [{id: 2, w: z}]}}}`,
+		expression:     `.overlay as $o | del(.overlay) | merge($o; {"*.list": "key:id"})`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: {list: [{id: 1, v: z}]}, b: {list: [{id: 2, v: y, w: z}]}}\n",
+		},
+	},
+	{
+		description:    "Append, prepend and unique-append",
+		subdescription: "Arrays can also be appended to, prepended to, or only have new entries appended.",
+		document:       `{a: [1, 2], b: [1, 2], c: [1, 2], overlay: {a: [2, 3], b: [2, 3], c: [2, 3]}}`,
+		expression:     `.overlay as $o | del(.overlay) | merge($o; {"a": "append", "b": "prepend", "c": "unique-append"})`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: [1, 2, 2, 3], b: [2, 3, 1, 2], c: [1, 2, 3]}\n",
+		},
+	},
+	{
+		description:    "Merge arrays by index",
+		subdescription: "Like `*d`, entries with the same index are deeply merged.",
+		document:       `{a: [{b: 1}, {c: 2}], overlay: {a: [{d: 3}]}}`,
+		expression:     `.overlay as $o | del(.overlay) | merge($o; {"a": "index"})`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: [{b: 1, d: 3}, {c: 2}]}\n",
+		},
+	},
+	{
+		description:    "Merge keeps comments from both sides",
+		subdescription: "Comments of the current node are kept, falling back to those of the overlay.",
+		document:       "a: 1 # original\nb: 2\noverlay:\n  a: 3 # ignored\n  # new comment\n  b: 4 # overlay\n",
+		expression:     `.overlay as $o | del(.overlay) | merge($o)`,
+		expected: []string{
+			"D0, P[], (!!map)::a: 3 # original\n# new comment\nb: 4 # overlay\n",
+		},
+	},
+	{
+		description: "Merge entries without the key are appended",
+		skipDoc:     true,
+		document:    `{a: [{name: x, v: 1}, cat], overlay: {a: [{v: 2}, {name: x, w: 3}]}}`,
+		expression:  `.overlay as $o | del(.overlay) | merge($o; {"a": "key:name"})`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: [{name: x, v: 1, w: 3}, cat, {v: 2}]}\n",
+		},
+	},
+	{
+		description: "Merge with a null overlay",
+		skipDoc:     true,
+		document:    `{a: 1}`,
+		expression:  `merge(null)`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: 1}\n",
+		},
+	},
+	{
+		description:    "Merge null values",
+		subdescription: "Like `*`, null values in the overlay replace the current values.",
+		document:       `{a: 1, b: {c: 2}}`,
+		expression:     `merge({"a": null, "b": {"c": null}})`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: null, b: {c: null}}\n",
+		},
+	},
+	{
+		description: "Merge in place",
+		skipDoc:     true,
+		document:    `{a: {b: [1]}, c: {b: [2]}}`,
+		expression:  `.c as $c | .a |= merge($c; {"b": "append"})`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: {b: [1, 2]}, c: {b: [2]}}\n",
+		},
+	},
+	{
+		description:   "Merge strategies must be a map",
+		skipDoc:       true,
+		document:      `{a: 1}`,
+		expression:    `merge({}; [1])`,
+		expectedError: "merge strategies must be a map of paths to strategies, but got !!seq",
+	},
+	{
+		description:   "Merge strategies must be strings",
+		skipDoc:       true,
+		document:      `{a: 1}`,
+		expression:    `merge({}; {"a": null})`,
+		expectedError: "merge strategy for 'a' must be a string, but got !!null",
+	},
+	{
+		description:   "Merge key strategies need a key",
+		skipDoc:       true,
+		document:      `{a: [1]}`,
+		expression:    `merge({}; {"a": "key:"})`,
+		expectedError: "merge strategy for 'a' is missing the key name after 'key:'",
+	},
+}
+
+func TestMergeOperatorScenarios(t *testing.T) {
+	for _, tt := range mergeOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "merge-strategies", mergeOperatorScenarios)
+}
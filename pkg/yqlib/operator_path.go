@@ -3,6 +3,7 @@ package yqlib
 import (
 	"container/list"
 	"fmt"
+	"strconv"
 )
 
 func createPathNodeFor(pathElement interface{}) *CandidateNode {
@@ -170,3 +171,138 @@ func getPathOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (
 
 	return context.ChildContext(results), nil
 }
+
+// getValueAtPath walks the path from the given node, returning nil if
+// any part of the path does not exist.
+func getValueAtPath(node *CandidateNode, path []interface{}) (*CandidateNode, error) {
+	for _, pathElement := range path {
+		if node.Kind == AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		switch pathElement := pathElement.(type) {
+		case string:
+			if node.Kind == ScalarNode && node.Tag == "!!null" {
+				return nil, nil
+			} else if node.Kind != MappingNode {
+				return nil, fmt.Errorf("GETPATH: cannot index %v with '%v'", node.Tag, pathElement)
+			}
+			index := findKeyInMap(node, createStringScalarNode(pathElement))
+			if index == -1 {
+				return nil, nil
+			}
+			node = node.Content[index+1]
+		case int:
+			if node.Kind == ScalarNode && node.Tag == "!!null" {
+				return nil, nil
+			} else if node.Kind != SequenceNode {
+				return nil, fmt.Errorf("GETPATH: cannot index %v with %v", node.Tag, pathElement)
+			}
+			if pathElement < 0 {
+				pathElement = len(node.Content) + pathElement
+			}
+			if pathElement < 0 || pathElement >= len(node.Content) {
+				return nil, nil
+			}
+			node = node.Content[pathElement]
+		}
+	}
+	return node, nil
+}
+
+// GETPATH(pathArray)
+func getValueAtPathOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("GetValueAtPath")
+
+	var results = list.New()
+
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+
+		pathsContext, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+
+		for pathEl := pathsContext.MatchingNodes.Front(); pathEl != nil; pathEl = pathEl.Next() {
+			path, err := getPathArrayFromNode("GETPATH", pathEl.Value.(*CandidateNode))
+			if err != nil {
+				return Context{}, err
+			}
+			value, err := getValueAtPath(candidate, path)
+			if err != nil {
+				return Context{}, err
+			}
+			if value == nil {
+				value = createScalarNode(nil, "null")
+			}
+			results.PushBack(value)
+		}
+	}
+
+	return context.ChildContext(results), nil
+}
+
+// findOriginalContainers returns the maps and arrays along the path that the value
+// was copied from, as far up as they are still at that path.
+func findOriginalContainers(value *CandidateNode, path []interface{}) []*CandidateNode {
+	originals := make([]*CandidateNode, len(path))
+	node := value
+	if value.origin != nil {
+		node = value.origin
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		parent := node.Parent
+		if parent == nil || node.Key == nil {
+			break
+		}
+		switch pathElement := path[i].(type) {
+		case string:
+			if parent.Kind != MappingNode || node.Key.Value != pathElement {
+				return originals
+			}
+		case int:
+			if parent.Kind != SequenceNode || node.Key.Value != strconv.Itoa(pathElement) {
+				return originals
+			}
+		}
+		originals[i] = parent
+		node = parent
+	}
+	return originals
+}
+
+// FROMPATHS
+func fromPathsOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("FromPaths")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		if candidate.Kind != SequenceNode {
+			return Context{}, fmt.Errorf("FROMPATHS: expected an array of [path, value] pairs, but got %v", candidate.Tag)
+		}
+
+		var result *CandidateNode
+		for _, pair := range candidate.Content {
+			if pair.Kind != SequenceNode || len(pair.Content) != 2 {
+				return Context{}, fmt.Errorf("FROMPATHS: expected a [path, value] pair, but got %v", pair.Tag)
+			}
+			path, err := getPathArrayFromNode("FROMPATHS", pair.Content[0])
+			if err != nil {
+				return Context{}, err
+			}
+			for _, pathElement := range path {
+				if index, ok := pathElement.(int); ok && index < 0 {
+					return Context{}, fmt.Errorf("FROMPATHS: array index %v in path must not be negative", index)
+				}
+			}
+			value := pair.Content[1]
+			result = setValueAtPath(result, path, value, findOriginalContainers(value, path))
+		}
+		if result == nil {
+			result = createScalarNode(nil, "null")
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
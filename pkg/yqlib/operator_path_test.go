@@ -155,6 +155,142 @@ var pathOperatorScenarios = []expressionScenario{
 		expression:     `delpaths(["a", 0])`,
 		expectedError:  "DELPATHS: expected entry [0] to be a sequence, but its a !!str. Note that delpaths takes an array of path arrays, e.g. [[\"a\", \"b\"]]",
 	},
+	{
+		description: "Get path",
+		document:    `{a: {b: [cat, dog]}}`,
+		expression:  `getpath(["a", "b", 1])`,
+		expected: []string{
+			"D0, P[a b 1], (!!str)::dog\n",
+		},
+	},
+	{
+		description:    "Get missing path",
+		subdescription: "Paths that do not exist return null.",
+		document:       `{a: {b: cat}}`,
+		expression:     `getpath(["a", "x", "y"])`,
+		expected: []string{
+			"D0, P[], (!!null)::null\n",
+		},
+	},
+	{
+		description: "Get path with negative index",
+		skipDoc:     true,
+		document:    `{a: [cat, dog]}`,
+		expression:  `getpath(["a", -1])`,
+		expected: []string{
+			"D0, P[a 1], (!!str)::dog\n",
+		},
+	},
+	{
+		description:   "Get path - wrong type",
+		skipDoc:       true,
+		document:      `{a: cat}`,
+		expression:    `getpath(["a", "b"])`,
+		expectedError: "GETPATH: cannot index !!str with 'b'",
+	},
+	{
+		description: "Get all paths",
+		document:    `{a: {b: cat}, c: [1]}`,
+		expression:  `[paths]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - a\n- - a\n  - b\n- - c\n- - c\n  - 0\n",
+		},
+	},
+	{
+		description: "Get paths relative to the current node",
+		skipDoc:     true,
+		document:    `{a: {b: cat}}`,
+		expression:  `.a | [paths]`,
+		expected: []string{
+			"D0, P[a], (!!seq)::- - b\n",
+		},
+	},
+	{
+		description:    "Get paths matching a filter",
+		subdescription: "The filter is run against the value at each path.",
+		document:       `{a: {b: cat}, c: [1, dog]}`,
+		expression:     `[paths(tag == "!!str")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - a\n  - b\n- - c\n  - 1\n",
+		},
+	},
+	{
+		description: "Get leaf paths",
+		document:    `{a: {b: cat}, c: [1, null]}`,
+		expression:  `[leaf_paths]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - a\n  - b\n- - c\n  - 0\n- - c\n  - 1\n",
+		},
+	},
+	{
+		description:    "Convert to path value pairs",
+		subdescription: "Empty maps and arrays are included as values, so that they survive a round trip through `from_paths`.",
+		document:       `{a: {b: cat}, c: [1, 2], d: {}}`,
+		expression:     `to_paths`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - - a\n    - b\n  - cat\n- - - c\n    - 0\n  - 1\n- - - c\n    - 1\n  - 2\n- - - d\n  - {}\n",
+		},
+	},
+	{
+		description:    "Convert from path value pairs",
+		subdescription: "Comments and styles on the values are kept.",
+		document:       "a:\n  b: cat # meow\n  c: \"dog\"\n",
+		expression:     `to_paths | map(select(.[1] != "dog")) | from_paths`,
+		expected: []string{
+			"D0, P[], (!!map)::a:\n    b: cat # meow\n",
+		},
+	},
+	{
+		description:           "Round trip through path value pairs",
+		subdescription:        "Maps and arrays that are still at the same path take their comments and styles from the originals.",
+		dontFormatInputForDoc: true,
+		document:              "# head\na: 1 # one\nb: {y: 2} # flow\nc: [1, 2]",
+		expression:            `to_paths | map(select(.[1] != 2)) | from_paths`,
+		expected: []string{
+			"D0, P[], (!!map)::# head\na: 1 # one\nc: [1]\n",
+		},
+	},
+	{
+		description: "Round trip keeps nested maps and arrays",
+		skipDoc:     true,
+		document:    "a:\n  # about b\n  b: [x, {c: y}] # line\n",
+		expression:  `.a | to_paths | from_paths`,
+		expected: []string{
+			"D0, P[], (!!map)::# about b\nb: [x, {c: y}] # line\n",
+		},
+	},
+	{
+		description: "Moved values keep the styles of the maps and arrays they are still in",
+		skipDoc:     true,
+		document:    "a: {b: 1}\n",
+		expression:  `to_paths | map(.[0] |= ["c"] + .) | from_paths`,
+		expected: []string{
+			"D0, P[], (!!map)::c:\n    a: {b: 1}\n",
+		},
+	},
+	{
+		description: "Convert from path value pairs with gaps",
+		skipDoc:     true,
+		expression:  `[[["a", 1], "x"]] | from_paths`,
+		expected: []string{
+			"D0, P[], (!!map)::a:\n    - null\n    - x\n",
+		},
+	},
+	{
+		description:   "Convert from path value pairs with a negative index",
+		skipDoc:       true,
+		expression:    `[[[-1], "x"]] | from_paths`,
+		expectedError: "FROMPATHS: array index -1 in path must not be negative",
+	},
+	{
+		description: "Convert scalar to path value pairs",
+		skipDoc:     true,
+		document:    `cat`,
+		expression:  `to_paths | from_paths`,
+		expected: []string{
+			"D0, P[], (!!str)::cat\n",
+		},
+	},
 }
 
 func TestPathOperatorsScenarios(t *testing.T) {
@@ -45,6 +45,88 @@ func pickSequence(original *CandidateNode, indices *CandidateNode) (*CandidateNo
 	return newNode, nil
 }
 
+// pickPath copies the node at the given path from the original into the picked node,
+// creating the parent maps and arrays (with the original's styles) as needed.
+func pickPath(original *CandidateNode, picked *CandidateNode, path []interface{}) {
+	if len(path) == 0 {
+		return
+	}
+	isLast := len(path) == 1
+
+	switch pathElement := path[0].(type) {
+	case string:
+		if original.Kind != MappingNode || picked.Kind != MappingNode {
+			return
+		}
+		key := createStringScalarNode(pathElement)
+		indexInMap := findKeyInMap(original, key)
+		if indexInMap == -1 {
+			return
+		}
+		originalChild := original.Content[indexInMap+1]
+		pickedIndex := findKeyInMap(picked, key)
+		if pickedIndex == -1 {
+			pickedValue := originalChild
+			if !isLast {
+				pickedValue = originalChild.CopyWithoutContent()
+			}
+			_, pickedChild := picked.AddKeyValueChild(original.Content[indexInMap], pickedValue)
+			pickPath(originalChild, pickedChild, path[1:])
+		} else if isLast {
+			picked.Content[pickedIndex+1].UpdateFrom(originalChild, assignPreferences{})
+		} else {
+			pickPath(originalChild, picked.Content[pickedIndex+1], path[1:])
+		}
+	case int:
+		if original.Kind != SequenceNode || picked.Kind != SequenceNode {
+			return
+		}
+		if pathElement < 0 {
+			pathElement = len(original.Content) + pathElement
+		}
+		if pathElement < 0 || pathElement >= len(original.Content) {
+			return
+		}
+		originalChild := original.Content[pathElement]
+		for len(picked.Content) <= pathElement {
+			picked.AddChild(createScalarNode(nil, "null"))
+		}
+		pickedChild := picked.Content[pathElement]
+		if isLast {
+			pickedChild.UpdateFrom(originalChild, assignPreferences{})
+			return
+		}
+		if pickedChild.Kind == ScalarNode && pickedChild.Tag == "!!null" {
+			pickedChild.UpdateFrom(originalChild.CopyWithoutContent(), assignPreferences{})
+		}
+		pickPath(originalChild, pickedChild, path[1:])
+	}
+}
+
+func pickPaths(original *CandidateNode, paths *CandidateNode) (*CandidateNode, error) {
+	newNode := original.CopyWithoutContent()
+	for _, pathNode := range paths.Content {
+		path, err := getPathArrayFromNode("PICK", pathNode)
+		if err != nil {
+			return nil, err
+		}
+		pickPath(original, newNode, path)
+	}
+	return newNode, nil
+}
+
+func isPathList(node *CandidateNode) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for _, child := range node.Content {
+		if child.Kind != SequenceNode {
+			return false
+		}
+	}
+	return true
+}
+
 func pickOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
 	log.Debugf("Pick")
 
@@ -64,10 +146,15 @@ func pickOperator(d *dataTreeNavigator, context Context, expressionNode *Express
 		node := el.Value.(*CandidateNode)
 
 		var replacement *CandidateNode
-		switch node.Kind {
-		case MappingNode:
+		switch {
+		case isPathList(indicesToPick) && (node.Kind == MappingNode || node.Kind == SequenceNode):
+			replacement, err = pickPaths(node, indicesToPick)
+			if err != nil {
+				return Context{}, err
+			}
+		case node.Kind == MappingNode:
 			replacement = pickMap(node, indicesToPick)
-		case SequenceNode:
+		case node.Kind == SequenceNode:
 			replacement, err = pickSequence(node, indicesToPick)
 			if err != nil {
 				return Context{}, err
@@ -69,6 +69,33 @@ var pickOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!seq)::# abc\n[lion, cat]\n# xyz\n",
 		},
 	},
+	{
+		description:    "Pick paths",
+		subdescription: "When given a list of path arrays, pick keeps the nodes at those paths (along with their comments and styles). Non existent paths are skipped.",
+		document:       "a:\n  b: cat # meow\n  c: dog\nd: [1, 2, 3]\ne: frog\n",
+		expression:     `pick([["a", "b"], ["d", 1], ["x", "y"]])`,
+		expected: []string{
+			"D0, P[], (!!map)::a:\n    b: cat # meow\nd: [null, 2]\n",
+		},
+	},
+	{
+		description: "Pick overlapping paths",
+		skipDoc:     true,
+		document:    `{a: {b: cat, c: dog}}`,
+		expression:  `pick([["a", "b"], ["a"]])`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: {b: cat, c: dog}}\n",
+		},
+	},
+	{
+		description: "Pick paths from array",
+		skipDoc:     true,
+		document:    `[{a: cat, b: dog}, {a: frog}]`,
+		expression:  `pick([[1, "a"]])`,
+		expected: []string{
+			"D0, P[], (!!seq)::[null, {a: frog}]\n",
+		},
+	},
 }
 
 func TestPickOperatorScenarios(t *testing.T) {
@@ -1,7 +1,6 @@
 package yqlib
 
 import (
-	"container/list"
 	"fmt"
 )
 
@@ -28,8 +27,6 @@ func reduceOperator(d *dataTreeNavigator, context Context, expressionNode *Expre
 		return Context{}, err
 	}
 
-	variableName := expressionNode.LHS.RHS.Operation.StringValue
-
 	initExp := expressionNode.RHS.LHS
 
 	accum, err := d.GetMatchingNodes(context, initExp)
@@ -37,15 +34,13 @@ func reduceOperator(d *dataTreeNavigator, context Context, expressionNode *Expre
 		return Context{}, err
 	}
 
-	log.Debugf("with variable %v", variableName)
-
 	blockExp := expressionNode.RHS.RHS
 	for el := array.MatchingNodes.Front(); el != nil; el = el.Next() {
 		candidate := el.Value.(*CandidateNode)
 		log.Debugf("REDUCING WITH %v", NodeToString(candidate))
-		l := list.New()
-		l.PushBack(candidate)
-		accum.SetVariable(variableName, l)
+		if err := bindVariable(d, &accum, expressionNode.LHS.RHS, candidate); err != nil {
+			return Context{}, err
+		}
 
 		accum, err = d.GetMatchingNodes(accum, blockExp)
 		if err != nil {
@@ -0,0 +1,217 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+)
+
+func getPositiveIntParameter(funcName string, d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (int, error) {
+	node, number, err := getMathParameter(funcName, d, context, expressionNode)
+	if err != nil {
+		return 0, err
+	} else if !number.isInt || number.intValue <= 0 {
+		return 0, fmt.Errorf("%v: expected a positive int but got %v", funcName, node.Value)
+	}
+	return int(number.intValue), nil
+}
+
+// newSequenceOf creates an array of the nodes, in the style of the array they came from.
+func newSequenceOf(nodes []*CandidateNode, style Style) *CandidateNode {
+	sequence := &CandidateNode{Kind: SequenceNode, Tag: "!!seq", Style: style}
+	sequence.AddChildren(nodes)
+	return sequence
+}
+
+// windows splits the array into arrays of the given size, starting every step entries.
+func windows(funcName string, candidate *CandidateNode, size int, step int, partial bool) (*CandidateNode, error) {
+	if candidate.Kind != SequenceNode {
+		return nil, fmt.Errorf("%v only works on arrays, but got %v", funcName, candidate.Tag)
+	}
+	result := candidate.CreateReplacementWithComments(SequenceNode, "!!seq", 0)
+	for start := 0; start < len(candidate.Content); start = start + step {
+		end := start + size
+		if end > len(candidate.Content) {
+			if !partial {
+				break
+			}
+			end = len(candidate.Content)
+		}
+		result.AddChild(newSequenceOf(candidate.Content[start:end], candidate.Style))
+	}
+	return result, nil
+}
+
+// chunk(n) splits the array into arrays of n entries, the last may be shorter.
+func chunkOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("chunk")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		size, err := getPositiveIntParameter("chunk", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+		result, err := windows("chunk", candidate, size, size, true)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
+
+// window(n) and window(n; step) return the sliding windows of n entries, moving by step (default 1).
+// Only full windows are returned.
+func windowOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("window")
+	sizeExp := expressionNode.RHS
+	var stepExp *ExpressionNode
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		sizeExp = expressionNode.RHS.LHS
+		stepExp = expressionNode.RHS.RHS
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		candidateContext := context.SingleReadonlyChildContext(candidate)
+		size, err := getPositiveIntParameter("window", d, candidateContext, sizeExp)
+		if err != nil {
+			return Context{}, err
+		}
+		step := 1
+		if stepExp != nil {
+			step, err = getPositiveIntParameter("window", d, candidateContext, stepExp)
+			if err != nil {
+				return Context{}, err
+			}
+		}
+		result, err := windows("window", candidate, size, step, false)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
+
+func getArrayOfArrays(funcName string, candidate *CandidateNode) error {
+	if candidate.Kind != SequenceNode {
+		return fmt.Errorf("%v only works on an array of arrays, but got %v", funcName, candidate.Tag)
+	}
+	for _, child := range candidate.Content {
+		if child.Kind != SequenceNode {
+			return fmt.Errorf("%v only works on an array of arrays, but got %v (%v)", funcName, child.Tag, child.GetNicePath())
+		}
+	}
+	return nil
+}
+
+// reshapeRows returns the nth entries of each array, stopping at the shortest array for zip,
+// or padding with nulls to the longest array for transpose.
+func reshapeRows(funcName string, padded bool) operatorHandler {
+	return func(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			candidate := el.Value.(*CandidateNode)
+			if err := getArrayOfArrays(funcName, candidate); err != nil {
+				return Context{}, err
+			}
+
+			length := 0
+			style := candidate.Style
+			for index, child := range candidate.Content {
+				if index == 0 {
+					style = child.Style
+				}
+				if index == 0 || (padded && len(child.Content) > length) || (!padded && len(child.Content) < length) {
+					length = len(child.Content)
+				}
+			}
+
+			result := candidate.CreateReplacementWithComments(SequenceNode, "!!seq", 0)
+			for index := 0; index < length; index++ {
+				row := make([]*CandidateNode, 0, len(candidate.Content))
+				for _, child := range candidate.Content {
+					if index < len(child.Content) {
+						row = append(row, child.Content[index])
+					} else {
+						row = append(row, createScalarNode(nil, "null"))
+					}
+				}
+				result.AddChild(newSequenceOf(row, style))
+			}
+			results.PushBack(result)
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+var zipOperator = reshapeRows("zip", false)
+var transposeOperator = reshapeRows("transpose", true)
+
+func addCombinations(arrays []*CandidateNode, prefix []*CandidateNode, style Style, results *list.List) {
+	if len(prefix) == len(arrays) {
+		results.PushBack(newSequenceOf(prefix, style))
+		return
+	}
+	for _, child := range arrays[len(prefix)].Content {
+		addCombinations(arrays, append(prefix[:len(prefix):len(prefix)], child), style, results)
+	}
+}
+
+// combinations returns each combination of one entry from each of the arrays,
+// combinations(n) returns each combination of n entries from the array.
+func combinationsOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("combinations")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		arrays := candidate.Content
+		if expressionNode.RHS != nil {
+			if candidate.Kind != SequenceNode {
+				return Context{}, fmt.Errorf("combinations only works on arrays, but got %v", candidate.Tag)
+			}
+			_, number, err := getMathParameter("combinations", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+			if err != nil {
+				return Context{}, err
+			} else if !number.isInt || number.intValue < 0 {
+				return Context{}, fmt.Errorf("combinations: expected a non negative int but got %v", formatFloatValue(number.floatValue))
+			}
+			arrays = make([]*CandidateNode, number.intValue)
+			for index := range arrays {
+				arrays[index] = candidate
+			}
+		} else if err := getArrayOfArrays("combinations", candidate); err != nil {
+			return Context{}, err
+		}
+		style := candidate.Style
+		if len(arrays) > 0 {
+			style = arrays[0].Style
+		}
+		addCombinations(arrays, make([]*CandidateNode, 0, len(arrays)), style, results)
+	}
+	return context.ChildContext(results), nil
+}
+
+// enumerate returns the entries of the array as {index, value} maps.
+func enumerateOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("enumerate")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		if candidate.Kind != SequenceNode {
+			return Context{}, fmt.Errorf("enumerate only works on arrays, but got %v", candidate.Tag)
+		}
+		result := candidate.CreateReplacementWithComments(SequenceNode, "!!seq", 0)
+		for index, child := range candidate.Content {
+			entry := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+			entry.AddKeyValueChild(createStringScalarNode("index"), createScalarNode(index, fmt.Sprintf("%v", index)))
+			entry.AddKeyValueChild(createStringScalarNode("value"), child)
+			result.AddChild(entry)
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
@@ -0,0 +1,138 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var reshapeOperatorScenarios = []expressionScenario{
+	{
+		description:    "Chunk",
+		subdescription: "Splits the array into arrays of the given size, for instance to roll out hosts in waves. The last chunk may be shorter.",
+		document:       `[host1, host2, host3, host4, host5]`,
+		expression:     `chunk(2)`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [host1, host2]\n- [host3, host4]\n- [host5]\n",
+		},
+	},
+	{
+		description:   "Chunk requires a positive size",
+		skipDoc:       true,
+		document:      `[1, 2]`,
+		expression:    `chunk(0)`,
+		expectedError: "chunk: expected a positive int but got 0",
+	},
+	{
+		description:   "Chunk of a map",
+		skipDoc:       true,
+		document:      `{a: 1}`,
+		expression:    `chunk(1)`,
+		expectedError: "chunk only works on arrays, but got !!map",
+	},
+	{
+		description:    "Window",
+		subdescription: "Returns each sliding window of the given size. Only full windows are returned.",
+		document:       `[1, 2, 3, 4]`,
+		expression:     `window(3)`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [1, 2, 3]\n- [2, 3, 4]\n",
+		},
+	},
+	{
+		description:    "Window with a step",
+		subdescription: "The second parameter sets how far each window moves along the array.",
+		document:       `[1, 2, 3, 4, 5, 6]`,
+		expression:     `window(2; 3)`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [1, 2]\n- [4, 5]\n",
+		},
+	},
+	{
+		description: "Window larger than the array",
+		skipDoc:     true,
+		document:    `[1, 2]`,
+		expression:  `window(3)`,
+		expected: []string{
+			"D0, P[], (!!seq)::[]\n",
+		},
+	},
+	{
+		description:    "Zip",
+		subdescription: "Pairs up the entries of an array of arrays, stopping at the shortest array.",
+		document:       `{hosts: [a, b, c], ports: [80, 443]}`,
+		expression:     `[.hosts, .ports] | zip`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [a, 80]\n- [b, 443]\n",
+		},
+	},
+	{
+		description:   "Zip requires arrays",
+		skipDoc:       true,
+		document:      `[[a], b]`,
+		expression:    `zip`,
+		expectedError: "zip only works on an array of arrays, but got !!str ([1])",
+	},
+	{
+		description:    "Transpose",
+		subdescription: "Like `zip`, but pads shorter arrays with null.",
+		document:       `[[a, b, c], [1, 2]]`,
+		expression:     `transpose`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [a, 1]\n- [b, 2]\n- [c, null]\n",
+		},
+	},
+	{
+		description: "Transpose an empty array",
+		skipDoc:     true,
+		document:    `[]`,
+		expression:  `transpose`,
+		expected: []string{
+			"D0, P[], (!!seq)::[]\n",
+		},
+	},
+	{
+		description:    "Combinations",
+		subdescription: "Returns each combination of one entry from each of the arrays.",
+		document:       `[[a, b], [1, 2]]`,
+		expression:     `combinations`,
+		expected: []string{
+			"D0, P[], (!!seq)::[a, 1]\n",
+			"D0, P[], (!!seq)::[a, 2]\n",
+			"D0, P[], (!!seq)::[b, 1]\n",
+			"D0, P[], (!!seq)::[b, 2]\n",
+		},
+	},
+	{
+		description:    "Combinations of n entries",
+		subdescription: "Returns each combination of n entries from the array.",
+		document:       `[0, 1]`,
+		expression:     `[combinations(2)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- [0, 0]\n- [0, 1]\n- [1, 0]\n- [1, 1]\n",
+		},
+	},
+	{
+		description: "Combinations with an empty array",
+		skipDoc:     true,
+		document:    `[[a, b], []]`,
+		expression:  `[combinations]`,
+		expected: []string{
+			"D0, P[], (!!seq)::[]\n",
+		},
+	},
+	{
+		description:    "Enumerate",
+		subdescription: "Returns the entries of the array as `{index, value}` maps.",
+		document:       `[a, b]`,
+		expression:     `enumerate`,
+		expected: []string{
+			"D0, P[], (!!seq)::- index: 0\n  value: a\n- index: 1\n  value: b\n",
+		},
+	},
+}
+
+func TestReshapeOperatorScenarios(t *testing.T) {
+	for _, tt := range reshapeOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "reshape", reshapeOperatorScenarios)
+}
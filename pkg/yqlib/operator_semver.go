@@ -0,0 +1,388 @@
+package yqlib
+
+import (
+	"cmp"
+	"container/list"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type semver struct {
+	prefix     string
+	major      int64
+	minor      int64
+	patch      int64
+	prerelease string
+	build      string
+}
+
+var semverRegex = regexp.MustCompile(`^(v?)([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// semverConstraintRegex is like semverRegex, but parts of the version may be wildcards (x, X or *).
+var semverConstraintRegex = regexp.MustCompile(`^(\^|~|>=|<=|!=|==|=|>|<)?v?([0-9]+|[xX*])(?:\.([0-9]+|[xX*]))?(?:\.([0-9]+|[xX*]))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// parseSemver parses versions like 1.2.3, v1.2.3-rc.1+build.5 and 1.2 (which is 1.2.0).
+func parseSemver(value string) (semver, bool) {
+	match := semverRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return semver{}, false
+	}
+	version := semver{prefix: match[1], prerelease: match[5], build: match[6]}
+	parts := []*int64{&version.major, &version.minor, &version.patch}
+	for index, part := range match[2:5] {
+		if part == "" {
+			continue
+		}
+		number, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return semver{}, false
+		}
+		*parts[index] = number
+	}
+	return version, true
+}
+
+func (v semver) String() string {
+	result := fmt.Sprintf("%v%v.%v.%v", v.prefix, v.major, v.minor, v.patch)
+	if v.prerelease != "" {
+		result = result + "-" + v.prerelease
+	}
+	if v.build != "" {
+		result = result + "+" + v.build
+	}
+	return result
+}
+
+func comparePrereleases(lhs string, rhs string) int {
+	if lhs == rhs {
+		return 0
+	} else if lhs == "" {
+		return 1
+	} else if rhs == "" {
+		return -1
+	}
+	lhsIdentifiers := strings.Split(lhs, ".")
+	rhsIdentifiers := strings.Split(rhs, ".")
+	for index := 0; index < len(lhsIdentifiers) && index < len(rhsIdentifiers); index++ {
+		lhsNumber, lhsErr := strconv.ParseInt(lhsIdentifiers[index], 10, 64)
+		rhsNumber, rhsErr := strconv.ParseInt(rhsIdentifiers[index], 10, 64)
+		var result int
+		switch {
+		case lhsErr == nil && rhsErr == nil:
+			result = cmp.Compare(lhsNumber, rhsNumber)
+		case lhsErr == nil:
+			// numeric identifiers have lower precedence
+			result = -1
+		case rhsErr == nil:
+			result = 1
+		default:
+			result = strings.Compare(lhsIdentifiers[index], rhsIdentifiers[index])
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return cmp.Compare(len(lhsIdentifiers), len(rhsIdentifiers))
+}
+
+// compareSemvers compares by precedence, as per https://semver.org - build metadata is ignored.
+func compareSemvers(lhs semver, rhs semver) int {
+	if result := cmp.Compare(lhs.major, rhs.major); result != 0 {
+		return result
+	} else if result := cmp.Compare(lhs.minor, rhs.minor); result != 0 {
+		return result
+	} else if result := cmp.Compare(lhs.patch, rhs.patch); result != 0 {
+		return result
+	}
+	return comparePrereleases(lhs.prerelease, rhs.prerelease)
+}
+
+// compareSemverNodes compares the nodes as versions, returning false if either isn't a version.
+func compareSemverNodes(lhs *CandidateNode, rhs *CandidateNode) (int, bool) {
+	if lhs.Kind != ScalarNode || rhs.Kind != ScalarNode {
+		return 0, false
+	}
+	lhsVersion, lhsOk := parseSemver(lhs.Value)
+	rhsVersion, rhsOk := parseSemver(rhs.Value)
+	if !lhsOk || !rhsOk {
+		return 0, false
+	}
+	return compareSemvers(lhsVersion, rhsVersion), true
+}
+
+type semverComparator struct {
+	operator string
+	version  semver
+}
+
+func (c semverComparator) matches(version semver) bool {
+	result := compareSemvers(version, c.version)
+	switch c.operator {
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case "!=":
+		return result != 0
+	}
+	return result == 0
+}
+
+// parseSemverComparator expands a single constraint, like ^1.2 or >=1.2.3, into comparators.
+func parseSemverComparator(constraint string) ([]semverComparator, error) {
+	match := semverConstraintRegex.FindStringSubmatch(constraint)
+	if match == nil {
+		return nil, fmt.Errorf("invalid semver constraint '%v'", constraint)
+	}
+	operator := match[1]
+
+	// the number of parts given before any wildcard
+	given := 0
+	numbers := make([]int64, 3)
+	for index, part := range match[2:5] {
+		if part == "" || part == "x" || part == "X" || part == "*" {
+			break
+		}
+		number, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semver constraint '%v'", constraint)
+		}
+		numbers[index] = number
+		given++
+	}
+	lower := semver{major: numbers[0], minor: numbers[1], patch: numbers[2]}
+	if given == 3 {
+		lower.prerelease = match[5]
+	}
+
+	// the first version after the range given by a partial version
+	var upper semver
+	switch given {
+	case 1:
+		upper = semver{major: lower.major + 1}
+	case 2:
+		upper = semver{major: lower.major, minor: lower.minor + 1}
+	}
+
+	if given == 0 {
+		if operator == "<" || operator == ">" || operator == "!=" {
+			return nil, fmt.Errorf("invalid semver constraint '%v', nothing can match", constraint)
+		}
+		return []semverComparator{}, nil
+	}
+
+	switch operator {
+	case "^":
+		switch {
+		case lower.major > 0 || given == 1:
+			upper = semver{major: lower.major + 1}
+		case lower.minor > 0 || given == 2:
+			upper = semver{minor: lower.minor + 1}
+		default:
+			upper = semver{patch: lower.patch + 1}
+		}
+		return []semverComparator{{">=", lower}, {"<", upper}}, nil
+	case "~":
+		if given == 1 {
+			upper = semver{major: lower.major + 1}
+		} else {
+			upper = semver{major: lower.major, minor: lower.minor + 1}
+		}
+		return []semverComparator{{">=", lower}, {"<", upper}}, nil
+	case ">", "<=":
+		if given == 3 {
+			return []semverComparator{{operator, lower}}, nil
+		} else if operator == ">" {
+			return []semverComparator{{">=", upper}}, nil
+		}
+		return []semverComparator{{"<", upper}}, nil
+	case ">=", "<":
+		return []semverComparator{{operator, lower}}, nil
+	case "!=":
+		if given != 3 {
+			return nil, fmt.Errorf("invalid semver constraint '%v', != needs a full version", constraint)
+		}
+		return []semverComparator{{operator, lower}}, nil
+	}
+	if given == 3 {
+		return []semverComparator{{"=", lower}}, nil
+	}
+	return []semverComparator{{">=", lower}, {"<", upper}}, nil
+}
+
+// parseSemverConstraints parses constraints like "^1.2", ">=1.2.3 <2" and "1.x || 2.x".
+// Comparators separated by spaces or commas must all match, and one of the || groups must match.
+func parseSemverConstraints(constraints string) ([][]semverComparator, error) {
+	groups := make([][]semverComparator, 0)
+	for _, group := range strings.Split(constraints, "||") {
+		comparators := make([]semverComparator, 0)
+		fields := strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' })
+		for index := 0; index < len(fields); index++ {
+			field := fields[index]
+			// allow a space between the operator and the version, e.g. ">= 1.2"
+			if strings.Trim(field, "^~<>=!") == "" && index+1 < len(fields) {
+				index++
+				field = field + fields[index]
+			}
+			parsed, err := parseSemverComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, parsed...)
+		}
+		groups = append(groups, comparators)
+	}
+	return groups, nil
+}
+
+// semverSatisfies checks the version against the constraints. Like most package managers,
+// prereleases only match if a comparator in the group is a prerelease of the same version.
+func semverSatisfies(version semver, groups [][]semverComparator) bool {
+	for _, group := range groups {
+		matches := true
+		prereleaseAllowed := version.prerelease == ""
+		for _, comparator := range group {
+			matches = matches && comparator.matches(version)
+			if comparator.version.prerelease != "" && comparator.version.major == version.major &&
+				comparator.version.minor == version.minor && comparator.version.patch == version.patch {
+				prereleaseAllowed = true
+			}
+		}
+		if matches && prereleaseAllowed {
+			return true
+		}
+	}
+	return false
+}
+
+func getSemver(funcName string, candidate *CandidateNode) (semver, error) {
+	version, ok := parseSemver(candidate.Value)
+	if candidate.Kind != ScalarNode || !ok {
+		return semver{}, fmt.Errorf("%v: '%v' (%v) is not a valid semantic version", funcName, candidate.Value, candidate.GetNicePath())
+	}
+	return version, nil
+}
+
+func semverParseOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("SemverParse")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		version, err := getSemver("semver_parse", candidate)
+		if err != nil {
+			return Context{}, err
+		}
+
+		node := candidate.CreateReplacement(MappingNode, "!!map", "")
+		for _, part := range []struct {
+			key   string
+			value int64
+		}{{"major", version.major}, {"minor", version.minor}, {"patch", version.patch}} {
+			node.AddKeyValueChild(createScalarNode(part.key, part.key), createScalarNode(part.value, fmt.Sprintf("%v", part.value)))
+		}
+		for _, part := range []struct {
+			key   string
+			value string
+		}{{"prerelease", version.prerelease}, {"build", version.build}} {
+			valueNode := createScalarNode(nil, "null")
+			if part.value != "" {
+				valueNode = createStringScalarNode(part.value)
+			}
+			node.AddKeyValueChild(createScalarNode(part.key, part.key), valueNode)
+		}
+		results.PushBack(node)
+	}
+	return context.ChildContext(results), nil
+}
+
+// semver_satisfies(constraints)
+func semverSatisfiesOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("SemverSatisfies")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		constraints, err := getStringArgument("semver_satisfies", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+		groups, err := parseSemverConstraints(constraints)
+		if err != nil {
+			return Context{}, err
+		}
+		version, err := getSemver("semver_satisfies", candidate)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(createBooleanCandidate(candidate, semverSatisfies(version, groups)))
+	}
+	return context.ChildContext(results), nil
+}
+
+func bumpSemver(version semver, part string) (semver, error) {
+	bumped := semver{prefix: version.prefix, major: version.major, minor: version.minor, patch: version.patch}
+	switch part {
+	case "major":
+		bumped.major, bumped.minor, bumped.patch = version.major+1, 0, 0
+	case "minor":
+		bumped.minor, bumped.patch = version.minor+1, 0
+	case "patch":
+		bumped.patch = version.patch + 1
+	case "prerelease":
+		if version.prerelease == "" {
+			// like npm, 1.2.3 becomes 1.2.4-0
+			bumped.patch = version.patch + 1
+			bumped.prerelease = "0"
+			break
+		}
+		identifiers := strings.Split(version.prerelease, ".")
+		last := identifiers[len(identifiers)-1]
+		if number, err := strconv.ParseInt(last, 10, 64); err == nil {
+			identifiers[len(identifiers)-1] = strconv.FormatInt(number+1, 10)
+		} else {
+			identifiers = append(identifiers, "0")
+		}
+		bumped.prerelease = strings.Join(identifiers, ".")
+	default:
+		return semver{}, fmt.Errorf("semver_bump: unknown part '%v', expected major, minor, patch or prerelease", part)
+	}
+	return bumped, nil
+}
+
+// semver_bump(part)
+func semverBumpOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("SemverBump")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		part, err := getStringArgument("semver_bump", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+		version, err := getSemver("semver_bump", candidate)
+		if err != nil {
+			return Context{}, err
+		}
+		bumped, err := bumpSemver(version, part)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBack(candidate.CreateReplacement(ScalarNode, "!!str", bumped.String()))
+	}
+	return context.ChildContext(results), nil
+}
+
+// with_semver(exp) compares versions by semver precedence in exp, e.g. with sort, min, max and <.
+func withSemverOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("WithSemver")
+	context.SetSemverCompare(true)
+	return d.GetMatchingNodes(context, expressionNode.RHS)
+}
@@ -0,0 +1,148 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var semverOperatorScenarios = []expressionScenario{
+	{
+		description:    "Sort by semantic version",
+		subdescription: "Within `with_semver`, versions are compared by their semver precedence. This works with `sort`, `sort_by`, `min`, `max` and comparison operators like `<`.",
+		document:       `[1.10.0, 1.9.0, 1.10.0-rc.1, v2.0.0]`,
+		expression:     `with_semver(sort)`,
+		expected: []string{
+			"D0, P[], (!!seq)::[1.9.0, 1.10.0-rc.1, 1.10.0, v2.0.0]\n",
+		},
+	},
+	{
+		description: "Highest version",
+		document:    `[1.10.0, 1.9.0, 1.10.0-rc.1]`,
+		expression:  `with_semver(max)`,
+		expected: []string{
+			"D0, P[0], (!!str)::1.10.0\n",
+		},
+	},
+	{
+		description: "Lowest version by an expression",
+		skipDoc:     true,
+		document:    `[{v: 1.10.0}, {v: 1.9.0}]`,
+		expression:  `with_semver(min_by(.v))`,
+		expected: []string{
+			"D0, P[1], (!!map)::{v: 1.9.0}\n",
+		},
+	},
+	{
+		description:    "Compare versions",
+		subdescription: "Unquoted versions like `1.10` are floats in yaml, within `with_semver` they are compared as versions.",
+		document:       "a: 1.10\nb: 1.9",
+		expression:     `[with_semver(.a > .b), .a > .b]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- true\n- false\n",
+		},
+	},
+	{
+		description: "Compare a version with a prerelease",
+		skipDoc:     true,
+		expression:  `with_semver("1.0.0-alpha.beta" < "1.0.0-beta", "1.0.0-alpha.1" < "1.0.0-alpha.beta", "1.0.0-rc.1" < "1.0.0", "1.0.0+build" >= "1.0.0")`,
+		expected: []string{
+			"D0, P[], (!!bool)::true\n",
+			"D0, P[], (!!bool)::true\n",
+			"D0, P[], (!!bool)::true\n",
+			"D0, P[], (!!bool)::true\n",
+		},
+	},
+	{
+		description: "Sort without with_semver",
+		skipDoc:     true,
+		document:    `[1.10.0, 1.9.0]`,
+		expression:  `sort`,
+		expected: []string{
+			"D0, P[], (!!seq)::[1.10.0, 1.9.0]\n",
+		},
+	},
+	{
+		description: "Parse a semantic version",
+		document:    `v1.2.3-rc.1+build.5`,
+		expression:  `semver_parse`,
+		expected: []string{
+			"D0, P[], (!!map)::major: 1\nminor: 2\npatch: 3\nprerelease: rc.1\nbuild: build.5\n",
+		},
+	},
+	{
+		description:   "Parse an invalid version",
+		skipDoc:       true,
+		document:      `a: cat`,
+		expression:    `.a | semver_parse`,
+		expectedError: "semver_parse: 'cat' (a) is not a valid semantic version",
+	},
+	{
+		description:    "Check version constraints",
+		subdescription: "Supports `^`, `~`, comparisons and wildcards like `1.2.x`. Comparators separated by spaces or commas must all match, and `||` separates alternatives. Like most package managers, prereleases only match when a comparator is a prerelease of the same version.",
+		document:       `[1.1.0, 1.2.5, 1.9.0, 1.10.0-rc.1, 2.0.0]`,
+		expression:     `map(select(semver_satisfies("^1.2")))`,
+		expected: []string{
+			"D0, P[], (!!seq)::[1.2.5, 1.9.0]\n",
+		},
+	},
+	{
+		description: "Check version constraints with ranges",
+		skipDoc:     true,
+		document:    `[1.1.0, 1.2.5, 1.9.0, 1.10.0-rc.1, 2.0.0, 2.0.3, 2.1.0]`,
+		expression:  `map(select(semver_satisfies(">= 1.10.0-rc.0, <2 || ~2.0")))`,
+		expected: []string{
+			"D0, P[], (!!seq)::[1.10.0-rc.1, 2.0.0, 2.0.3]\n",
+		},
+	},
+	{
+		description: "Check version constraints with wildcards and zero majors",
+		skipDoc:     true,
+		document:    `[0.2.3, 0.2.9, 0.3.0, 0.0.3, 0.0.4]`,
+		expression:  `map(semver_satisfies("^0.2.3")), map(semver_satisfies("^0.0.3")), map(semver_satisfies("0.2.x")), map(semver_satisfies("*")), map(semver_satisfies("> 0.2 <=0.3.0"))`,
+		expected: []string{
+			"D0, P[], (!!seq)::[true, true, false, false, false]\n",
+			"D0, P[], (!!seq)::[false, false, false, true, false]\n",
+			"D0, P[], (!!seq)::[true, true, false, false, false]\n",
+			"D0, P[], (!!seq)::[true, true, true, true, true]\n",
+			"D0, P[], (!!seq)::[false, false, true, false, false]\n",
+		},
+	},
+	{
+		description:   "Check an invalid constraint",
+		skipDoc:       true,
+		document:      `1.2.3`,
+		expression:    `semver_satisfies("~> 1.2")`,
+		expectedError: "invalid semver constraint '~>1.2'",
+	},
+	{
+		description:    "Bump a version",
+		subdescription: "Bump the major, minor, patch or prerelease part of the version.",
+		document:       "chart: v1.2.3\nimage: 2.0.0-rc.1",
+		expression:     `.chart |= semver_bump("minor") | .image |= semver_bump("prerelease")`,
+		expected: []string{
+			"D0, P[], (!!map)::chart: v1.3.0\nimage: 2.0.0-rc.2\n",
+		},
+	},
+	{
+		description: "Bump other parts",
+		skipDoc:     true,
+		document:    `1.2.3-beta`,
+		expression:  `[semver_bump("major"), semver_bump("patch"), semver_bump("prerelease"), ("1.2.3" | semver_bump("prerelease"))]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 2.0.0\n- 1.2.4\n- 1.2.3-beta.0\n- 1.2.4-0\n",
+		},
+	},
+	{
+		description:   "Bump an unknown part",
+		skipDoc:       true,
+		document:      `1.2.3`,
+		expression:    `semver_bump("huge")`,
+		expectedError: "semver_bump: unknown part 'huge', expected major, minor, patch or prerelease",
+	},
+}
+
+func TestSemverOperatorScenarios(t *testing.T) {
+	for _, tt := range semverOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "semver", semverOperatorScenarios)
+}
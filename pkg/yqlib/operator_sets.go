@@ -0,0 +1,331 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// writeNodeHashKey writes a key for the node such that nodes that are equal, according
+// to recursiveNodeEqual, have the same key.
+func writeNodeHashKey(sb *strings.Builder, node *CandidateNode) {
+	switch node.Kind {
+	case ScalarNode:
+		tag := node.guessTagFromCustomType()
+		sb.WriteString(tag)
+		if tag != "!!null" {
+			sb.WriteString(fmt.Sprintf("%v:%v", len(node.Value), node.Value))
+		}
+	case SequenceNode:
+		sb.WriteString("[")
+		for _, child := range node.Content {
+			writeNodeHashKey(sb, child)
+			sb.WriteString(",")
+		}
+		sb.WriteString("]")
+	case MappingNode:
+		// equal maps can have their keys in any order
+		entries := make([]string, 0, len(node.Content)/2)
+		for index := 0; index < len(node.Content)-1; index = index + 2 {
+			var entry strings.Builder
+			writeNodeHashKey(&entry, node.Content[index])
+			entry.WriteString("=")
+			writeNodeHashKey(&entry, node.Content[index+1])
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		sb.WriteString("{")
+		sb.WriteString(strings.Join(entries, ","))
+		sb.WriteString("}")
+	default:
+		sb.WriteString(fmt.Sprintf("%v:%v", node.Kind, node.Value))
+	}
+}
+
+func nodeHashKey(node *CandidateNode) string {
+	var sb strings.Builder
+	writeNodeHashKey(&sb, node)
+	return sb.String()
+}
+
+type nodeLookupEntry struct {
+	key   *CandidateNode
+	value *CandidateNode
+}
+
+// nodeLookup finds the values added with a key equal to a given node (by recursiveNodeEqual),
+// using a hash of the keys rather than comparing against every key.
+type nodeLookup struct {
+	buckets map[string][]nodeLookupEntry
+}
+
+func newNodeLookup() *nodeLookup {
+	return &nodeLookup{buckets: make(map[string][]nodeLookupEntry)}
+}
+
+func (l *nodeLookup) add(key *CandidateNode, value *CandidateNode) {
+	hashKey := nodeHashKey(key)
+	l.buckets[hashKey] = append(l.buckets[hashKey], nodeLookupEntry{key: key, value: value})
+}
+
+func (l *nodeLookup) get(key *CandidateNode) []*CandidateNode {
+	values := make([]*CandidateNode, 0)
+	for _, entry := range l.buckets[nodeHashKey(key)] {
+		if recursiveNodeEqual(entry.key, key) {
+			values = append(values, entry.value)
+		}
+	}
+	return values
+}
+
+func (l *nodeLookup) contains(key *CandidateNode) bool {
+	return len(l.get(key)) > 0
+}
+
+func getArrayArgument(funcName string, d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (*CandidateNode, error) {
+	result, err := d.GetMatchingNodes(context.ReadOnlyClone(), expressionNode)
+	if err != nil {
+		return nil, err
+	} else if result.MatchingNodes.Len() == 0 {
+		return nil, fmt.Errorf("%v must be given an array, but got nothing", funcName)
+	}
+	node := result.MatchingNodes.Front().Value.(*CandidateNode)
+	if node.Kind != SequenceNode {
+		return nil, fmt.Errorf("%v must be given an array, but got %v", funcName, node.Tag)
+	}
+	return node, nil
+}
+
+// getKey returns the first result of the key expression for the node, or null.
+func getKey(d *dataTreeNavigator, context Context, node *CandidateNode, keyExp *ExpressionNode) (*CandidateNode, error) {
+	result, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(node), keyExp)
+	if err != nil {
+		return nil, err
+	} else if result.MatchingNodes.Len() == 0 {
+		return createScalarNode(nil, "null"), nil
+	}
+	return result.MatchingNodes.Front().Value.(*CandidateNode), nil
+}
+
+// setOperator keeps the (unique) entries of the array that are, or are not, in the other array.
+func setOperator(funcName string, keep bool) operatorHandler {
+	return func(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			candidate := el.Value.(*CandidateNode)
+			if candidate.Kind != SequenceNode {
+				return Context{}, fmt.Errorf("%v only works on arrays, but got %v", funcName, candidate.Tag)
+			}
+			other, err := getArrayArgument(funcName, d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+			if err != nil {
+				return Context{}, err
+			}
+
+			otherLookup := newNodeLookup()
+			for _, child := range other.Content {
+				otherLookup.add(child, child)
+			}
+
+			seen := newNodeLookup()
+			resultNode := candidate.CreateReplacementWithComments(SequenceNode, "!!seq", candidate.Style)
+			for _, child := range candidate.Content {
+				if otherLookup.contains(child) == keep && !seen.contains(child) {
+					seen.add(child, child)
+					resultNode.AddChild(child)
+				}
+			}
+			results.PushBack(resultNode)
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+var intersectionOperator = setOperator("intersection", true)
+var differenceOperator = setOperator("difference", false)
+
+// union_by(f; other) adds the entries of the other array, keeping the first entry for each f.
+func unionByOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("UnionBy")
+	if expressionNode.RHS.Operation.OperationType != blockOpType {
+		return Context{}, fmt.Errorf("union_by must be given a block (f; other), got %v instead", expressionNode.RHS.Operation.OperationType.Type)
+	}
+	keyExp := expressionNode.RHS.LHS
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		if candidate.Kind != SequenceNode {
+			return Context{}, fmt.Errorf("union_by only works on arrays, but got %v", candidate.Tag)
+		}
+		other, err := getArrayArgument("union_by", d, context.SingleReadonlyChildContext(candidate), expressionNode.RHS.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+
+		seen := newNodeLookup()
+		resultNode := candidate.CreateReplacementWithComments(SequenceNode, "!!seq", candidate.Style)
+		for _, child := range append(append([]*CandidateNode{}, candidate.Content...), other.Content...) {
+			key, err := getKey(d, context, child, keyExp)
+			if err != nil {
+				return Context{}, err
+			}
+			if !seen.contains(key) {
+				seen.add(key, child)
+				resultNode.AddChild(child)
+			}
+		}
+		results.PushBack(resultNode)
+	}
+	return context.ChildContext(results), nil
+}
+
+// IN(s) is true if the input is equal to any result of s,
+// IN(source; s) is true if any result of source is equal to any result of s.
+func inOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("IN")
+	sourceExp := &ExpressionNode{Operation: &Operation{OperationType: selfReferenceOpType}}
+	streamExp := expressionNode.RHS
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		sourceExp = expressionNode.RHS.LHS
+		streamExp = expressionNode.RHS.RHS
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		candidateContext := context.SingleReadonlyChildContext(candidate)
+
+		stream, err := d.GetMatchingNodes(candidateContext, streamExp)
+		if err != nil {
+			return Context{}, err
+		}
+		lookup := newNodeLookup()
+		for streamEl := stream.MatchingNodes.Front(); streamEl != nil; streamEl = streamEl.Next() {
+			node := streamEl.Value.(*CandidateNode)
+			lookup.add(node, node)
+		}
+
+		source, err := d.GetMatchingNodes(candidateContext, sourceExp)
+		if err != nil {
+			return Context{}, err
+		}
+		found := false
+		for sourceEl := source.MatchingNodes.Front(); sourceEl != nil && !found; sourceEl = sourceEl.Next() {
+			found = lookup.contains(sourceEl.Value.(*CandidateNode))
+		}
+		results.PushBack(createBooleanCandidate(candidate, found))
+	}
+	return context.ChildContext(results), nil
+}
+
+// INDEX(f) and INDEX(stream; f) create a map of the entries keyed by f, later entries replace earlier ones.
+func sqlIndexOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("INDEX")
+	var streamExp *ExpressionNode
+	keyExp := expressionNode.RHS
+	if expressionNode.RHS.Operation.OperationType == blockOpType {
+		streamExp = expressionNode.RHS.LHS
+		keyExp = expressionNode.RHS.RHS
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		entries := list.New()
+		if streamExp != nil {
+			stream, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(candidate), streamExp)
+			if err != nil {
+				return Context{}, err
+			}
+			entries = stream.MatchingNodes
+		} else if candidate.Kind == SequenceNode || candidate.Kind == MappingNode {
+			for index := len(candidate.Content) - 1; index >= 0; index-- {
+				if candidate.Kind == SequenceNode || index%2 == 1 {
+					entries.PushFront(candidate.Content[index])
+				}
+			}
+		} else {
+			return Context{}, fmt.Errorf("INDEX cannot iterate over %v", candidate.Tag)
+		}
+
+		indexNode := &CandidateNode{Kind: MappingNode, Tag: "!!map"}
+		positions := make(map[string]int)
+		for entryEl := entries.Front(); entryEl != nil; entryEl = entryEl.Next() {
+			entry := entryEl.Value.(*CandidateNode)
+			key, err := getKey(d, context, entry, keyExp)
+			if err != nil {
+				return Context{}, err
+			}
+			keyValue, err := getUniqueKeyValue(context.SingleChildContext(key))
+			if err != nil {
+				return Context{}, err
+			}
+			if position, exists := positions[keyValue]; exists {
+				value := entry.Copy()
+				value.SetParent(indexNode)
+				value.Key = indexNode.Content[position]
+				indexNode.Content[position+1] = value
+				continue
+			}
+			positions[keyValue] = len(indexNode.Content)
+			indexNode.AddKeyValueChild(createStringScalarNode(keyValue), entry.Copy())
+		}
+		results.PushBack(indexNode)
+	}
+	return context.ChildContext(results), nil
+}
+
+// join_by(f; other; g) joins the entries of the array with the entries of the other array where
+// f and g are equal, merging the matching maps like '*'.
+func joinByOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("JoinBy")
+	args := getFunctionArguments(expressionNode.RHS)
+	if len(args) != 3 {
+		return Context{}, fmt.Errorf("join_by expects (f; other; g), but got %v arguments", len(args))
+	}
+	keyExp, otherExp, otherKeyExp := args[0], args[1], args[2]
+	merge := multiply(multiplyPreferences{})
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		if candidate.Kind != SequenceNode {
+			return Context{}, fmt.Errorf("join_by only works on arrays, but got %v", candidate.Tag)
+		}
+		other, err := getArrayArgument("join_by", d, context.SingleReadonlyChildContext(candidate), otherExp)
+		if err != nil {
+			return Context{}, err
+		}
+
+		otherLookup := newNodeLookup()
+		for _, child := range other.Content {
+			key, err := getKey(d, context, child, otherKeyExp)
+			if err != nil {
+				return Context{}, err
+			}
+			otherLookup.add(key, child)
+		}
+
+		resultNode := candidate.CreateReplacementWithComments(SequenceNode, "!!seq", candidate.Style)
+		for _, child := range candidate.Content {
+			key, err := getKey(d, context, child, keyExp)
+			if err != nil {
+				return Context{}, err
+			}
+			for _, match := range otherLookup.get(key) {
+				if child.Kind != MappingNode || match.Kind != MappingNode {
+					return Context{}, fmt.Errorf("join_by can only merge maps, but got %v and %v", child.Tag, match.Tag)
+				}
+				merged, err := merge(d, context, child, match)
+				if err != nil {
+					return Context{}, err
+				}
+				resultNode.AddChild(merged)
+			}
+		}
+		results.PushBack(resultNode)
+	}
+	return context.ChildContext(results), nil
+}
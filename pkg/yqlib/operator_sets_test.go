@@ -0,0 +1,154 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var setOperatorScenarios = []expressionScenario{
+	{
+		description:    "Intersection",
+		subdescription: "Returns the unique entries that are in both arrays, in the order of the first array.",
+		document:       `{a: [cat, dog, cat, {b: 1}], b: [{b: 1}, dog, fish]}`,
+		expression:     `.b as $b | .a | intersection($b)`,
+		expected: []string{
+			"D0, P[a], (!!seq)::[dog, {b: 1}]\n",
+		},
+	},
+	{
+		description:    "Difference",
+		subdescription: "Returns the unique entries of the first array that are not in the second array.",
+		document:       `{a: [cat, dog, cat, {b: 1}], b: [{b: 1}, dog, fish]}`,
+		expression:     `.b as $b | .a | difference($b)`,
+		expected: []string{
+			"D0, P[a], (!!seq)::[cat]\n",
+		},
+	},
+	{
+		description: "Difference compares maps regardless of key order",
+		skipDoc:     true,
+		document:    `[{a: 1, b: [1, 2]}, {a: 1, b: [2, 1]}, null]`,
+		expression:  `difference([{"b": [1, 2], "a": 1}])`,
+		expected: []string{
+			"D0, P[], (!!seq)::[{a: 1, b: [2, 1]}, null]\n",
+		},
+	},
+	{
+		description: "Intersection does not match different types",
+		skipDoc:     true,
+		document:    `[1, "1", 1.0, true]`,
+		expression:  `intersection(["1", true])`,
+		expected: []string{
+			"D0, P[], (!!seq)::[\"1\", true]\n",
+		},
+	},
+	{
+		description:   "Intersection requires an array",
+		skipDoc:       true,
+		document:      `[1, 2]`,
+		expression:    `intersection(1)`,
+		expectedError: "intersection must be given an array, but got !!int",
+	},
+	{
+		description:    "Union by",
+		subdescription: "Adds the entries of the second array, keeping the first entry for each key.",
+		document:       `{defaults: [{name: a, v: 1}, {name: b, v: 1}], overrides: [{name: b, v: 2}, {name: c, v: 2}]}`,
+		expression:     `.defaults as $d | .overrides | union_by(.name; $d)`,
+		expected: []string{
+			"D0, P[overrides], (!!seq)::[{name: b, v: 2}, {name: c, v: 2}, {name: a, v: 1}]\n",
+		},
+	},
+	{
+		description:    "IN",
+		subdescription: "Returns true if the input is equal to any result of the expression.",
+		document:       `[1, 2, 3]`,
+		expression:     `.[] | IN(2, 3)`,
+		expected: []string{
+			"D0, P[0], (!!bool)::false\n",
+			"D0, P[1], (!!bool)::true\n",
+			"D0, P[2], (!!bool)::true\n",
+		},
+	},
+	{
+		description:    "IN with a source",
+		subdescription: "Returns true if any result of the source is equal to any result of the second expression.",
+		document:       `{a: [1, 2], b: [3, 2]}`,
+		expression:     `IN(.a[]; .b[])`,
+		expected: []string{
+			"D0, P[], (!!bool)::true\n",
+		},
+	},
+	{
+		description: "IN with no matches",
+		skipDoc:     true,
+		document:    `{a: [1, 2], b: [{a: 1}]}`,
+		expression:  `IN(.a[]; .b[])`,
+		expected: []string{
+			"D0, P[], (!!bool)::false\n",
+		},
+	},
+	{
+		description:    "INDEX",
+		subdescription: "Creates a map of the entries keyed by the expression. Later entries replace earlier entries with the same key.",
+		document:       `[{id: 1, name: sam}, {id: 2, name: bob}, {id: 1, name: pat}]`,
+		expression:     `INDEX(.id)`,
+		expected: []string{
+			"D0, P[], (!!map)::\"1\": {id: 1, name: pat}\n\"2\": {id: 2, name: bob}\n",
+		},
+	},
+	{
+		description: "INDEX with a stream",
+		document:    `{users: [{id: 1, name: sam}, {id: 2, name: bob}]}`,
+		expression:  `INDEX(.users[]; .name)`,
+		expected: []string{
+			"D0, P[], (!!map)::sam: {id: 1, name: sam}\nbob: {id: 2, name: bob}\n",
+		},
+	},
+	{
+		description:   "INDEX of a scalar",
+		skipDoc:       true,
+		document:      `cat`,
+		expression:    `INDEX(.)`,
+		expectedError: "INDEX cannot iterate over !!str",
+	},
+	{
+		description:    "JOIN",
+		subdescription: "Pairs each entry with the entry of an `INDEX` map that has its key. Like jq, keys are looked up as strings.",
+		document:       `{users: [{id: 1, name: sam}, {id: 2, name: bob}], orders: [{user: 1, item: apple}, {user: 2, item: fig}]}`,
+		expression:     `(.users | INDEX(.id)) as $users | .orders | JOIN($users; .user | tostring)`,
+		expected: []string{
+			"D0, P[orders], (!!seq)::- - {user: 1, item: apple}\n  - {id: 1, name: sam}\n- - {user: 2, item: fig}\n  - {id: 2, name: bob}\n",
+		},
+	},
+	{
+		description:    "JOIN a stream",
+		subdescription: "The last argument is applied to each pair, here merging them with `add`.",
+		document:       `{users: [{id: 1, name: sam}, {id: 2, name: bob}], orders: [{user: 1, item: apple}, {user: 2, item: fig}]}`,
+		expression:     `(.users | INDEX(.id)) as $users | [JOIN($users; .orders[]; .user | tostring; add)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- {user: 1, item: apple, id: 1, name: sam}\n- {user: 2, item: fig, id: 2, name: bob}\n",
+		},
+	},
+	{
+		description:    "Join by",
+		subdescription: "Joins the entries of the array with the entries of the second array where the keys are equal, merging each matching pair of maps with `*`. Entries without a match are dropped.",
+		document:       `{users: [{id: 1, name: sam}, {id: 2, name: bob}], orders: [{user: 1, item: apple}, {user: 1, item: pear}, {user: 3, item: fig}]}`,
+		expression:     `.users as $users | .orders | join_by(.user; $users; .id)`,
+		expected: []string{
+			"D0, P[orders], (!!seq)::[{user: 1, item: apple, id: 1, name: sam}, {user: 1, item: pear, id: 1, name: sam}]\n",
+		},
+	},
+	{
+		description:   "Join by requires maps",
+		skipDoc:       true,
+		document:      `[1, 2]`,
+		expression:    `join_by(.; [1]; .)`,
+		expectedError: "join_by can only merge maps, but got !!int and !!int",
+	},
+}
+
+func TestSetOperatorScenarios(t *testing.T) {
+	for _, tt := range setOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "set-operators", setOperatorScenarios)
+}
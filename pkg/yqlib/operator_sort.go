@@ -33,7 +33,7 @@ func sortByOperator(d *dataTreeNavigator, context Context, expressionNode *Expre
 				if err != nil {
 					return err
 				}
-				sortableNode := sortableNode{Node: valueNode, CompareContext: compareContext, dateTimeLayout: context.GetDateTimeLayout()}
+				sortableNode := sortableNode{Node: valueNode, CompareContext: compareContext, dateTimeLayout: context.GetDateTimeLayout(), semverCompare: context.IsSemverCompare()}
 				sortableArray = append(sortableArray, sortableNode)
 				return nil
 			}
@@ -68,6 +68,7 @@ type sortableNode struct {
 	Node           *CandidateNode
 	CompareContext Context
 	dateTimeLayout string
+	semverCompare  bool
 }
 
 type sortableNodeArray []sortableNode
@@ -86,6 +87,11 @@ func (a sortableNodeArray) Less(i, j int) bool {
 		rhs := rhsEl.Value.(*CandidateNode)
 
 		result := a.compare(lhs, rhs, a[i].dateTimeLayout)
+		if a[i].semverCompare {
+			if semverResult, ok := compareSemverNodes(lhs, rhs); ok {
+				result = semverResult
+			}
+		}
 
 		if result < 0 {
 			return true
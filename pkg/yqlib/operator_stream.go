@@ -0,0 +1,185 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// createStreamEvent creates a [path, leaf] event, or a [path] event
+// (marking the end of a map or array) when the leaf is nil.
+func createStreamEvent(path []interface{}, leaf *CandidateNode) *CandidateNode {
+	pathNode := &CandidateNode{Kind: SequenceNode, Tag: "!!seq", Style: FlowStyle}
+	for _, pathElement := range path {
+		pathNode.AddChild(createPathNodeFor(pathElement))
+	}
+	event := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	event.AddChild(pathNode)
+	if leaf != nil {
+		event.AddChild(leaf)
+	}
+	return event
+}
+
+func appendPath(path []interface{}, pathElement interface{}) []interface{} {
+	newPath := make([]interface{}, len(path), len(path)+1)
+	copy(newPath, path)
+	return append(newPath, pathElement)
+}
+
+// appendStreamEvents adds the events for the given node, like jq's tostream. Scalars and
+// empty maps/arrays are leaves, and every other map/array is closed with an event
+// holding the path of its last entry.
+func appendStreamEvents(node *CandidateNode, path []interface{}, results *list.List) {
+	if node.Kind == AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	var lastPath []interface{}
+	switch {
+	case node.Kind == MappingNode && len(node.Content) > 0:
+		for index := 0; index < len(node.Content); index = index + 2 {
+			lastPath = appendPath(path, node.Content[index].Value)
+			appendStreamEvents(node.Content[index+1], lastPath, results)
+		}
+	case node.Kind == SequenceNode && len(node.Content) > 0:
+		for index, child := range node.Content {
+			lastPath = appendPath(path, index)
+			appendStreamEvents(child, lastPath, results)
+		}
+	default:
+		results.PushBack(createStreamEvent(path, node))
+		return
+	}
+	results.PushBack(createStreamEvent(lastPath, nil))
+}
+
+func toStreamOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("ToStream")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		appendStreamEvents(el.Value.(*CandidateNode), []interface{}{}, results)
+	}
+	return context.ChildContext(results), nil
+}
+
+// setValueAtPath sets the value at the path, creating any maps and arrays needed
+// along the way. It returns the updated node, which is new if node was nil. New maps
+// and arrays copy the style and comments of the originals along the path, if given.
+func setValueAtPath(node *CandidateNode, path []interface{}, value *CandidateNode, originals []*CandidateNode) *CandidateNode {
+	if len(path) == 0 {
+		copied := value.Copy()
+		copied.Parent = nil
+		copied.Key = nil
+		return copied
+	}
+	var original *CandidateNode
+	if len(originals) > 0 {
+		original, originals = originals[0], originals[1:]
+	}
+	switch pathElement := path[0].(type) {
+	case string:
+		if node == nil || node.Kind != MappingNode {
+			node = createContainerLike(original, MappingNode, "!!map")
+		}
+		key := createStringScalarNode(pathElement)
+		indexInMap := findKeyInMap(node, key)
+		if indexInMap == -1 {
+			if original != nil && original.Kind == MappingNode {
+				if originalIndex := findKeyInMap(original, key); originalIndex != -1 {
+					key = original.Content[originalIndex]
+				}
+			}
+			node.AddKeyValueChild(key, setValueAtPath(nil, path[1:], value, originals))
+			return node
+		}
+		child := setValueAtPath(node.Content[indexInMap+1], path[1:], value, originals)
+		child.SetParent(node)
+		child.Key = node.Content[indexInMap]
+		node.Content[indexInMap+1] = child
+	case int:
+		if node == nil || node.Kind != SequenceNode {
+			node = createContainerLike(original, SequenceNode, "!!seq")
+		}
+		for len(node.Content) < pathElement {
+			node.AddChild(createScalarNode(nil, "null"))
+		}
+		if pathElement == len(node.Content) {
+			node.AddChild(setValueAtPath(nil, path[1:], value, originals))
+			return node
+		}
+		child := setValueAtPath(node.Content[pathElement], path[1:], value, originals)
+		child.SetParent(node)
+		child.Key = node.Content[pathElement].Key
+		node.Content[pathElement] = child
+	}
+	return node
+}
+
+// createContainerLike creates an empty map or array, with the tag, style and
+// comments of the original if it is of the same kind.
+func createContainerLike(original *CandidateNode, kind Kind, tag string) *CandidateNode {
+	if original == nil || original.Kind != kind {
+		return &CandidateNode{Kind: kind, Tag: tag}
+	}
+	return &CandidateNode{
+		Kind:           kind,
+		Tag:            original.Tag,
+		Style:          original.Style,
+		HeadComment:    original.HeadComment,
+		LineComment:    original.LineComment,
+		FootComment:    original.FootComment,
+		LeadingContent: original.LeadingContent,
+	}
+}
+
+func getStreamEvent(node *CandidateNode) ([]interface{}, *CandidateNode, error) {
+	if node.Kind != SequenceNode || len(node.Content) < 1 || len(node.Content) > 2 {
+		return nil, nil, fmt.Errorf("FROMSTREAM: expected a [path, leaf] or [path] event, but got %v", node.Tag)
+	}
+	path, err := getPathArrayFromNode("FROMSTREAM", node.Content[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pathElement := range path {
+		if index, ok := pathElement.(int); ok && index < 0 {
+			return nil, nil, fmt.Errorf("FROMSTREAM: array index %v in event path must not be negative", index)
+		}
+	}
+	if len(node.Content) == 1 {
+		return path, nil, nil
+	}
+	return path, node.Content[1], nil
+}
+
+// FROMSTREAM(events)
+func fromStreamOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("FromStream")
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		candidate := el.Value.(*CandidateNode)
+		events, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(candidate), expressionNode.RHS)
+		if err != nil {
+			return Context{}, err
+		}
+
+		var current *CandidateNode
+		for eventEl := events.MatchingNodes.Front(); eventEl != nil; eventEl = eventEl.Next() {
+			path, leaf, err := getStreamEvent(eventEl.Value.(*CandidateNode))
+			if err != nil {
+				return Context{}, err
+			}
+			switch {
+			case leaf != nil && len(path) == 0:
+				results.PushBack(setValueAtPath(nil, path, leaf, nil))
+			case leaf != nil:
+				current = setValueAtPath(current, path, leaf, nil)
+			case len(path) == 1 && current != nil:
+				// the end of a top level map or array
+				results.PushBack(current)
+				current = nil
+			}
+		}
+	}
+	return context.ChildContext(results), nil
+}
@@ -0,0 +1,83 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var streamOperatorScenarios = []expressionScenario{
+	{
+		description:    "To stream",
+		subdescription: "Each scalar (or empty map/array) is returned as a `[path, leaf]` event. After the last entry of each map/array, a `[path]` event marks its end.",
+		document:       `{a: [1, {b: cat}], c: {}}`,
+		expression:     `[tostream]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - [a, 0]\n  - 1\n- - [a, 1, b]\n  - cat\n- - [a, 1, b]\n- - [a, 1]\n- - [c]\n  - {}\n- - [c]\n",
+		},
+	},
+	{
+		description: "To stream a scalar",
+		skipDoc:     true,
+		document:    `cat`,
+		expression:  `tostream`,
+		expected: []string{
+			"D0, P[], (!!seq)::- []\n- cat\n",
+		},
+	},
+	{
+		description:    "From stream",
+		subdescription: "Reassembles the top level values from the events given.",
+		document:       `{a: [1, {b: cat}], c: {}}`,
+		expression:     `fromstream(tostream)`,
+		expected: []string{
+			"D0, P[], (!!map)::a:\n    - 1\n    - b: cat\nc: {}\n",
+		},
+	},
+	{
+		description: "From stream keeps comments",
+		skipDoc:     true,
+		document:    "a: cat # meow\n",
+		expression:  `fromstream(tostream)`,
+		expected: []string{
+			"D0, P[], (!!map)::a: cat # meow\n",
+		},
+	},
+	{
+		description:    "From stream with multiple values",
+		subdescription: "Top level scalars are returned as they are, maps and arrays are returned when their end event is reached.",
+		expression:     `fromstream([[], 3], [[0], 1], [[0]])`,
+		expected: []string{
+			"D0, P[], (!!int)::3\n",
+			"D0, P[], (!!seq)::- 1\n",
+		},
+	},
+	{
+		description:    "Filter a stream",
+		subdescription: "Events can be filtered before they are reassembled, e.g. to remove a field from every entry in an array.",
+		document:       `[{name: cat, id: 1}, {name: dog, id: 2}]`,
+		expression:     `fromstream(tostream | select(.[0][-1] != "id"))`,
+		expected: []string{
+			"D0, P[], (!!seq)::- name: cat\n- name: dog\n",
+		},
+	},
+	{
+		description:    "Truncate stream",
+		subdescription: "Removes the given number of elements from the start of each event path, dropping events that are not deep enough. The stream is evaluated against null.",
+		expression:     `[1 | truncate_stream([[0], 1], [[1, 0], 2], [[1, 0]], [[1]])]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - - 0\n  - 2\n- - - 0\n",
+		},
+	},
+	{
+		description:   "From stream with a bad event",
+		skipDoc:       true,
+		expression:    `fromstream("cat")`,
+		expectedError: "FROMSTREAM: expected a [path, leaf] or [path] event, but got !!str",
+	},
+}
+
+func TestStreamOperatorScenarios(t *testing.T) {
+	for _, tt := range streamOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "stream", streamOperatorScenarios)
+}
@@ -4,13 +4,28 @@ import (
 	"container/list"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 var StringInterpolationEnabled = true
 
 type changeCasePrefs struct {
 	ToUpperCase bool
+	// only change the case of ascii letters, like jq's ascii_downcase
+	ASCIIOnly bool
+}
+
+func changeASCIICase(value string, toUpperCase bool) string {
+	return strings.Map(func(r rune) rune {
+		if toUpperCase && r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		} else if !toUpperCase && r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return r
+	}, value)
 }
 
 func encodeToYamlString(node *CandidateNode) (string, error) {
@@ -193,7 +208,9 @@ func changeCaseOperator(_ *dataTreeNavigator, context Context, expressionNode *E
 		}
 
 		value := ""
-		if prefs.ToUpperCase {
+		if prefs.ASCIIOnly {
+			value = changeASCIICase(node.Value, prefs.ToUpperCase)
+		} else if prefs.ToUpperCase {
 			value = strings.ToUpper(node.Value)
 		} else {
 			value = strings.ToLower(node.Value)
@@ -208,69 +225,174 @@ func changeCaseOperator(_ *dataTreeNavigator, context Context, expressionNode *E
 
 }
 
-func getSubstituteParameters(d *dataTreeNavigator, block *ExpressionNode, context Context) (string, string, error) {
-	regEx := ""
-	replacementText := ""
+func getSubstituteArguments(funcName string, d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (*regexp.Regexp, *ExpressionNode, matchPreferences, error) {
+	var args []*ExpressionNode
+	if expressionNode.RHS.Operation.OperationType == unionOpType {
+		// the original sub("regEx", "replacement") form
+		args = []*ExpressionNode{expressionNode.RHS.LHS, expressionNode.RHS.RHS}
+	} else {
+		args = getFunctionArguments(expressionNode.RHS)
+	}
+
+	matchPrefs := matchPreferences{}
+	switch len(args) {
+	case 2:
+	case 3:
+		flagNodes, err := d.GetMatchingNodes(context.ReadOnlyClone(), args[2])
+		if err != nil {
+			return nil, nil, matchPrefs, err
+		}
+		flags := ""
+		if flagNodes.MatchingNodes.Front() != nil {
+			flags = flagNodes.MatchingNodes.Front().Value.(*CandidateNode).Value
+		}
+		matchPrefs, err = parseMatchFlags(flags)
+		if err != nil {
+			return nil, nil, matchPrefs, err
+		}
+	default:
+		return nil, nil, matchPrefs, fmt.Errorf("%v expects (regEx; replacement) or (regEx; replacement; flags), but got %v arguments", funcName, len(args))
+	}
 
-	regExNodes, err := d.GetMatchingNodes(context.ReadOnlyClone(), block.LHS)
+	regExNodes, err := d.GetMatchingNodes(context.ReadOnlyClone(), args[0])
 	if err != nil {
-		return "", "", err
+		return nil, nil, matchPrefs, err
 	}
+	regExStr := ""
 	if regExNodes.MatchingNodes.Front() != nil {
-		regEx = regExNodes.MatchingNodes.Front().Value.(*CandidateNode).Value
+		regExStr = regExNodes.MatchingNodes.Front().Value.(*CandidateNode).Value
 	}
+	log.Debugf("regEx %v", regExStr)
 
-	log.Debugf("regEx %v", regEx)
+	regEx, err := compileRegEx(regExStr, matchPrefs)
+	return regEx, args[1], matchPrefs, err
+}
 
-	replacementNodes, err := d.GetMatchingNodes(context, block.RHS)
+// getReplacement evaluates the replacement expression with the capture object of the match as '.'
+func getReplacement(d *dataTreeNavigator, context Context, replacementExp *ExpressionNode, captureNode *CandidateNode) (string, error) {
+	replacementNodes, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(captureNode), replacementExp)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
-	if replacementNodes.MatchingNodes.Front() != nil {
-		replacementText = replacementNodes.MatchingNodes.Front().Value.(*CandidateNode).Value
+	if replacementNodes.MatchingNodes.Front() == nil {
+		return "", nil
 	}
-
-	return regEx, replacementText, nil
+	replacementNode := replacementNodes.MatchingNodes.Front().Value.(*CandidateNode)
+	if replacementNode.Kind != ScalarNode {
+		return "", fmt.Errorf("cannot substitute with %v, the replacement must be a string", replacementNode.Tag)
+	}
+	return replacementNode.Value, nil
 }
 
-func substitute(original string, regex *regexp.Regexp, replacement string) (Kind, string, string) {
-	replacedString := regex.ReplaceAllString(original, replacement)
-	return ScalarNode, "!!str", replacedString
+func substitute(d *dataTreeNavigator, context Context, candidate *CandidateNode, regEx *regexp.Regexp, replacementExp *ExpressionNode, matchPrefs matchPreferences) (string, error) {
+	original := candidate.Value
+	allMatches, allIndices := getMatches(matchPrefs, regEx, original)
+	if len(allMatches) > 0 && len(allMatches[0]) == 0 {
+		return original, nil
+	}
+
+	subNames := regEx.SubexpNames()
+	// only literal strings can refer to capture groups, the results of expressions are used as they are
+	isLiteral := replacementExp.Operation.OperationType == valueOpType ||
+		(replacementExp.Operation.OperationType == stringInterpolationOpType && !strings.Contains(replacementExp.Operation.StringValue, "\\("))
+	var replaced []byte
+	lastIndex := 0
+	for i, matches := range allMatches {
+		captureNode := createCaptureNode(subNames, candidate, matches, allIndices[i])
+		replacement, err := getReplacement(d, context, replacementExp, captureNode)
+		if err != nil {
+			return "", err
+		}
+		replaced = append(replaced, original[lastIndex:allIndices[i][0]]...)
+		if isLiteral {
+			replaced = expandReplacement(replaced, regEx, replacement, original, allIndices[i])
+		} else {
+			replaced = append(replaced, replacement...)
+		}
+		lastIndex = allIndices[i][1]
+	}
+	replaced = append(replaced, original[lastIndex:]...)
+	return string(replaced), nil
 }
 
-func substituteStringOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
-	//rhs  block operator
-	//lhs of block = regex
-	//rhs of block = replacement expression
-	block := expressionNode.RHS
+var replacementReferenceRegex = regexp.MustCompile(`^\$(?:\{([^}]*)\}|([a-zA-Z0-9_]+))`)
 
-	regExStr, replacementText, err := getSubstituteParameters(d, block, context)
+// expandReplacement appends the replacement, expanding $1, ${1}, $name and ${name} to the capture group
+// of the match and $$ to $. Unlike regexp.Expand, references to groups that do not exist are left as they are.
+func expandReplacement(dst []byte, regEx *regexp.Regexp, replacement string, original string, match []int) []byte {
+	for {
+		dollar := strings.Index(replacement, "$")
+		if dollar < 0 {
+			return append(dst, replacement...)
+		}
+		dst = append(dst, replacement[:dollar]...)
+		replacement = replacement[dollar:]
+		if strings.HasPrefix(replacement, "$$") {
+			dst = append(dst, '$')
+			replacement = replacement[2:]
+			continue
+		}
 
-	if err != nil {
-		return Context{}, err
+		reference := replacementReferenceRegex.FindStringSubmatch(replacement)
+		group := -1
+		if reference != nil {
+			name := reference[1] + reference[2]
+			if number, err := strconv.Atoi(name); err == nil && number <= regEx.NumSubexp() {
+				group = number
+			} else if err != nil && name != "" {
+				group = regEx.SubexpIndex(name)
+			}
+		}
+		if group < 0 {
+			dst = append(dst, '$')
+			replacement = replacement[1:]
+			continue
+		}
+		if match[2*group] >= 0 {
+			dst = append(dst, original[match[2*group]:match[2*group+1]]...)
+		}
+		replacement = replacement[len(reference[0]):]
 	}
+}
 
-	regEx, err := regexp.Compile(regExStr)
-	if err != nil {
-		return Context{}, err
-	}
+func substituteOperator(funcName string) operatorHandler {
+	return func(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+		//rhs  block operator
+		//lhs of block = regex
+		//rhs of block = replacement expression, evaluated against the capture object of each match
+		//optional third argument = flags
+		regEx, replacementExp, matchPrefs, err := getSubstituteArguments(funcName, d, context, expressionNode)
+		if err != nil {
+			return Context{}, err
+		}
+		// sub has always replaced all matches, so it is the same as gsub and the g flag would do nothing
+		if funcName == "sub" && matchPrefs.Global {
+			return Context{}, fmt.Errorf("sub already replaces all matches, use it without the 'g' flag")
+		}
+		matchPrefs.Global = true
 
-	var results = list.New()
+		var results = list.New()
 
-	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
-		node := el.Value.(*CandidateNode)
-		if node.guessTagFromCustomType() != "!!str" {
-			return Context{}, fmt.Errorf("cannot substitute with %v, can only substitute strings. Hint: Most often you'll want to use '|=' over '=' for this operation", node.Tag)
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			node := el.Value.(*CandidateNode)
+			if node.guessTagFromCustomType() != "!!str" {
+				return Context{}, fmt.Errorf("cannot substitute with %v, can only substitute strings. Hint: Most often you'll want to use '|=' over '=' for this operation", node.Tag)
+			}
+
+			replaced, err := substitute(d, context, node, regEx, replacementExp, matchPrefs)
+			if err != nil {
+				return Context{}, err
+			}
+			results.PushBack(node.CreateReplacement(ScalarNode, "!!str", replaced))
 		}
 
-		result := node.CreateReplacement(substitute(node.Value, regEx, replacementText))
-		results.PushBack(result)
+		return context.ChildContext(results), nil
 	}
-
-	return context.ChildContext(results), nil
-
 }
 
+var substituteStringOperator = substituteOperator("sub")
+var globalSubstituteStringOperator = substituteOperator("gsub")
+
 func addMatch(original []*CandidateNode, match string, offset int, name string) []*CandidateNode {
 
 	newContent := append(original,
@@ -303,7 +425,76 @@ func addMatch(original []*CandidateNode, match string, offset int, name string)
 }
 
 type matchPreferences struct {
-	Global bool
+	Global     bool
+	IgnoreCase bool
+	// ignore whitespace and # comments in the regEx, like jq's 'x' flag
+	Extended bool
+}
+
+func parseMatchFlags(flags string) (matchPreferences, error) {
+	matchPrefs := matchPreferences{}
+	unrecognised := ""
+	for _, flag := range flags {
+		switch flag {
+		case 'g':
+			matchPrefs.Global = true
+		case 'i':
+			matchPrefs.IgnoreCase = true
+		case 'x':
+			matchPrefs.Extended = true
+		default:
+			unrecognised = unrecognised + string(flag)
+		}
+	}
+	if len(unrecognised) > 0 {
+		return matchPrefs, fmt.Errorf(`unrecognised match params '%v', please see docs at https://mikefarah.gitbook.io/yq/operators/string-operators`, unrecognised)
+	}
+	return matchPrefs, nil
+}
+
+// removeExtendedWhitespace strips unescaped whitespace and comments outside of character classes.
+func removeExtendedWhitespace(regExStr string) string {
+	var sb strings.Builder
+	inClass := false
+	inComment := false
+	escaped := false
+	for _, r := range regExStr {
+		switch {
+		case inComment:
+			inComment = r != '\n'
+			continue
+		case escaped:
+			escaped = false
+			// '\ ' and '\#' are a literal space and #
+			if r != ' ' && r != '#' {
+				sb.WriteRune('\\')
+			}
+		case r == '\\':
+			escaped = true
+			continue
+		case inClass:
+			inClass = r != ']'
+		case r == '[':
+			inClass = true
+		case r == '#':
+			inComment = true
+			continue
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func compileRegEx(regExStr string, matchPrefs matchPreferences) (*regexp.Regexp, error) {
+	if matchPrefs.Extended {
+		regExStr = removeExtendedWhitespace(regExStr)
+	}
+	if matchPrefs.IgnoreCase {
+		regExStr = "(?i)" + regExStr
+	}
+	return regexp.Compile(regExStr)
 }
 
 func getMatches(matchPrefs matchPreferences, regEx *regexp.Regexp, value string) ([][]string, [][]int) {
@@ -350,6 +541,28 @@ func match(matchPrefs matchPreferences, regEx *regexp.Regexp, candidate *Candida
 
 }
 
+// createCaptureNode creates a map of the capture groups of a match, keyed by their names.
+func createCaptureNode(subNames []string, candidate *CandidateNode, matches []string, indices []int) *CandidateNode {
+	capturesNode := candidate.CreateReplacement(MappingNode, "!!map", "")
+
+	_, submatches := matches[0], matches[1:]
+	for j, submatch := range submatches {
+
+		keyNode := createScalarNode(subNames[j+1], subNames[j+1])
+		var valueNode *CandidateNode
+
+		offset := indices[2+j*2]
+		// offset of -1 means there was no match, force a null value like jq
+		if offset < 0 {
+			valueNode = createScalarNode(nil, "null")
+		} else {
+			valueNode = createScalarNode(submatch, submatch)
+		}
+		capturesNode.AddKeyValueChild(keyNode, valueNode)
+	}
+	return capturesNode
+}
+
 func capture(matchPrefs matchPreferences, regEx *regexp.Regexp, candidate *CandidateNode, value string, results *list.List) {
 	subNames := regEx.SubexpNames()
 	allMatches, allIndices := getMatches(matchPrefs, regEx, value)
@@ -361,26 +574,7 @@ func capture(matchPrefs matchPreferences, regEx *regexp.Regexp, candidate *Candi
 	}
 
 	for i, matches := range allMatches {
-		capturesNode := candidate.CreateReplacement(MappingNode, "!!map", "")
-
-		_, submatches := matches[0], matches[1:]
-		for j, submatch := range submatches {
-
-			keyNode := createScalarNode(subNames[j+1], subNames[j+1])
-			var valueNode *CandidateNode
-
-			offset := allIndices[i][2+j*2]
-			// offset of -1 means there was no match, force a null value like jq
-			if offset < 0 {
-				valueNode = createScalarNode(nil, "null")
-			} else {
-				valueNode = createScalarNode(submatch, submatch)
-			}
-			capturesNode.AddKeyValueChild(keyNode, valueNode)
-		}
-
-		results.PushBack(capturesNode)
-
+		results.PushBack(createCaptureNode(subNames, candidate, matches, allIndices[i]))
 	}
 
 }
@@ -402,15 +596,9 @@ func extractMatchArguments(d *dataTreeNavigator, context Context, expressionNode
 		if replacementNodes.MatchingNodes.Front() != nil {
 			paramText = replacementNodes.MatchingNodes.Front().Value.(*CandidateNode).Value
 		}
-		if strings.Contains(paramText, "g") {
-			paramText = strings.ReplaceAll(paramText, "g", "")
-			matchPrefs.Global = true
-		}
-		if strings.Contains(paramText, "i") {
-			return nil, matchPrefs, fmt.Errorf(`'i' is not a valid option for match. To ignore case, use an expression like match("(?i)cat")`)
-		}
-		if len(paramText) > 0 {
-			return nil, matchPrefs, fmt.Errorf(`unrecognised match params '%v', please see docs at https://mikefarah.gitbook.io/yq/operators/string-operators`, paramText)
+		matchPrefs, err = parseMatchFlags(paramText)
+		if err != nil {
+			return nil, matchPrefs, err
 		}
 	}
 
@@ -424,7 +612,7 @@ func extractMatchArguments(d *dataTreeNavigator, context Context, expressionNode
 		regExStr = regExNodes.MatchingNodes.Front().Value.(*CandidateNode).Value
 	}
 	log.Debugf("regEx %v", regExStr)
-	regEx, err := regexp.Compile(regExStr)
+	regEx, err := compileRegEx(regExStr, matchPrefs)
 	return regEx, matchPrefs, err
 }
 
@@ -575,3 +763,159 @@ func split(value string, spltStr string) (Kind, string, []*CandidateNode) {
 
 	return SequenceNode, "!!seq", contents
 }
+
+func getStringArgument(funcName string, d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (string, error) {
+	result, err := d.GetMatchingNodes(context.ReadOnlyClone(), expressionNode)
+	if err != nil {
+		return "", err
+	} else if result.MatchingNodes.Len() == 0 {
+		return "", fmt.Errorf("%v must be given a string, but got nothing", funcName)
+	}
+	node := result.MatchingNodes.Front().Value.(*CandidateNode)
+	if node.Kind != ScalarNode || node.guessTagFromCustomType() != "!!str" {
+		return "", fmt.Errorf("%v must be given a string, but got %v", funcName, node.Tag)
+	}
+	return node.Value, nil
+}
+
+// trimStringOperator removes the prefix/suffix if present, anything that isn't a string is left as is.
+func trimStringOperator(funcName string, trim func(string, string) string) operatorHandler {
+	return func(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			node := el.Value.(*CandidateNode)
+			toTrim, err := getStringArgument(funcName, d, context.SingleReadonlyChildContext(node), expressionNode.RHS)
+			if err != nil {
+				return Context{}, err
+			}
+			if node.Kind != ScalarNode || node.guessTagFromCustomType() != "!!str" {
+				results.PushBack(node)
+				continue
+			}
+			newStringNode := node.CreateReplacement(ScalarNode, node.Tag, trim(node.Value, toTrim))
+			newStringNode.Style = node.Style
+			results.PushBack(newStringNode)
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+var leftTrimStringOperator = trimStringOperator("ltrimstr", strings.TrimPrefix)
+var rightTrimStringOperator = trimStringOperator("rtrimstr", strings.TrimSuffix)
+
+func stringPredicateOperator(funcName string, predicate func(string, string) bool) operatorHandler {
+	return func(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+		log.Debugf("%v", funcName)
+		results := list.New()
+		for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+			node := el.Value.(*CandidateNode)
+			if node.Kind != ScalarNode || node.guessTagFromCustomType() != "!!str" {
+				return Context{}, fmt.Errorf("cannot use %v with %v, can only operate on strings", funcName, node.Tag)
+			}
+			value, err := getStringArgument(funcName, d, context.SingleReadonlyChildContext(node), expressionNode.RHS)
+			if err != nil {
+				return Context{}, err
+			}
+			results.PushBack(createBooleanCandidate(node, predicate(node.Value, value)))
+		}
+		return context.ChildContext(results), nil
+	}
+}
+
+var startsWithOperator = stringPredicateOperator("startswith", strings.HasPrefix)
+var endsWithOperator = stringPredicateOperator("endswith", strings.HasSuffix)
+
+// splits(regex) and splits(regex; flags)
+func splitsOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	log.Debugf("splitsOperator")
+	regEx, _, err := extractMatchArguments(d, context, expressionNode)
+	if err != nil {
+		return Context{}, err
+	}
+
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		node := el.Value.(*CandidateNode)
+		if node.Kind != ScalarNode || node.guessTagFromCustomType() != "!!str" {
+			return Context{}, fmt.Errorf("cannot split %v, can only split strings", node.Tag)
+		}
+		for _, part := range regEx.Split(node.Value, -1) {
+			results.PushBack(createStringScalarNode(part))
+		}
+	}
+	return context.ChildContext(results), nil
+}
+
+func getCodepoint(funcName string, node *CandidateNode) (rune, error) {
+	if node.Kind != ScalarNode || node.guessTagFromCustomType() != "!!int" {
+		return 0, fmt.Errorf("%v expects codepoints to be ints, but got %v", funcName, node.Tag)
+	}
+	_, value, err := parseInt64(node.Value)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 || value > utf8.MaxRune {
+		return 0, fmt.Errorf("%v: %v is not a valid codepoint", funcName, value)
+	}
+	return rune(value), nil
+}
+
+// implode converts an array of codepoints into a string.
+func implodeOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("implodeOperator")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		node := el.Value.(*CandidateNode)
+		if node.Kind != SequenceNode {
+			return Context{}, fmt.Errorf("cannot implode %v, can only implode arrays of codepoints", node.Tag)
+		}
+		var builder strings.Builder
+		for _, child := range node.Content {
+			codepoint, err := getCodepoint("implode", child)
+			if err != nil {
+				return Context{}, err
+			}
+			builder.WriteRune(codepoint)
+		}
+		results.PushBack(node.CreateReplacement(ScalarNode, "!!str", builder.String()))
+	}
+	return context.ChildContext(results), nil
+}
+
+// explode (without an argument) converts a string into an array of codepoints.
+// explode(exp), for anchors, is in operator_anchors_aliases.go
+func explodeCodepointsOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("explodeCodepointsOperator")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		node := el.Value.(*CandidateNode)
+		if node.Kind != ScalarNode || node.guessTagFromCustomType() != "!!str" {
+			return Context{}, fmt.Errorf("cannot explode %v into codepoints, can only explode strings. To explode anchors, use explode(.)", node.Tag)
+		}
+		result := node.CreateReplacement(SequenceNode, "!!seq", "")
+		for _, codepoint := range node.Value {
+			result.AddChild(createScalarNode(int64(codepoint), fmt.Sprintf("%v", codepoint)))
+		}
+		results.PushBack(result)
+	}
+	return context.ChildContext(results), nil
+}
+
+// ascii converts an ascii codepoint into a single character string.
+func asciiOperator(_ *dataTreeNavigator, context Context, _ *ExpressionNode) (Context, error) {
+	log.Debugf("asciiOperator")
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		node := el.Value.(*CandidateNode)
+		codepoint, err := getCodepoint("ascii", node)
+		if err != nil {
+			return Context{}, err
+		}
+		if codepoint > 127 {
+			return Context{}, fmt.Errorf("ascii: %v is not an ascii codepoint", codepoint)
+		}
+		results.PushBack(node.CreateReplacement(ScalarNode, "!!str", string(codepoint)))
+	}
+	return context.ChildContext(results), nil
+}
@@ -308,6 +308,126 @@ var stringsOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!map)::a: !horse cart\nb: !goat heart\n",
 		},
 	},
+	{
+		description:    "Substitute with an expression",
+		subdescription: "The replacement is an expression, evaluated with the capture object of each match (as returned by `capture`) as `.`.",
+		document:       `a: cat and dog`,
+		expression:     `.a |= sub("(?P<animal>[a-z]+) and"; (.animal | upcase) + " and")`,
+		expected: []string{
+			"D0, P[], (!!map)::a: CAT and dog\n",
+		},
+	},
+	{
+		description:    "Substitute using a lookup map",
+		subdescription: "Matches missing from the map are left as they are.",
+		document:       `a: the cat sat on the mat`,
+		expression:     `{"cat": "dog", "mat": "rug"} as $m | .a |= sub("(?P<word>[a-z]+)"; $m[.word] // .word)`,
+		expected: []string{
+			"D0, P[], (!!map)::a: the dog sat on the rug\n",
+		},
+	},
+	{
+		description:    "Substitute, ignoring case",
+		subdescription: "Unlike jq, `sub` replaces all matches like `gsub`, as it always has in yq, so the 'g' flag makes no difference. The 'i' flag ignores case and the 'x' flag ignores whitespace and `#` comments in the regex.",
+		document:       `a: Cat cat CAT`,
+		expression:     `.a |= gsub("c a t # the animal"; "dog"; "ix")`,
+		expected: []string{
+			"D0, P[], (!!map)::a: dog dog dog\n",
+		},
+	},
+	{
+		description: "Substitute with flags still replaces all matches",
+		skipDoc:     true,
+		document:    `aAa`,
+		expression:  `[sub("a"; "x"), sub("a"; "x"; ""), sub("a"; "x"; "i"), gsub("a"; "x")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- xAx\n- xAx\n- xxx\n- xAx\n",
+		},
+	},
+	{
+		description:    "Substitute with a variable containing $",
+		subdescription: "Only literal strings can refer to capture groups, the results of expressions like variables are used as they are.",
+		document:       `price: cost`,
+		expression:     `"$5 ${1}" as $p | .price |= sub("(cost)"; $p)`,
+		expected: []string{
+			"D0, P[], (!!map)::price: $5 ${1}\n",
+		},
+	},
+	{
+		description: "Substitute with $ that are not capture groups",
+		skipDoc:     true,
+		document:    `cost`,
+		expression:  `[sub("cost"; "$5"), sub("(c)ost"; "$$1 ${x} $1")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- $5\n- $1 ${x} c\n",
+		},
+	},
+	{
+		description: "Extended regex keeps escaped whitespace",
+		skipDoc:     true,
+		document:    `a b#c`,
+		expression:  `sub("a\\ b \\# c"; "x"; "x")`,
+		expected: []string{
+			"D0, P[], (!!str)::x\n",
+		},
+	},
+	{
+		description: "Extended regex keeps whitespace in a character class",
+		skipDoc:     true,
+		document:    `a b`,
+		expression:  `gsub("[ ]"; "_"; "x")`,
+		expected: []string{
+			"D0, P[], (!!str)::a_b\n",
+		},
+	},
+	{
+		description: "Substitute with a capture group reference",
+		skipDoc:     true,
+		document:    `abc`,
+		expression:  `gsub("(?P<x>b)"; "[${x}]")`,
+		expected: []string{
+			"D0, P[], (!!str)::a[b]c\n",
+		},
+	},
+	{
+		description: "Substitute with no match",
+		skipDoc:     true,
+		document:    `abc`,
+		expression:  `sub("z"; "y")`,
+		expected: []string{
+			"D0, P[], (!!str)::abc\n",
+		},
+	},
+	{
+		description:   "Substitute with a map",
+		skipDoc:       true,
+		document:      `abc`,
+		expression:    `sub("b"; {})`,
+		expectedError: "cannot substitute with !!map, the replacement must be a string",
+	},
+	{
+		description:   "Substitute with the g flag",
+		skipDoc:       true,
+		document:      `abc`,
+		expression:    `sub("b"; "c"; "g")`,
+		expectedError: "sub already replaces all matches, use it without the 'g' flag",
+	},
+	{
+		description:   "Substitute with bad flags",
+		skipDoc:       true,
+		document:      `abc`,
+		expression:    `sub("b"; "c"; "q")`,
+		expectedError: "unrecognised match params 'q', please see docs at https://mikefarah.gitbook.io/yq/operators/string-operators",
+	},
+	{
+		description: "Match string, ignoring case with the 'i' flag",
+		skipDoc:     true,
+		document:    `foo bar FOO`,
+		expression:  `[match("foo"; "gi") | .string]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- foo\n- FOO\n",
+		},
+	},
 	{
 		description: "Split strings",
 		document:    `"cat; meow; 1; ; true"`,
@@ -364,6 +484,107 @@ var stringsOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!seq)::[\"1\", \"true\", \"null\", \"~\", cat, \"{an: object}\", \"[array, 2]\"]\n",
 		},
 	},
+	{
+		description:    "Trim prefix and suffix",
+		subdescription: "Like jq, anything that isn't a string (or doesn't have the prefix/suffix) is left as is.",
+		document:       `[foobar, barfoo, 3]`,
+		expression:     `[.[] | ltrimstr("foo") | rtrimstr("foo")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- bar\n- bar\n- 3\n",
+		},
+	},
+	{
+		description: "Starts with and ends with",
+		document:    `foobar`,
+		expression:  `[startswith("foo"), endswith("foo")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- true\n- false\n",
+		},
+	},
+	{
+		description:   "Starts with on a number",
+		skipDoc:       true,
+		document:      `3`,
+		expression:    `startswith("3")`,
+		expectedError: "cannot use startswith with !!int, can only operate on strings",
+	},
+	{
+		description:    "Split by a regex",
+		subdescription: "Returns each part of the string, split by the regex.",
+		document:       `"a, b,c"`,
+		expression:     `[splits(", *")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a\n- b\n- c\n",
+		},
+	},
+	{
+		description: "Split by a regex with flags",
+		skipDoc:     true,
+		document:    `a1b22c`,
+		expression:  `[splits("[0-9]+"; "g")]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a\n- b\n- c\n",
+		},
+	},
+	{
+		description:    "Explode a string into codepoints",
+		subdescription: "Without an argument, explode converts a string into an array of codepoints. `explode(exp)` still explodes anchors and aliases.",
+		document:       `héllo`,
+		expression:     `explode`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 104\n- 233\n- 108\n- 108\n- 111\n",
+		},
+	},
+	{
+		description: "Implode codepoints into a string",
+		document:    `[104, 233, 108, 108, 111]`,
+		expression:  `implode`,
+		expected: []string{
+			"D0, P[], (!!str)::héllo\n",
+		},
+	},
+	{
+		description: "Explode and implode",
+		skipDoc:     true,
+		document:    `{a: cat}`,
+		expression:  `.a |= (explode | reverse | implode)`,
+		expected: []string{
+			"D0, P[], (!!map)::{a: tac}\n",
+		},
+	},
+	{
+		description:   "Implode a bad codepoint",
+		skipDoc:       true,
+		document:      `[cat]`,
+		expression:    `implode`,
+		expectedError: "implode expects codepoints to be ints, but got !!str",
+	},
+	{
+		description: "Codepoint to ascii",
+		document:    `[65, 97]`,
+		expression:  `[.[] | ascii]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- A\n- a\n",
+		},
+	},
+	{
+		description:    "Ascii downcase",
+		subdescription: "Unlike `downcase`, only ascii letters are changed.",
+		document:       `ÀbC`,
+		expression:     `[ascii_downcase, downcase]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- Àbc\n- àbc\n",
+		},
+	},
+	{
+		description: "Ascii upcase",
+		skipDoc:     true,
+		document:    `àBc`,
+		expression:  `[ascii_upcase, upcase]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- àBC\n- ÀBC\n",
+		},
+	},
 }
 
 func TestStringsOperatorScenarios(t *testing.T) {
@@ -92,7 +92,7 @@ func subtractScalars(context Context, target *CandidateNode, lhs *CandidateNode,
 	}
 
 	if isDateTime {
-		return subtractDateTime(context.GetDateTimeLayout(), target, lhs, rhs)
+		return subtractDateTime(context, target, lhs, rhs)
 	} else if lhsTag == "!!str" {
 		return fmt.Errorf("strings cannot be subtracted")
 	} else if lhsTag == "!!int" && rhsTag == "!!int" {
@@ -130,25 +130,30 @@ func subtractScalars(context Context, target *CandidateNode, lhs *CandidateNode,
 	return nil
 }
 
-func subtractDateTime(layout string, target *CandidateNode, lhs *CandidateNode, rhs *CandidateNode) error {
-	var durationStr string
-	if strings.HasPrefix(rhs.Value, "-") {
-		durationStr = rhs.Value[1:]
-	} else {
-		durationStr = "-" + rhs.Value
-	}
-	duration, err := time.ParseDuration(durationStr)
+func subtractDateTime(context Context, target *CandidateNode, lhs *CandidateNode, rhs *CandidateNode) error {
+	layout := context.GetDateTimeLayout()
 
+	currentTime, err := parseDateTime(layout, lhs.Value)
 	if err != nil {
-		return fmt.Errorf("unable to parse duration [%v]: %w", rhs.Value, err)
+		return err
+	}
+	currentTime = inDateTimeLocation(currentTime, context.GetDateTimeLocation())
+
+	// subtracting two date times gives the duration between them
+	if rhs.Tag == "!!timestamp" || rhs.guessTagFromCustomType() == "!!str" {
+		if otherTime, err := parseDateTime(layout, rhs.Value); err == nil {
+			target.Tag = "!!str"
+			target.Value = currentTime.Sub(otherTime).String()
+			return nil
+		}
 	}
 
-	currentTime, err := parseDateTime(layout, lhs.Value)
+	duration, err := parseCalendarDuration(rhs.Value)
 	if err != nil {
-		return err
+		return fmt.Errorf("unable to parse duration [%v]: %w", rhs.Value, err)
 	}
 
-	newTime := currentTime.Add(duration)
+	newTime := duration.negate().addTo(currentTime)
 	target.Value = newTime.Format(layout)
 	return nil
 }
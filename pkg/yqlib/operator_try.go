@@ -0,0 +1,64 @@
+package yqlib
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+)
+
+// try <exp>
+// <exp>?
+// errors raised while evaluating the expression for an input are suppressed,
+// and that input produces no more results.
+func tryOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	return tryCatch(d, context, expressionNode.RHS, nil)
+}
+
+// try <exp> catch <handler>
+// lhs is the try operator, rhs is the handler. When an error is raised, the handler is
+// evaluated with the error message as its input.
+func catchOperator(d *dataTreeNavigator, context Context, expressionNode *ExpressionNode) (Context, error) {
+	if expressionNode.LHS.Operation.OperationType != tryOpType {
+		return Context{}, fmt.Errorf("catch must follow a try, e.g. `try <exp> catch <handler>`")
+	}
+	return tryCatch(d, context, expressionNode.LHS.RHS, expressionNode.RHS)
+}
+
+func tryCatch(d *dataTreeNavigator, context Context, tryExp *ExpressionNode, catchExp *ExpressionNode) (Context, error) {
+	if context.MatchingNodes.Len() == 0 {
+		return tryCatchSingle(d, context, tryExp, catchExp)
+	}
+
+	// evaluate each input separately, so that an error for one
+	// does not lose the results of the others.
+	results := list.New()
+	for el := context.MatchingNodes.Front(); el != nil; el = el.Next() {
+		result, err := tryCatchSingle(d, context.SingleChildContext(el.Value.(*CandidateNode)), tryExp, catchExp)
+		if err != nil {
+			return Context{}, err
+		}
+		results.PushBackList(result.MatchingNodes)
+	}
+	return context.ChildContext(results), nil
+}
+
+// tryCatchSingle evaluates unions and pipes step by step, like limit, so that the results
+// produced before an error are kept, e.g. `try (1, error("e"), 3) catch .` returns 1 and then "e".
+func tryCatchSingle(d *dataTreeNavigator, context Context, tryExp *ExpressionNode, catchExp *ExpressionNode) (Context, error) {
+	results := list.New()
+	err := appendFirstMatchingNodes(d, context, tryExp, math.MaxInt, results)
+	if err == nil {
+		return context.ChildContext(results), nil
+	}
+	log.Debugf("try caught error: %v", err)
+	if catchExp == nil {
+		return context.ChildContext(results), nil
+	}
+	errorNode := createScalarNode(err.Error(), err.Error())
+	caught, err := d.GetMatchingNodes(context.SingleChildContext(errorNode), catchExp)
+	if err != nil {
+		return Context{}, err
+	}
+	results.PushBackList(caught.MatchingNodes)
+	return context.ChildContext(results), nil
+}
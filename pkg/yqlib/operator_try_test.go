@@ -0,0 +1,106 @@
+package yqlib
+
+import (
+	"testing"
+)
+
+var tryOperatorScenarios = []expressionScenario{
+	{
+		description:    "Catch an error",
+		subdescription: "The handler is given the error message as its input.",
+		expression:     `try error("something went wrong") catch ("caught: " + .)`,
+		expected: []string{
+			"D0, P[], (!!str)::caught: something went wrong\n",
+		},
+	},
+	{
+		description:    "Skip entries that fail",
+		subdescription: "Without a `catch`, errors are suppressed and the input produces no results.",
+		document:       `[1, "cat", 3]`,
+		expression:     `map(try to_number)`,
+		expected: []string{
+			"D0, P[], (!!seq)::[1, 3]\n",
+		},
+	},
+	{
+		description:    "Error suppression postfix",
+		subdescription: "`<exp>?` is shorthand for `try <exp>`.",
+		document:       `[1, "cat", 3]`,
+		expression:     `.[] | to_number?`,
+		expected: []string{
+			"D0, P[0], (!!int)::1\n",
+			"D0, P[2], (!!int)::3\n",
+		},
+	},
+	{
+		description: "Provide a default on error",
+		document:    `version: latest`,
+		expression:  `.version |= ((to_number)? // 0)`,
+		expected: []string{
+			"D0, P[], (!!map)::version: 0\n",
+		},
+	},
+	{
+		description:    "Results before an error are kept",
+		subdescription: "Like jq, the results produced before the error are returned, followed by those of the handler.",
+		expression:     `try (1, error("e"), 3) catch .`,
+		expected: []string{
+			"D0, P[], (!!int)::1\n",
+			"D0, P[], (!!str)::e\n",
+		},
+	},
+	{
+		description: "Results before an error within a try pipe are kept",
+		skipDoc:     true,
+		document:    `[1, 2, 3]`,
+		expression:  `[try (.[] | select(. != 2) // error("two"))]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n",
+		},
+	},
+	{
+		description:   "Errors in the handler are not caught",
+		expression:    `try error("first") catch error("second: " + .)`,
+		expectedError: "second: first",
+	},
+	{
+		description: "Try without errors returns the results",
+		skipDoc:     true,
+		document:    `{a: {b: cat}}`,
+		expression:  `try .a.b catch "nope"`,
+		expected: []string{
+			"D0, P[a b], (!!str)::cat\n",
+		},
+	},
+	{
+		description: "Catch binds looser than pipe within the handler",
+		skipDoc:     true,
+		expression:  `try error("x") catch . | upcase`,
+		expected: []string{
+			"D0, P[], (!!str)::X\n",
+		},
+	},
+	{
+		description: "Try on multiple inputs only drops failing ones",
+		skipDoc:     true,
+		document:    `[a, 2]`,
+		expression:  `.[] | try to_number catch "bad"`,
+		expected: []string{
+			"D0, P[], (!!str)::bad\n",
+			"D0, P[1], (!!int)::2\n",
+		},
+	},
+	{
+		description:   "Catch without try",
+		skipDoc:       true,
+		expression:    `1 catch 2`,
+		expectedError: "catch must follow a try, e.g. `try <exp> catch <handler>`",
+	},
+}
+
+func TestTryOperatorScenarios(t *testing.T) {
+	for _, tt := range tryOperatorScenarios {
+		testScenario(t, &tt)
+	}
+	documentOperatorScenarios(t, "try-catch", tryOperatorScenarios)
+}
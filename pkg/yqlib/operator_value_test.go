@@ -69,6 +69,13 @@ var valueOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!int)::0xE\n",
 		},
 	},
+	{
+		document:   ``,
+		expression: `99999999999999999999`,
+		expected: []string{
+			"D0, P[], (!!float)::99999999999999999999\n",
+		},
+	},
 	{
 		document:   ``,
 		expression: `12`,
@@ -23,6 +23,165 @@ func useWithPipe(_ *dataTreeNavigator, _ Context, _ *ExpressionNode) (Context, e
 	return Context{}, fmt.Errorf("must use variable with a pipe, e.g. `exp as $x | ...`")
 }
 
+// getPatternAlternatives splits destructuring alternatives (e.g. `[$a] ?// {a: $a}`) into their patterns.
+func getPatternAlternatives(patternExp *ExpressionNode) []*ExpressionNode {
+	if patternExp.Operation.OperationType.Type != "DESTRUCTURE_ALTERNATIVE" {
+		return []*ExpressionNode{patternExp}
+	}
+	return append(getPatternAlternatives(patternExp.LHS), getPatternAlternatives(patternExp.RHS)...)
+}
+
+// getPatternElements flattens the union of elements in an array or object pattern.
+func getPatternElements(expressionNode *ExpressionNode) []*ExpressionNode {
+	if expressionNode == nil || expressionNode.Operation.OperationType.Type == "EMPTY" {
+		return []*ExpressionNode{}
+	} else if expressionNode.Operation.OperationType.Type != "UNION" {
+		return []*ExpressionNode{expressionNode}
+	}
+	return append(getPatternElements(expressionNode.LHS), getPatternElements(expressionNode.RHS)...)
+}
+
+// getPatternVariables returns the names of all the variables a pattern binds
+func getPatternVariables(patternExp *ExpressionNode) []string {
+	if patternExp == nil {
+		return []string{}
+	}
+	switch patternExp.Operation.OperationType.Type {
+	case "GET_VARIABLE":
+		return []string{patternExp.Operation.StringValue}
+	case "CREATE_MAP":
+		// only a variable key binds a name, other keys are just expressions
+		if patternExp.LHS.Operation.OperationType.Type == "GET_VARIABLE" {
+			return append(getPatternVariables(patternExp.LHS), getPatternVariables(patternExp.RHS)...)
+		}
+		return getPatternVariables(patternExp.RHS)
+	case "COLLECT", "COLLECT_OBJECT", "EMPTY":
+		return getPatternVariables(patternExp.RHS)
+	}
+	return append(getPatternVariables(patternExp.LHS), getPatternVariables(patternExp.RHS)...)
+}
+
+func isObjectPattern(patternExp *ExpressionNode) bool {
+	return patternExp.Operation.OperationType.Type == "SHORT_PIPE" &&
+		patternExp.RHS.Operation.OperationType.Type == "COLLECT_OBJECT"
+}
+
+func getPatternChild(value *CandidateNode, key string) *CandidateNode {
+	for i := 0; i+1 < len(value.Content); i = i + 2 {
+		if value.Content[i].Value == key {
+			return value.Content[i+1]
+		}
+	}
+	return createScalarNode(nil, "null")
+}
+
+// isBarePatternKey is true for keys like {name: $x} and {kind: $k}, which are literal
+// keys even when the name is also a function or operator.
+func isBarePatternKey(keyExp *ExpressionNode) bool {
+	if keyExp.LHS != nil || keyExp.RHS != nil {
+		return false
+	}
+	switch keyExp.Operation.OperationType.Type {
+	case "VALUE", "TRAVERSE_PATH":
+		return false
+	}
+	return wordRegex.MatchString(keyExp.Operation.StringValue)
+}
+
+func getPatternKey(d *dataTreeNavigator, context Context, value *CandidateNode, keyExp *ExpressionNode) (string, error) {
+	switch {
+	case keyExp.Operation.OperationType.Type == "GET_VARIABLE":
+		// {$name} uses the name as the key
+		return keyExp.Operation.StringValue, nil
+	case isBarePatternKey(keyExp):
+		return keyExp.Operation.StringValue, nil
+	}
+	keys, err := d.GetMatchingNodes(context.SingleReadonlyChildContext(value), keyExp)
+	if err != nil {
+		return "", err
+	} else if keys.MatchingNodes.Len() == 0 {
+		return "", fmt.Errorf("destructuring key expression returned nothing")
+	}
+	return keys.MatchingNodes.Front().Value.(*CandidateNode).Value, nil
+}
+
+// bindVariable binds the pattern on the RHS of an 'as' operator to the given value. Patterns may
+// be a variable name (e.g. `$x`), an array (e.g. `[$first, $second]`) or an object
+// (e.g. `{name: $n, spec: {replicas: $r}}`).
+// When given alternatives (e.g. `[$a] ?// {a: $a}`), the first pattern that matches the value is used.
+func bindVariable(d *dataTreeNavigator, context *Context, patternExp *ExpressionNode, value *CandidateNode) error {
+	alternatives := getPatternAlternatives(patternExp)
+	if len(alternatives) > 1 {
+		var err error
+		for _, alternative := range alternatives {
+			clearPatternVariables(context, patternExp)
+			if err = bindPattern(d, context, alternative, value); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+	return bindPattern(d, context, patternExp, value)
+}
+
+// clearPatternVariables sets all the variables of a pattern to null, so that when
+// using alternatives, the variables that are not in the matching pattern are still defined.
+func clearPatternVariables(context *Context, patternExp *ExpressionNode) {
+	for _, name := range getPatternVariables(patternExp) {
+		context.SetVariable(name, createScalarNode(nil, "null").AsList())
+	}
+}
+
+func bindPattern(d *dataTreeNavigator, context *Context, patternExp *ExpressionNode, value *CandidateNode) error {
+	switch {
+	case patternExp.Operation.OperationType.Type == "GET_VARIABLE":
+		context.SetVariable(patternExp.Operation.StringValue, value.AsList())
+		return nil
+
+	case patternExp.Operation.OperationType.Type == "COLLECT":
+		if value.Kind != SequenceNode && value.Tag != "!!null" {
+			return fmt.Errorf("cannot destructure %v with an array pattern", value.Tag)
+		}
+		for i, element := range getPatternElements(patternExp.RHS) {
+			child := createScalarNode(nil, "null")
+			if i < len(value.Content) {
+				child = value.Content[i]
+			}
+			if err := bindPattern(d, context, element, child); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case isObjectPattern(patternExp):
+		if value.Kind != MappingNode && value.Tag != "!!null" {
+			return fmt.Errorf("cannot destructure %v with an object pattern", value.Tag)
+		}
+		for _, entry := range getPatternElements(patternExp.LHS) {
+			keyExp, entryPattern := entry, entry
+			if entry.Operation.OperationType.Type == "CREATE_MAP" {
+				keyExp, entryPattern = entry.LHS, entry.RHS
+			} else if entry.Operation.OperationType.Type != "GET_VARIABLE" {
+				return fmt.Errorf("object patterns must be of the form {key: $name} or {$name}")
+			}
+			key, err := getPatternKey(d, *context, value, keyExp)
+			if err != nil {
+				return err
+			}
+			child := getPatternChild(value, key)
+			// {$name: pattern} binds $name as well as the pattern
+			if keyExp != entryPattern && keyExp.Operation.OperationType.Type == "GET_VARIABLE" {
+				context.SetVariable(keyExp.Operation.StringValue, child.AsList())
+			}
+			if err := bindPattern(d, context, entryPattern, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("RHS of 'as' operator must be a variable name (e.g. $foo) or a destructuring pattern (e.g. [$a, $b])")
+}
+
 // variables are like loops in jq
 // https://stedolan.github.io/jq/manual/#Variable
 func variableLoop(d *dataTreeNavigator, context Context, originalExp *ExpressionNode) (Context, error) {
@@ -56,35 +215,42 @@ func variableLoopSingleChild(d *dataTreeNavigator, context Context, originalExp
 	if err != nil {
 		return Context{}, err
 	}
-	if variableExp.RHS.Operation.OperationType.Type != "GET_VARIABLE" {
-		return Context{}, fmt.Errorf("RHS of 'as' operator must be a variable name e.g. $foo")
-	}
-	variableName := variableExp.RHS.Operation.StringValue
-
 	prefs := variableExp.Operation.Preferences.(assignVarPreferences)
 
 	results := list.New()
 
+	alternatives := getPatternAlternatives(variableExp.RHS)
+
 	// now we loop over lhs, set variable to each result and calculate originalExp.Rhs
 	for el := lhs.MatchingNodes.Front(); el != nil; el = el.Next() {
 		log.Debugf("PROCESSING VARIABLE: %v", NodeToString(el.Value.(*CandidateNode)))
-		var variableValue = list.New()
-		if prefs.IsReference {
-			variableValue.PushBack(el.Value)
-		} else {
-			candidateCopy := el.Value.(*CandidateNode).Copy()
-			variableValue.PushBack(candidateCopy)
+		variableValue := el.Value.(*CandidateNode)
+		if !prefs.IsReference {
+			variableValue = variableValue.Copy()
 		}
-		newContext := context.ChildContext(context.MatchingNodes)
-		newContext.SetVariable(variableName, variableValue)
-
-		rhs, err := d.GetMatchingNodes(newContext, originalExp.RHS)
 
-		if err != nil {
-			return Context{}, err
+		// with destructuring alternatives, if binding the pattern or evaluating
+		// the expression fails then the next alternative is tried.
+		for i, pattern := range alternatives {
+			newContext := context.ChildContext(context.MatchingNodes)
+			if len(alternatives) > 1 {
+				clearPatternVariables(&newContext, variableExp.RHS)
+			}
+			err := bindPattern(d, &newContext, pattern, variableValue)
+			var rhs Context
+			if err == nil {
+				rhs, err = d.GetMatchingNodes(newContext, originalExp.RHS)
+			}
+			if err != nil && i < len(alternatives)-1 {
+				log.Debugf("destructuring alternative %v failed: %v", i, err)
+				continue
+			} else if err != nil {
+				return Context{}, err
+			}
+			log.Debugf("PROCESSING VARIABLE DONE, got back: %v", rhs.MatchingNodes.Len())
+			results.PushBackList(rhs.MatchingNodes)
+			break
 		}
-		log.Debugf("PROCESSING VARIABLE DONE, got back: %v", rhs.MatchingNodes.Len())
-		results.PushBackList(rhs.MatchingNodes)
 	}
 
 	// if there is no LHS - then I guess we just calculate originalExp.Rhs
@@ -88,6 +88,144 @@ var variableOperatorScenarios = []expressionScenario{
 			"D0, P[], (!!map)::a: {b: \"new\", c: something}\n",
 		},
 	},
+	{
+		description: "Destructure an object",
+		document:    `{name: web, spec: {replicas: 3}}`,
+		expression:  `. as {name: $n, spec: {replicas: $r}} | $n + " has " + ($r | to_string) + " replicas"`,
+		expected: []string{
+			"D0, P[name], (!!str)::web has 3 replicas\n",
+		},
+	},
+	{
+		description:    "Destructure an object using the key names",
+		subdescription: "`{$name}` is short for `{name: $name}`, and `{$spec: {...}}` binds `$spec` as well as destructuring it.",
+		document:       `{name: web, spec: {replicas: 3}}`,
+		expression:     `. as {$name, $spec: {replicas: $r}} | [$name, $spec.replicas, $r]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- web\n- 3\n- 3\n",
+		},
+	},
+	{
+		description:    "Destructure an object with keys that are also operator names",
+		subdescription: "Bare names are always used as literal keys, even when they are the names of operators like `kind` and `key`.",
+		document:       `{kind: Deployment, key: k1, metadata: {name: web}}`,
+		expression:     `. as {kind: $k, key: $key, metadata: {name: $n}} | [$k, $key, $n]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- Deployment\n- k1\n- web\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `{type: a, tag: b, length: c}`,
+		expression: `. as {type: $t, tag: $g, length: $l} | [$t, $g, $l]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a\n- b\n- c\n",
+		},
+	},
+	{
+		description:    "Destructure an object with expression keys",
+		subdescription: "Keys can be quoted strings, or expressions in brackets that are evaluated against the value being destructured.",
+		document:       `{key: "a b", "a b": cat}`,
+		expression:     `. as {"key": $k, (.key): $v} | [$k, $v]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- \"a b\"\n- cat\n",
+		},
+	},
+	{
+		description:    "Destructure an array",
+		subdescription: "Missing elements are null.",
+		document:       `[a, b]`,
+		expression:     `. as [$first, $second, $third] | [$first, $second, $third]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- a\n- b\n- null\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `{a: [{b: 1}, {b: 2}]}`,
+		expression: `. as {a: [$x, {b: $y}]} | [$x.b, $y]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 1\n- 2\n",
+		},
+	},
+	{
+		skipDoc:       true,
+		document:      `{a: 1}`,
+		expression:    `. as [$x] | $x`,
+		expectedError: "cannot destructure !!map with an array pattern",
+	},
+	{
+		skipDoc:       true,
+		document:      `[1]`,
+		expression:    `. as {a: $x} | $x`,
+		expectedError: "cannot destructure !!seq with an object pattern",
+	},
+	{
+		skipDoc:       true,
+		document:      `[1]`,
+		expression:    `. as 3 | .`,
+		expectedError: "RHS of 'as' operator must be a variable name (e.g. $foo) or a destructuring pattern (e.g. [$a, $b])",
+	},
+	{
+		description:    "Destructuring alternatives",
+		subdescription: "`?//` tries each pattern in turn, using the first that matches (and evaluates without an error). Variables that are not in the matching pattern are null.",
+		document:       `[{name: a}, [b], c]`,
+		expression:     `.[] as {$name} ?// [$name] ?// $name | $name`,
+		expected: []string{
+			"D0, P[0 name], (!!str)::a\n",
+			"D0, P[1 0], (!!str)::b\n",
+			"D0, P[2], (!!str)::c\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[[1], {a: 2}]`,
+		expression: `[.[] as [$x] ?// {a: $y} | [$x, $y]]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- - 1\n  - null\n- - null\n  - 2\n",
+		},
+	},
+	{
+		skipDoc:       true,
+		description:   "alternative is used when the expression errors",
+		document:      `[[cat]]`,
+		expression:    `.[] as [$x] ?// $x | $x | to_number`,
+		expectedError: "cannot convert node at path [0] of tag !!seq to number",
+	},
+	{
+		description:    "Destructuring alternatives when the expression fails",
+		subdescription: "If the expression errors, the next alternative is tried.",
+		document:       `{a: cat, b: "3"}`,
+		expression:     `. as {a: $v} ?// {b: $v} | $v | to_number`,
+		expected: []string{
+			"D0, P[b], (!!int)::3\n",
+		},
+	},
+	{
+		description:    "Destructure with ref",
+		subdescription: "The variables reference the original nodes, so they can be updated.",
+		document:       `{spec: {replicas: 3}}`,
+		expression:     `.spec ref {replicas: $r} | $r = 5`,
+		expected: []string{
+			"D0, P[], (!!map)::{spec: {replicas: 5}}\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[{a: 1, b: 2}, {a: 3, b: 4}]`,
+		expression: `.[] as {a: $x, b: $y} ireduce (0; . + $x * $y)`,
+		expected: []string{
+			"D0, P[], (!!int)::14\n",
+		},
+	},
+	{
+		skipDoc:    true,
+		document:   `[[1, 2], [3, 4]]`,
+		expression: `[foreach .[] as [$x, $y] (0; . + $x * $y)]`,
+		expected: []string{
+			"D0, P[], (!!seq)::- 2\n- 14\n",
+		},
+	},
 }
 
 func TestVariableOperatorScenarios(t *testing.T) {
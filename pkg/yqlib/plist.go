@@ -0,0 +1,19 @@
+package yqlib
+
+type PlistPreferences struct {
+	Binary bool
+}
+
+func NewDefaultPlistPreferences() PlistPreferences {
+	return PlistPreferences{
+		Binary: false,
+	}
+}
+
+func (p *PlistPreferences) Copy() PlistPreferences {
+	return PlistPreferences{
+		Binary: p.Binary,
+	}
+}
+
+var ConfiguredPlistPreferences = NewDefaultPlistPreferences()
@@ -0,0 +1,259 @@
+//go:build !yq_noplist
+
+package yqlib
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+const samplePlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>Cat &amp; Dog</string>
+	<key>CFBundleVersion</key>
+	<integer>42</integer>
+	<key>Scale</key>
+	<real>1.5</real>
+	<key>Enabled</key>
+	<true/>
+	<key>Created</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>Icon</key>
+	<data>
+	aGVsbG8gd29y
+	bGQ=
+	</data>
+	<key>Tags</key>
+	<array>
+		<string>cat</string>
+		<dict/>
+		<array/>
+	</array>
+</dict>
+</plist>
+`
+
+const samplePlistYaml = `CFBundleName: Cat & Dog
+CFBundleVersion: 42
+Scale: 1.5
+Enabled: true
+Created: 2024-01-02T03:04:05Z
+Icon: !!binary aGVsbG8gd29ybGQ=
+Tags:
+  - cat
+  - {}
+  - []
+`
+
+const expectedPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>Cat &amp; Dog</string>
+	<key>CFBundleVersion</key>
+	<integer>42</integer>
+	<key>Scale</key>
+	<real>1.5</real>
+	<key>Enabled</key>
+	<true/>
+	<key>Created</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>Icon</key>
+	<data>aGVsbG8gd29ybGQ=</data>
+	<key>Tags</key>
+	<array>
+		<string>cat</string>
+		<dict/>
+		<array/>
+	</array>
+</dict>
+</plist>
+`
+
+var plistScenarios = []formatScenario{
+	{
+		description:    "Parse plist",
+		subdescription: "Dates are decoded as timestamps, and data as base64 encoded binary.",
+		input:          samplePlist,
+		expected:       samplePlistYaml,
+		scenarioType:   "decode",
+	},
+	{
+		description:  "Encode plist",
+		input:        samplePlistYaml,
+		expected:     expectedPlist,
+		scenarioType: "encode",
+	},
+	{
+		description:    "Update a plist",
+		subdescription: "The plist is written back in the canonical format.",
+		input:          "<plist version=\"1.0\"><dict><key>CFBundleVersion</key><integer>41</integer></dict></plist>",
+		expression:     `.CFBundleVersion += 1 | .CFBundleShortVersionString = "1.0." + (.CFBundleVersion | tostring)`,
+		expected:       "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE plist PUBLIC \"-//Apple//DTD PLIST 1.0//EN\" \"http://www.apple.com/DTDs/PropertyList-1.0.dtd\">\n<plist version=\"1.0\">\n<dict>\n\t<key>CFBundleVersion</key>\n\t<integer>42</integer>\n\t<key>CFBundleShortVersionString</key>\n\t<string>1.0.42</string>\n</dict>\n</plist>\n",
+		scenarioType:   "roundtrip",
+	},
+	{
+		description:  "Roundtrip plist",
+		skipDoc:      true,
+		input:        expectedPlist,
+		expected:     expectedPlist,
+		scenarioType: "roundtrip",
+	},
+	{
+		description:  "Roundtrip binary plist",
+		skipDoc:      true,
+		input:        samplePlistYaml,
+		expected:     samplePlistYaml,
+		scenarioType: "binary-roundtrip",
+	},
+	{
+		description:  "Roundtrip binary plist with large counts and unicode",
+		skipDoc:      true,
+		input:        "a: [" + strings.Repeat("-1, 300, 70000, 5000000000, ", 5) + "ünïcode, a long string over fifteen chars]\nb: !!binary AAECAwQFBgcICQoLDA0ODxAR\n",
+		expected:     "a:\n" + strings.Repeat("  - -1\n  - 300\n  - 70000\n  - 5000000000\n", 5) + "  - ünïcode\n  - a long string over fifteen chars\nb: !!binary AAECAwQFBgcICQoLDA0ODxAR\n",
+		scenarioType: "binary-roundtrip",
+	},
+	{
+		description:  "Special reals",
+		skipDoc:      true,
+		input:        "[.inf, -.inf, .nan, 2e10]",
+		expected:     "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE plist PUBLIC \"-//Apple//DTD PLIST 1.0//EN\" \"http://www.apple.com/DTDs/PropertyList-1.0.dtd\">\n<plist version=\"1.0\">\n<array>\n\t<real>+infinity</real>\n\t<real>-infinity</real>\n\t<real>nan</real>\n\t<real>2e+10</real>\n</array>\n</plist>\n",
+		scenarioType: "encode",
+	},
+	{
+		description:  "Plist value without a plist element",
+		skipDoc:      true,
+		input:        "<array><integer>-3</integer><real>2</real><false/></array>",
+		expected:     "- -3\n- 2.0\n- false\n",
+		scenarioType: "decode",
+	},
+	{
+		description:   "Null is not supported",
+		skipDoc:       true,
+		input:         "a: null",
+		expectedError: "plist does not support null (a)",
+		scenarioType:  "encode-error",
+	},
+	{
+		description:   "Unknown element",
+		skipDoc:       true,
+		input:         "<plist><dict><key>a</key><cat/></dict></plist>",
+		expectedError: "bad file 'sample.yml': unknown plist element <cat>",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Not a plist",
+		skipDoc:       true,
+		input:         "cat",
+		expectedError: "bad file 'sample.yml': failed to parse plist: no plist element found",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Truncated binary plist",
+		skipDoc:       true,
+		input:         "bplist00\x08",
+		expectedError: "bad file 'sample.yml': invalid binary plist",
+		scenarioType:  "decode-error",
+	},
+}
+
+func binaryPlistPreferences() PlistPreferences {
+	prefs := NewDefaultPlistPreferences()
+	prefs.Binary = true
+	return prefs
+}
+
+func testPlistScenario(t *testing.T, s formatScenario) {
+	switch s.scenarioType {
+	case "decode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewPlistDecoder(), NewYamlEncoder(ConfiguredYamlPreferences)), s.description)
+	case "encode":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewPlistEncoder(NewDefaultPlistPreferences())), s.description)
+	case "roundtrip":
+		test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewPlistDecoder(), NewPlistEncoder(NewDefaultPlistPreferences())), s.description)
+	case "binary-roundtrip":
+		binary := mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewPlistEncoder(binaryPlistPreferences()))
+		test.AssertResultWithContext(t, true, strings.HasPrefix(binary, plistBinaryHeader), s.description)
+		decoded := mustProcessFormatScenario(formatScenario{input: binary}, NewPlistDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		test.AssertResultWithContext(t, s.expected, decoded, s.description)
+	case "decode-error", "encode-error":
+		var result string
+		var err error
+		if s.scenarioType == "decode-error" {
+			result, err = processFormatScenario(s, NewPlistDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+		} else {
+			result, err = processFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewPlistEncoder(NewDefaultPlistPreferences()))
+		}
+		if err == nil {
+			t.Errorf("Expected error '%v' but it worked: %v", s.expectedError, result)
+		} else {
+			test.AssertResultWithContext(t, true, strings.HasPrefix(err.Error(), s.expectedError),
+				fmt.Sprintf("Expected [%v] to start with [%v]", err.Error(), s.expectedError))
+		}
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func documentPlistScenario(_ *testing.T, w *bufio.Writer, i interface{}) {
+	s := i.(formatScenario)
+	if s.skipDoc {
+		return
+	}
+	writeOrPanic(w, fmt.Sprintf("## %v\n", s.description))
+
+	if s.subdescription != "" {
+		writeOrPanic(w, s.subdescription)
+		writeOrPanic(w, "\n\n")
+	}
+
+	expression := s.expression
+	if expression == "" {
+		expression = "."
+	}
+
+	switch s.scenarioType {
+	case "decode":
+		writeOrPanic(w, "Given a sample.plist file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```xml\n%v```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=yaml '%v' sample.plist\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n\n", mustProcessFormatScenario(s, NewPlistDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))))
+	case "encode":
+		writeOrPanic(w, "Given a sample.yml file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```yaml\n%v```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=plist '%v' sample.yml\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```xml\n%v```\n\n", mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewPlistEncoder(NewDefaultPlistPreferences()))))
+	case "roundtrip":
+		writeOrPanic(w, "Given a sample.plist file of:\n")
+		writeOrPanic(w, fmt.Sprintf("```xml\n%v\n```\n", s.input))
+		writeOrPanic(w, "then\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq '%v' sample.plist\n```\n", expression))
+		writeOrPanic(w, "will output\n")
+		writeOrPanic(w, fmt.Sprintf("```xml\n%v```\n\n", mustProcessFormatScenario(s, NewPlistDecoder(), NewPlistEncoder(NewDefaultPlistPreferences()))))
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func TestPlistScenarios(t *testing.T) {
+	for _, tt := range plistScenarios {
+		testPlistScenario(t, tt)
+	}
+	genericScenarios := make([]interface{}, len(plistScenarios))
+	for i, s := range plistScenarios {
+		genericScenarios[i] = s
+	}
+	documentScenarios(t, "usage", "plist", genericScenarios, documentPlistScenario)
+}
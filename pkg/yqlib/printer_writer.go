@@ -40,8 +40,14 @@ func NewMultiPrinterWriter(expression *ExpressionNode, format *Format) PrinterWr
 	switch format {
 	case JSONFormat:
 		extension = "json"
+	case JSONLFormat:
+		extension = "jsonl"
 	case PropertiesFormat:
 		extension = "properties"
+	case CBORFormat:
+		extension = "cbor"
+	case MsgpackFormat:
+		extension = "msgpack"
 	}
 
 	return &multiPrintWriter{
@@ -1,8 +1,9 @@
 package yqlib
 
 type ShellVariablesPreferences struct {
-	KeySeparator string
-	UnwrapScalar bool
+	KeySeparator  string
+	UnwrapScalar  bool
+	UnflattenKeys bool
 }
 
 func NewDefaultShellVariablesPreferences() ShellVariablesPreferences {
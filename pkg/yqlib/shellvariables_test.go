@@ -3,6 +3,7 @@ package yqlib
 import (
 	"bufio"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mikefarah/yq/v4/test"
@@ -67,20 +68,183 @@ var shellVariablesScenarios = []formatScenario{
 			"my_app__db_config__port=5432" + "\n",
 		scenarioType: "shell-separator",
 	},
+	{
+		description:    "Decode shell variables",
+		subdescription: "Quotes are removed and comments are kept. Like environment variables, all values are strings.",
+		input: "" +
+			"# database settings" + "\n" +
+			"export DB_HOST=localhost" + "\n" +
+			"DB_PORT=5432 # default port" + "\n" +
+			"GREETING=\"Hello, \\\"world\\\"\"" + "\n" +
+			"NAME='Miles O'\"'\"'Brien'" + "\n",
+		expected: "" +
+			"# database settings" + "\n" +
+			"DB_HOST: localhost" + "\n" +
+			"DB_PORT: \"5432\" # default port" + "\n" +
+			"GREETING: Hello, \"world\"" + "\n" +
+			"NAME: Miles O'Brien" + "\n",
+		scenarioType: "decode",
+	},
+	{
+		description:    "Decode shell variables: ANSI-C quoting and line continuations",
+		subdescription: "$'...' values support C style escapes, and a backslash at the end of a line continues the value on the next line.",
+		input: "" +
+			"MESSAGE=$'caf\\u00e9\\nit\\'s open'" + "\n" +
+			"COMMAND=run\\" + "\n" +
+			"--verbose" + "\n",
+		expected: "" +
+			"MESSAGE: |-" + "\n" +
+			"  café" + "\n" +
+			"  it's open" + "\n" +
+			"COMMAND: run--verbose" + "\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Decode shell variables: comments after the last variable",
+		skipDoc:      true,
+		input:        "A=1\n\n# foot\n",
+		expected:     "A: \"1\"\n# foot\n",
+		scenarioType: "decode",
+	},
+	{
+		description:  "Decode empty shell variables",
+		skipDoc:      true,
+		input:        "A=\nB=''\n",
+		expected:     "A: \"\"\nB: \"\"\n",
+		scenarioType: "decode",
+	},
+	{
+		description:    "Decode shell variables: un-flatten keys",
+		subdescription: "Use --shell-unflatten to split the variable names on the key separator into nested maps and arrays.",
+		input: "" +
+			"name='Mike Wazowski'" + "\n" +
+			"eyes_color=turquoise" + "\n" +
+			"eyes_number=1" + "\n" +
+			"friends_0='James P. Sullivan'" + "\n" +
+			"friends_1='Celia Mae'" + "\n",
+		expected: "" +
+			"name: Mike Wazowski" + "\n" +
+			"eyes:" + "\n" +
+			"  color: turquoise" + "\n" +
+			"  number: \"1\"" + "\n" +
+			"friends:" + "\n" +
+			"  - James P. Sullivan" + "\n" +
+			"  - Celia Mae" + "\n",
+		scenarioType: "decode-unflatten",
+	},
+	{
+		description:  "Decode shell variables: un-flatten sparse array",
+		skipDoc:      true,
+		input:        "a_2=c\na_0=a\n",
+		expected:     "a:\n  - a\n  - null\n  - c\n",
+		scenarioType: "decode-unflatten",
+	},
+	{
+		description:   "Decode shell variables: un-flatten index too large",
+		skipDoc:       true,
+		input:         "a_0=x\na_1002=y\n",
+		expectedError: "cannot un-flatten a_1002: array index 1002 is more than 1000 past the end of the array",
+		scenarioType:  "decode-unflatten-error",
+	},
+	{
+		description:   "Decode shell variables: un-flatten conflict",
+		skipDoc:       true,
+		input:         "a=1\na_b=2\n",
+		expectedError: "cannot un-flatten a_b, a is already set",
+		scenarioType:  "decode-unflatten-error",
+	},
+	{
+		description:   "Decode shell variables: duplicate",
+		skipDoc:       true,
+		input:         "a=1\na=2\n",
+		expectedError: "a is set more than once",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Decode shell variables: missing quote",
+		skipDoc:       true,
+		input:         "a=1\nb='2\n",
+		expectedError: "line 2: missing closing ' quote",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Decode shell variables: invalid name",
+		skipDoc:       true,
+		input:         "1a=1\n",
+		expectedError: "line 1: '1a' is not a valid variable name",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:   "Decode shell variables: unquoted space",
+		skipDoc:       true,
+		input:         "a=b c\n",
+		expectedError: "line 1: unexpected 'c' after the value of a",
+		scenarioType:  "decode-error",
+	},
+	{
+		description:    "Round trip shell variables",
+		subdescription: "Un-flattened variables are flattened again when encoding.",
+		input: "" +
+			"export app_name='my app'" + "\n" +
+			"app_ports_0=80" + "\n" +
+			"app_ports_1=443" + "\n",
+		expected: "" +
+			"app_name='my app'" + "\n" +
+			"app_ports_0=80" + "\n" +
+			"app_ports_1=443" + "\n",
+		scenarioType: "roundtrip",
+	},
+}
+
+func shellVariablesPreferencesFor(s formatScenario) ShellVariablesPreferences {
+	prefs := ConfiguredShellVariablesPreferences
+	switch s.scenarioType {
+	case "shell-separator":
+		prefs.KeySeparator = "__"
+	case "decode-unflatten", "decode-unflatten-error", "roundtrip":
+		prefs.UnflattenKeys = true
+	}
+	return prefs
+}
+
+func processShellVariableScenario(s formatScenario) (string, error) {
+	// Save and restore the original preferences
+	originalPreferences := ConfiguredShellVariablesPreferences
+	ConfiguredShellVariablesPreferences = shellVariablesPreferencesFor(s)
+	defer func() { ConfiguredShellVariablesPreferences = originalPreferences }()
+
+	switch s.scenarioType {
+	case "", "shell-separator":
+		return processFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewShellVariablesEncoder())
+	case "decode", "decode-unflatten", "decode-error", "decode-unflatten-error":
+		return processFormatScenario(s, NewShellVariablesDecoder(), NewYamlEncoder(ConfiguredYamlPreferences))
+	case "roundtrip":
+		return processFormatScenario(s, NewShellVariablesDecoder(), NewShellVariablesEncoder())
+	default:
+		panic(fmt.Sprintf("unhandled scenario type %q", s.scenarioType))
+	}
+}
+
+func mustProcessShellVariableScenario(s formatScenario) string {
+	result, err := processShellVariableScenario(s)
+	if err != nil {
+		panic(err)
+	}
+	return result
 }
 
 func TestShellVariableScenarios(t *testing.T) {
 	for _, s := range shellVariablesScenarios {
-		//fmt.Printf("\t<%s> <%s>\n", s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewShellVariablesEncoder()))
-		if s.scenarioType == "shell-separator" {
-			// Save and restore the original separator
-			originalSeparator := ConfiguredShellVariablesPreferences.KeySeparator
-			ConfiguredShellVariablesPreferences.KeySeparator = "__"
-			test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewShellVariablesEncoder()), s.description)
-			ConfiguredShellVariablesPreferences.KeySeparator = originalSeparator
-		} else {
-			test.AssertResultWithContext(t, s.expected, mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewShellVariablesEncoder()), s.description)
+		if s.expectedError != "" {
+			result, err := processShellVariableScenario(s)
+			if err == nil {
+				t.Errorf("Expected error '%v' but it worked: %v", s.expectedError, result)
+			} else {
+				test.AssertResultComplexWithContext(t, "bad file 'sample.yml': "+s.expectedError, err.Error(), s.description)
+			}
+			continue
 		}
+		test.AssertResultWithContext(t, s.expected, mustProcessShellVariableScenario(s), s.description)
 	}
 	genericScenarios := make([]interface{}, len(shellVariablesScenarios))
 	for i, s := range shellVariablesScenarios {
@@ -101,29 +265,28 @@ func documentShellVariableScenario(_ *testing.T, w *bufio.Writer, i interface{})
 		writeOrPanic(w, "\n\n")
 	}
 
-	writeOrPanic(w, "Given a sample.yml file of:\n")
-	writeOrPanic(w, fmt.Sprintf("```yaml\n%v\n```\n", s.input))
+	inputFile, inputFormat, flags, outputFormat := "sample.yml", "yaml", "-o=shell", "sh"
+	switch s.scenarioType {
+	case "shell-separator":
+		flags = "-o=shell --shell-key-separator=\"__\""
+	case "decode":
+		inputFile, inputFormat, flags, outputFormat = "sample.env", "sh", "-p=shell", "yaml"
+	case "decode-unflatten":
+		inputFile, inputFormat, flags, outputFormat = "sample.env", "sh", "-p=shell --shell-unflatten", "yaml"
+	case "roundtrip":
+		inputFile, inputFormat, flags = "sample.env", "sh", "-p=shell -o=shell --shell-unflatten"
+	}
+
+	writeOrPanic(w, fmt.Sprintf("Given a %v file of:\n", inputFile))
+	writeOrPanic(w, fmt.Sprintf("```%v\n%v\n```\n", inputFormat, strings.TrimSuffix(s.input, "\n")))
 
 	writeOrPanic(w, "then\n")
 
-	expression := s.expression
-
-	if s.scenarioType == "shell-separator" {
-		writeOrPanic(w, "```bash\nyq -o=shell --shell-key-separator=\"__\" sample.yml\n```\n")
-	} else if expression != "" {
-		writeOrPanic(w, fmt.Sprintf("```bash\nyq -o=shell '%v' sample.yml\n```\n", expression))
+	if s.expression != "" {
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq %v '%v' %v\n```\n", flags, s.expression, inputFile))
 	} else {
-		writeOrPanic(w, "```bash\nyq -o=shell sample.yml\n```\n")
+		writeOrPanic(w, fmt.Sprintf("```bash\nyq %v %v\n```\n", flags, inputFile))
 	}
 	writeOrPanic(w, "will output\n")
-
-	if s.scenarioType == "shell-separator" {
-		// Save and restore the original separator
-		originalSeparator := ConfiguredShellVariablesPreferences.KeySeparator
-		ConfiguredShellVariablesPreferences.KeySeparator = "__"
-		writeOrPanic(w, fmt.Sprintf("```sh\n%v```\n\n", mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewShellVariablesEncoder())))
-		ConfiguredShellVariablesPreferences.KeySeparator = originalSeparator
-	} else {
-		writeOrPanic(w, fmt.Sprintf("```sh\n%v```\n\n", mustProcessFormatScenario(s, NewYamlDecoder(ConfiguredYamlPreferences), NewShellVariablesEncoder())))
-	}
+	writeOrPanic(w, fmt.Sprintf("```%v\n%v```\n\n", outputFormat, mustProcessShellVariableScenario(s)))
 }
@@ -19,10 +19,16 @@ type StreamEvaluator interface {
 type streamEvaluator struct {
 	treeNavigator DataTreeNavigator
 	fileIndex     int
+	variables     Variables
 }
 
 func NewStreamEvaluator() StreamEvaluator {
-	return &streamEvaluator{treeNavigator: NewDataTreeNavigator()}
+	return NewStreamEvaluatorWithVariables(nil)
+}
+
+// NewStreamEvaluatorWithVariables creates an evaluator where the given variables are available to the expression, e.g. $name
+func NewStreamEvaluatorWithVariables(variables Variables) StreamEvaluator {
+	return &streamEvaluator{treeNavigator: NewDataTreeNavigator(), variables: variables}
 }
 
 func (s *streamEvaluator) EvaluateNew(expression string, printer Printer) error {
@@ -34,7 +40,7 @@ func (s *streamEvaluator) EvaluateNew(expression string, printer Printer) error
 	inputList := list.New()
 	inputList.PushBack(candidateNode)
 
-	result, errorParsing := s.treeNavigator.GetMatchingNodes(Context{MatchingNodes: inputList}, node)
+	result, errorParsing := s.treeNavigator.GetMatchingNodes(newRootContext(inputList, s.variables), node)
 	if errorParsing != nil {
 		return errorParsing
 	}
@@ -94,7 +100,7 @@ func (s *streamEvaluator) Evaluate(filename string, reader io.Reader, node *Expr
 		inputList := list.New()
 		inputList.PushBack(candidateNode)
 
-		result, errorParsing := s.treeNavigator.GetMatchingNodes(Context{MatchingNodes: inputList}, node)
+		result, errorParsing := s.treeNavigator.GetMatchingNodes(newRootContext(inputList, s.variables), node)
 		if errorParsing != nil {
 			return currentIndex, errorParsing
 		}
@@ -14,11 +14,18 @@ type StringEvaluator interface {
 
 type stringEvaluator struct {
 	treeNavigator DataTreeNavigator
+	variables     Variables
 }
 
 func NewStringEvaluator() StringEvaluator {
+	return NewStringEvaluatorWithVariables(nil)
+}
+
+// NewStringEvaluatorWithVariables creates an evaluator where the given variables are available to the expression, e.g. $name
+func NewStringEvaluatorWithVariables(variables Variables) StringEvaluator {
 	return &stringEvaluator{
 		treeNavigator: NewDataTreeNavigator(),
+		variables:     variables,
 	}
 }
 
@@ -32,7 +39,7 @@ func (s *stringEvaluator) EvaluateAll(expression string, input string, encoder E
 		return "", err
 	}
 
-	evaluator := NewAllAtOnceEvaluator()
+	evaluator := NewAllAtOnceEvaluatorWithVariables(s.variables)
 	if results, err = evaluator.EvaluateCandidateNodes(expression, documents); err != nil {
 		return "", err
 	}
@@ -58,7 +65,7 @@ func (s *stringEvaluator) Evaluate(expression string, input string, encoder Enco
 	}
 
 	reader := bufio.NewReader(strings.NewReader(input))
-	evaluator := NewStreamEvaluator()
+	evaluator := NewStreamEvaluatorWithVariables(s.variables)
 	if _, err := evaluator.Evaluate("", reader, node, printer, decoder); err != nil {
 		return "", err
 	}
@@ -42,3 +42,25 @@ func TestStringEvaluator_Evaluate_Nominal(t *testing.T) {
 		test.AssertResult(t, expected_output, result)
 	}
 }
+
+func TestStringEvaluator_WithVariables(t *testing.T) {
+	expected_output := "a: cat\n"
+	variables := Variables{"name": NewStringVariable("cat")}
+	encoder := NewYamlEncoder(ConfiguredYamlPreferences)
+	decoder := NewYamlDecoder(ConfiguredYamlPreferences)
+
+	evaluator := NewStringEvaluatorWithVariables(variables)
+	result, err := evaluator.Evaluate(".a = $name", "a: dog\n", encoder, decoder)
+	if err != nil {
+		t.Error(err)
+	} else {
+		test.AssertResult(t, expected_output, result)
+	}
+
+	result, err = evaluator.EvaluateAll(".a = $name", "a: dog\n", encoder, decoder)
+	if err != nil {
+		t.Error(err)
+	} else {
+		test.AssertResult(t, expected_output, result)
+	}
+}
@@ -0,0 +1,93 @@
+package yqlib
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Variables that are set before an expression is evaluated, e.g. from the
+// command line. Each is available to the expression as $name.
+type Variables map[string]*CandidateNode
+
+// NewStringVariable creates a variable holding the given value as a string (like jq's --arg).
+func NewStringVariable(value string) *CandidateNode {
+	return createStringScalarNode(value)
+}
+
+// NewStringArrayVariable creates a variable holding an array of strings (like jq's --args).
+func NewStringArrayVariable(values []string) *CandidateNode {
+	node := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	for _, value := range values {
+		node.AddChild(createStringScalarNode(value))
+	}
+	return node
+}
+
+// ParseVariable parses the given yaml (or json) text into a variable (like jq's --argjson).
+func ParseVariable(text string) (*CandidateNode, error) {
+	decoder := NewYamlDecoder(ConfiguredYamlPreferences)
+	if err := decoder.Init(strings.NewReader(text)); err != nil {
+		return nil, err
+	}
+	node, err := decoder.Decode()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("no value given")
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Decode(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("expected a single value")
+	}
+	return node, nil
+}
+
+// ReadFileVariable creates a variable holding the raw contents of a file (like jq's --rawfile).
+func ReadFileVariable(filename string) (*CandidateNode, error) {
+	contents, err := os.ReadFile(filename) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	return createStringScalarNode(string(contents)), nil
+}
+
+// SlurpFileVariable creates a variable holding an array of all the documents in a file (like jq's --slurpfile).
+// The file is decoded based on its extension, defaulting to yaml.
+func SlurpFileVariable(filename string) (*CandidateNode, error) {
+	format, err := FormatFromString(FormatStringFromFilename(filename))
+	if err != nil {
+		return nil, err
+	}
+	if format.DecoderFactory == nil {
+		return nil, fmt.Errorf("no support for %v input format", format.FormalName)
+	}
+	reader, cleanup, err := readStream(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	documents, err := readDocuments(reader, filename, 0, format.DecoderFactory())
+	if err != nil {
+		return nil, err
+	}
+	node := &CandidateNode{Kind: SequenceNode, Tag: "!!seq"}
+	for el := documents.Front(); el != nil; el = el.Next() {
+		node.AddChild(el.Value.(*CandidateNode))
+	}
+	return node, nil
+}
+
+// newRootContext creates the context an expression is evaluated in, with the given
+// variables set. Variables are copied so that evaluating one document
+// cannot affect the next.
+func newRootContext(matchingNodes *list.List, variables Variables) Context {
+	context := Context{MatchingNodes: matchingNodes}
+	for name, value := range variables {
+		context.SetVariable(name, value.Copy().AsList())
+	}
+	return context
+}
@@ -0,0 +1,53 @@
+package yqlib
+
+import (
+	"testing"
+
+	"github.com/mikefarah/yq/v4/test"
+)
+
+func TestEvaluateNodesWithVariables(t *testing.T) {
+	name := NewStringVariable("cat")
+	value, err := ParseVariable(`{"b": [1, 2]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := NewAllAtOnceEvaluatorWithVariables(Variables{"name": name, "value": value})
+
+	results, err := evaluator.EvaluateNodes(`[$name, $value.b[1]]`, createScalarNode(nil, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertResultComplex(t, []string{"D0, P[], (!!seq)::- cat\n- 2\n"}, resultsToString(t, results))
+}
+
+func TestEvaluateNodesDoesNotUpdateVariables(t *testing.T) {
+	value, err := ParseVariable(`a: 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := NewAllAtOnceEvaluatorWithVariables(Variables{"value": value})
+
+	for i := 0; i < 2; i++ {
+		results, err := evaluator.EvaluateNodes(`$value | .a += 1`, createScalarNode(nil, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.AssertResultComplex(t, []string{"D0, P[], (!!map)::a: 2\n"}, resultsToString(t, results))
+	}
+}
+
+func TestParseVariableMultipleDocuments(t *testing.T) {
+	_, err := ParseVariable("a: 1\n---\nb: 2")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	test.AssertResult(t, "expected a single value", err.Error())
+}
+
+func TestStringArrayVariable(t *testing.T) {
+	node := NewStringArrayVariable([]string{"a", "b"})
+	test.AssertResultComplex(t, []string{"D0, P[], (!!seq)::- a\n- b\n"}, resultsToString(t, node.AsList()))
+}
@@ -9,13 +9,14 @@ import (
 func main() {
 	cmd := command.New()
 
-	args := os.Args[1:]
+	args := command.ExpandVariableFlags(os.Args[1:])
+	cmd.SetArgs(args)
 
 	_, _, err := cmd.Find(args)
 	if err != nil && args[0] != "__complete" && args[0] != "__completeNoDesc" {
 		// default command when nothing matches...
 		newArgs := []string{"eval"}
-		cmd.SetArgs(append(newArgs, os.Args[1:]...))
+		cmd.SetArgs(append(newArgs, args...))
 
 	}
 